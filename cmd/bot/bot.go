@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"virusbot/config"
+	"virusbot/internal/client"
+	"virusbot/internal/game"
+	"virusbot/internal/logging"
+	"virusbot/internal/protocol"
+	"virusbot/internal/replay"
+	"virusbot/internal/strategy"
+)
+
+// Bot is one bot instance: a client connection plus the strategy playing it
+type Bot struct {
+	name     string
+	cfg      *config.Config
+	client   *client.Client
+	strategy strategy.Strategy
+	playerID int                      // our player in the current game, set on game_start
+	recorder *strategy.PolicyRecorder // records opponent moves when configured
+	stats    *SessionStats            // session totals, shared between instances
+	events   *EventWriter             // writes events as JSON lines when configured
+	logger   logging.Logger           // prefixes every line with the bot's name
+
+	connected bool      // a connection was made before, so the next is a reconnect
+	gameStart time.Time // when the current game started
+
+	warmupMu   sync.Mutex
+	stopWarmup context.CancelFunc // cancels a running strategy warm-up
+}
+
+// NewBot creates a bot with its own client and strategy
+func NewBot(cfg *config.Config) *Bot {
+	b := &Bot{
+		name:     cfg.BotName,
+		cfg:      cfg,
+		strategy: strategy.NewStrategy(cfg),
+		stats:    NewSessionStats(),
+		logger:   logging.WithPrefix(cfg.Logger(), "["+cfg.BotName+"] "),
+	}
+	if cfg.RecordPolicyFile != "" {
+		b.recorder = strategy.NewPolicyRecorder(loadOrNewPolicy(cfg.RecordPolicyFile, b.logger))
+	}
+	b.client = client.NewClient(cfg, b.handleEvent)
+	b.client.SetLogger(b.logger)
+	if cfg.ReplayDir != "" {
+		b.client.SetRecorder(replay.NewRecorder(cfg.ReplayDir))
+	}
+	return b
+}
+
+// loadOrNewPolicy continues a policy file from earlier runs, or starts a new
+// policy if there is none
+func loadOrNewPolicy(path string, logger logging.Logger) *strategy.Policy {
+	policy, err := strategy.LoadPolicy(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Warnf("Starting a new policy: %v", err)
+		}
+		return strategy.NewPolicy()
+	}
+	return policy
+}
+
+// Name returns the bot's name
+func (b *Bot) Name() string {
+	return b.name
+}
+
+// Stats returns the session statistics the bot reports to
+func (b *Bot) Stats() *SessionStats {
+	return b.stats
+}
+
+// SetFactors replaces the heuristic weights of the bot's strategy, when it
+// has any
+func (b *Bot) SetFactors(factors strategy.EvaluationFactors) {
+	tunable, ok := b.strategy.(strategy.Tunable)
+	if !ok {
+		b.logger.Warnf("Strategy %s has no weights to change", b.strategy.Name())
+		return
+	}
+	tunable.SetFactors(factors)
+}
+
+// Shutdown asks the bot to stop once the current game, if any, has ended.
+// Run returns then; until then turns are still played.
+func (b *Bot) Shutdown() {
+	if b.client.InGame() {
+		b.logger.Infof("Finishing the current game before shutting down")
+	}
+	b.client.BeginShutdown()
+}
+
+// Client returns the bot's WebSocket client
+func (b *Bot) Client() *client.Client {
+	return b.client
+}
+
+// handleEvent logs game events reported by the client
+func (b *Bot) handleEvent(event string, data interface{}) {
+	if b.events != nil {
+		if err := b.events.Emit(b.name, event, data); err != nil {
+			b.logger.Warnf("%v", err)
+		}
+	}
+
+	switch event {
+	case "connected":
+		b.logger.Infof("Connected to game server!")
+		if b.connected {
+			b.stats.RecordReconnect()
+		}
+		b.connected = true
+		if b.cfg.LobbyID != "" {
+			b.logger.Infof("Joining lobby: %s", b.cfg.LobbyID)
+		} else if b.cfg.AutoCreate {
+			b.logger.Infof("Creating new lobby...")
+		}
+
+	case "lobby_update":
+		if msg, ok := data.(*protocol.LobbyMessage); ok {
+			b.logger.Infof("In lobby %s: %d players, %dx%d board", msg.LobbyID, len(msg.Players), msg.BoardSize, msg.BoardSize)
+		}
+
+	case "challenge":
+		b.logger.Infof("Challenge received! Auto-accepting...")
+
+	case "challenge_cancelled":
+		if msg, ok := data.(*protocol.ChallengeCancelledMessage); ok {
+			b.logger.Infof("Challenge %s was withdrawn", msg.ChallengeID)
+		}
+
+	case "challenge_declined":
+		b.logger.Infof("Declined challenge %v", data)
+
+	case "challenge_timeout":
+		b.logger.Infof("Accepted challenge %v never started a game", data)
+
+	case "challenge_sent":
+		if msg, ok := data.(*protocol.ChallengeSentMessage); ok {
+			b.logger.Infof("Challenged user %s (challenge %s)", msg.TargetUserID, msg.ChallengeID)
+		}
+
+	case "game_start":
+		b.logger.Infof("Game started!")
+		b.gameStart = time.Now()
+		b.strategy.Reset()
+		if msg, ok := data.(*client.GameState); ok {
+			b.playerID = msg.YourPlayerID
+			// Debug: log the game state
+			b.logger.Debugf("GameState from callback: Board=%v, Players=%v, CurrentPlayer=%d, YourPlayerID=%d", msg.Board != nil, msg.Players, msg.CurrentPlayer, msg.YourPlayerID)
+			b.startWarmup(msg)
+			if b.recorder != nil {
+				if state := msg.ToGame(); state != nil && state.Board != nil {
+					b.recorder.Start(state.Board, msg.YourPlayerID)
+				}
+			}
+		}
+
+	case "move_made":
+		if msg, ok := data.(*protocol.MoveMadeMessage); ok {
+			b.logger.Infof("Player %d moved to (%d, %d), movesLeft=%d", msg.Player, msg.Row, msg.Col, msg.MovesLeft)
+			// The client holds its lock during callbacks, so pass only who moved
+			mover := &game.GameState{CurrentPlayer: msg.Player, YourPlayerID: b.playerID}
+			b.strategy.OnMoveMade(mover, game.Move{Position: game.Position{Row: msg.Row, Col: msg.Col}})
+			if b.recorder != nil {
+				b.recorder.Observe(msg.Player, game.Position{Row: msg.Row, Col: msg.Col})
+			}
+			if msg.Player == b.playerID {
+				b.stats.RecordMove()
+			}
+		} else {
+			b.logger.Infof("Move made")
+		}
+
+	case "game_end":
+		b.logger.Infof("Game ended!")
+		winner := 0
+		if msg, ok := data.(*protocol.GameEndMessage); ok {
+			winner = msg.Winner
+		}
+		b.stats.RecordGame(GameResult{Winner: winner, YourPlayer: b.playerID, Duration: time.Since(b.gameStart)})
+		if b.cfg.FinalBoardFile != "" {
+			if err := b.saveFinalBoard(b.cfg.FinalBoardFile, winner); err != nil {
+				b.logger.Errorf("Failed to save final board: %v", err)
+			}
+		}
+		if b.recorder != nil {
+			if err := b.recorder.Policy().Save(b.cfg.RecordPolicyFile); err != nil {
+				b.logger.Errorf("Failed to save policy: %v", err)
+			} else {
+				b.logger.Infof("Policy with %d positions saved to %s", b.recorder.Policy().Len(), b.cfg.RecordPolicyFile)
+			}
+		}
+
+	case "disconnected":
+		b.logger.Infof("Disconnected from server")
+
+	case "reconnecting":
+		b.logger.Infof("Reconnecting to server (attempt %v)...", data)
+	}
+}
+
+// startWarmup lets the strategy prepare in the background from the initial
+// position. The state is cloned here because the client keeps mutating its
+// board once the callback returns.
+func (b *Bot) startWarmup(msg *client.GameState) {
+	w, ok := b.strategy.(strategy.WarmUpper)
+	if !ok {
+		return
+	}
+	state := msg.ToGame()
+	if state == nil || state.Board == nil {
+		return
+	}
+	state = state.Clone()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.warmupMu.Lock()
+	if b.stopWarmup != nil {
+		b.stopWarmup()
+	}
+	b.stopWarmup = cancel
+	b.warmupMu.Unlock()
+
+	go w.WarmUp(ctx, state)
+}
+
+// cancelWarmup stops a running warm-up, if any
+func (b *Bot) cancelWarmup() {
+	b.warmupMu.Lock()
+	defer b.warmupMu.Unlock()
+	if b.stopWarmup != nil {
+		b.stopWarmup()
+		b.stopWarmup = nil
+	}
+}
+
+// finalBoard is the JSON written by saveFinalBoard
+type finalBoard struct {
+	Winner     int     `json:"winner"`
+	YourPlayer int     `json:"yourPlayer"`
+	Grid       [][]int `json:"grid"`
+}
+
+// saveFinalBoard writes the current board as raw cell values to path
+func (b *Bot) saveFinalBoard(path string, winner int) error {
+	state := b.client.GetGameState().ToGame()
+	if state == nil {
+		return fmt.Errorf("no game state")
+	}
+
+	data, err := json.Marshal(finalBoard{
+		Winner:     winner,
+		YourPlayer: state.YourPlayerID,
+		Grid:       state.ExportGrid(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal final board: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	b.logger.Infof("Final board saved to %s", path)
+	return nil
+}
+
+// Run connects the bot and plays turns until the context is cancelled or
+// the connection fails. The client is always disconnected on return.
+func (b *Bot) Run(ctx context.Context) error {
+	b.logger.Infof("Using strategy: %s", b.strategy.Name())
+
+	if err := b.client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer b.client.Disconnect()
+	defer b.cancelWarmup()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Start the client in a goroutine
+	runErr := make(chan error, 1)
+	go func() {
+		err := b.client.Run()
+		if err != nil {
+			b.logger.Errorf("Client error: %v", err)
+		}
+		runErr <- err
+		cancel()
+	}()
+
+	// Main loop - handle turns as soon as the client reports one, with the
+	// ticker as a fallback for turns it could not announce
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	eliminated := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.logger.Infof("Shutting down...")
+			select {
+			case err := <-runErr:
+				return err
+			default:
+				return nil
+			}
+
+		case <-b.client.TurnStarted():
+			b.takeTurn(&eliminated)
+
+		case <-ticker.C:
+			b.takeTurn(&eliminated)
+		}
+
+		if b.client.ShuttingDown() && !b.client.InGame() {
+			b.logger.Infof("No game in progress, shutting down")
+			return nil
+		}
+	}
+}
+
+// takeTurn plays the current turn if it is ours. eliminated remembers that
+// the elimination was already logged.
+func (b *Bot) takeTurn(eliminated *bool) {
+	// Refresh game state and check if it's our turn
+	state := b.client.GetGameState()
+	if state == nil || !b.client.IsMyTurn() {
+		return
+	}
+	b.cancelWarmup()
+
+	// Once eliminated, never act again; just wait for game_end
+	if !state.ToGame().AmIAlive() {
+		if !*eliminated {
+			b.logger.Infof("We have been eliminated, waiting for the game to end")
+			*eliminated = true
+		}
+		return
+	}
+	*eliminated = false
+
+	b.logger.Infof("It's my turn!")
+	b.stats.RecordTurn(playTurn(b.client, b.strategy, b.logger))
+}
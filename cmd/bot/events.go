@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// eventLine is one event as written by an EventWriter
+type eventLine struct {
+	TS    time.Time   `json:"ts"`
+	Bot   string      `json:"bot"`
+	Event string      `json:"event"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// EventWriter writes client events as JSON, one object per line, so a
+// pipeline can follow the bots without parsing the logs. It is shared by the
+// bots of one process and safe for concurrent use.
+type EventWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewEventWriter writes events to w
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{w: w}
+}
+
+// Emit writes one event. Each line is encoded before it is written in a
+// single call, so lines from several bots never interleave.
+func (e *EventWriter) Emit(bot, event string, data interface{}) error {
+	if err, ok := data.(error); ok {
+		data = err.Error()
+	}
+	line, err := json.Marshal(eventLine{TS: time.Now().UTC(), Bot: bot, Event: event, Data: data})
+	if err != nil {
+		// Keep the event, describing what could not be encoded
+		line, err = json.Marshal(eventLine{TS: time.Now().UTC(), Bot: bot, Event: event, Data: fmt.Sprint(data)})
+		if err != nil {
+			return fmt.Errorf("failed to encode %s event: %w", event, err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := e.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", event, err)
+	}
+	return nil
+}
@@ -3,15 +3,18 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"virusbot/config"
 	"virusbot/internal/client"
 	"virusbot/internal/game"
+	"virusbot/internal/logging"
 	"virusbot/internal/protocol"
 	"virusbot/internal/strategy"
 )
@@ -40,6 +43,7 @@ func main() {
 	autoCreate := flag.Bool("create", false, "Create a new lobby")
 	autoAccept := flag.Bool("accept", false, "Auto-accept challenges")
 	debug := flag.Bool("debug", false, "Enable debug logging")
+	instances := flag.Int("instances", 1, "Number of bot instances to run in this process")
 	flag.Parse()
 
 	// Load configuration
@@ -65,244 +69,291 @@ func main() {
 		cfg.Debug = true
 	}
 
-	log.Printf("Starting Virus Bot (%s strategy)", cfg.Strategy)
-	log.Printf("Connecting to: %s", cfg.ServerURL)
-
-	// Create strategy
-	strategy := strategy.NewStrategy(cfg)
-	log.Printf("Using strategy: %s", strategy.Name())
-
-	// Create callback for handling game events
-	callback := func(event string, data interface{}) {
-		switch event {
-		case "connected":
-			log.Printf("Connected to game server!")
-			if cfg.LobbyID != "" {
-				log.Printf("Joining lobby: %s", cfg.LobbyID)
-			} else if cfg.AutoCreate {
-				log.Println("Creating new lobby...")
-			}
-
-		case "challenge":
-			log.Printf("Challenge received! Auto-accepting...")
-
-		case "game_start":
-			log.Println("Game started!")
-			// Debug: log the game state
-			if msg, ok := data.(*client.GameState); ok {
-				log.Printf("GameState from callback: Board=%v, Players=%v, CurrentPlayer=%d, YourPlayerID=%d",
-					msg.Board != nil, msg.Players, msg.CurrentPlayer, msg.YourPlayerID)
-			}
-
-		case "move_made":
-			if msg, ok := data.(*protocol.MoveMadeMessage); ok {
-				log.Printf("Player %d moved to (%d, %d), movesLeft=%d", msg.Player, msg.Row, msg.Col, msg.MovesLeft)
-			} else {
-				log.Println("Move made")
-			}
-
-		case "game_end":
-			log.Println("Game ended!")
-
-		case "disconnected":
-			log.Println("Disconnected from server")
-		}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
-
-	// Create WebSocket client
-	wsClient := client.NewClient(cfg, callback)
-
-	// Connect to server
-	if err := wsClient.Connect(); err != nil {
-		log.Fatalf("Failed to connect: %v", err)
+	if *instances < 1 {
+		log.Fatalf("Invalid -instances value %d: must be at least 1", *instances)
 	}
 
+	logger := cfg.Logger()
+	logger.Infof("Starting Virus Bot (%s strategy)", cfg.Strategy)
+	logger.Debugf("Configuration: %s", cfg)
+	logger.Infof("Connecting to: %s", cfg.ServerURL)
+
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Handle signals
+	stats := NewSessionStats()
+	bots := newBots(cfg, *instances, stats)
+	if cfg.EventsJSON {
+		events := NewEventWriter(os.Stdout)
+		for _, bot := range bots {
+			bot.events = events
+		}
+	}
+
+	// Handle signals once for every instance
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	// Start the client in a goroutine
 	go func() {
-		if err := wsClient.Run(); err != nil {
-			log.Printf("Client error: %v", err)
-			cancel()
-		}
-	}()
-
-	// Main loop - handle turns
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
 		select {
-		case <-ctx.Done():
-			log.Println("Shutting down...")
-			wsClient.Disconnect()
-			return
-
 		case <-sigChan:
-			log.Println("Received shutdown signal")
-			cancel()
-			wsClient.Disconnect()
+		case <-ctx.Done():
 			return
+		}
+		if cfg.GracefulShutdown {
+			logger.Infof("Received shutdown signal, finishing current games (signal again to quit now)")
+			shutdownGracefully(ctx, bots, sigChan, cfg.ShutdownTimeout, logger)
+		} else {
+			logger.Infof("Received shutdown signal")
+		}
+		cancel()
+	}()
 
-		case <-ticker.C:
-			// Refresh game state and check if it's our turn
-			state := wsClient.GetGameState()
-			if state == nil || !wsClient.IsMyTurn() {
-				continue
+	if cfg.WeightsFile != "" {
+		reloadWeights(bots, cfg, logger)
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for {
+				select {
+				case <-hup:
+					reloadWeights(bots, cfg, logger)
+				case <-ctx.Done():
+					return
+				}
 			}
+		}()
+	}
+	if cfg.HTTPAddr != "" {
+		go serveStatus(ctx, cfg.HTTPAddr, statusHandler(bots, stats), logger)
+	}
+	runBots(ctx, bots, logger)
+	logger.Infof("Session summary: %s", stats.Summary())
+}
 
-			log.Printf("It's my turn!")
-
-			// Execute moves - keep making moves until no more valid moves or turn ends
-			for i := 0; i < 3; i++ {
-				// Refresh game state from server
-				state := wsClient.GetGameState()
-				if state == nil || state.Board == nil {
-					log.Printf("Board is nil, stopping")
-					break
-				}
+// newBots creates n bots sharing the base configuration and reporting to
+// stats. With more than one instance each bot gets its own config copy with
+// a numbered name.
+func newBots(cfg *config.Config, n int, stats *SessionStats) []*Bot {
+	bots := make([]*Bot, 0, n)
+	for i := 0; i < n; i++ {
+		botCfg := cfg
+		if n > 1 {
+			copied := *cfg
+			copied.BotName = fmt.Sprintf("%s-%d", cfg.BotName, i+1)
+			botCfg = &copied
+		}
+		bot := NewBot(botCfg)
+		bot.stats = stats
+		bots = append(bots, bot)
+	}
+	return bots
+}
 
-				// Check if it's still our turn
-				if !wsClient.IsMyTurn() {
-					log.Printf("Turn ended")
-					break
-				}
+// shutdownGracefully lets every bot finish its current game and returns
+// once they all stopped, on a second signal, or when timeout (if not zero)
+// runs out
+func shutdownGracefully(ctx context.Context, bots []*Bot, sigChan <-chan os.Signal, timeout time.Duration, logger logging.Logger) {
+	for _, bot := range bots {
+		bot.Shutdown()
+	}
 
-				// Convert to game state with fresh board
-				gs := convertToGameState(state)
-				if gs == nil || gs.Board == nil {
-					log.Printf("Failed to convert game state")
-					break
-				}
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case <-ctx.Done():
+	case <-sigChan:
+		logger.Infof("Received second shutdown signal, quitting now")
+	case <-expired:
+		logger.Warnf("Games still running after %v, quitting anyway", timeout)
+	}
+}
 
-				// Debug: log player positions and board state
-				if cfg.Debug {
-					log.Printf("Client state - Players: %v", state.Players)
-					if gs.Board != nil {
-						log.Printf("Game state - Base positions: %v", gs.Board.BasePos)
-						// Log our cells
-						myCells := gs.Board.GetPlayerCells(state.YourPlayerID)
-						log.Printf("Our cells (player %d): %v", state.YourPlayerID, myCells)
-						// Log reachable cells
-						reachable := gs.Board.GetReachableCells(state.YourPlayerID)
-						log.Printf("Reachable cells: %v", reachable)
-					}
-				}
+// reloadWeights applies the weights file to every bot. A file that cannot be
+// read or parsed leaves the weights in use unchanged.
+func reloadWeights(bots []*Bot, cfg *config.Config, logger logging.Logger) {
+	factors, err := strategy.LoadFactors(cfg.WeightsFile, strategy.FactorsFromConfig(cfg))
+	if err != nil {
+		logger.Errorf("Keeping the current weights: %v", err)
+		return
+	}
+	for _, bot := range bots {
+		bot.SetFactors(factors)
+	}
+	logger.Infof("Loaded weights from %s", cfg.WeightsFile)
+}
 
-				// Get fresh strategy moves (1 at a time)
-				moves := strategy.DecideMoves(gs, 1)
-				if len(moves) == 0 {
-					log.Printf("No more valid moves")
-					break
-				}
+// runBots runs every bot until the context is cancelled and waits for all of
+// them to shut down. A bot whose connection fails stops on its own without
+// taking the others down.
+func runBots(ctx context.Context, bots []*Bot, logger logging.Logger) {
+	var wg sync.WaitGroup
+	for _, bot := range bots {
+		wg.Add(1)
+		go func(bot *Bot) {
+			defer wg.Done()
+			if err := bot.Run(ctx); err != nil {
+				bot.logger.Errorf("Bot stopped: %v", err)
+			}
+		}(bot)
+	}
+	wg.Wait()
+	logger.Infof("All bots stopped")
+}
 
-				move := moves[0]
-				log.Printf("Strategy suggests: (%d, %d)", move.Position.Row, move.Position.Col)
+// playTurn plans the current turn and sends it as one batch, reporting what
+// it did. Planned moves that are no longer legal are dropped. When no legal
+// action exists at all, the rest of the turn is passed so the main loop
+// does not retry the same hopeless position on every tick.
+func playTurn(wsClient *client.Client, strategy strategy.Strategy, logger logging.Logger) turnReport {
+	var report turnReport
+
+	// Refresh game state from server
+	state := wsClient.GetGameState()
+	if state == nil || state.Board == nil {
+		logger.Warnf("Board is nil, stopping")
+		return report
+	}
 
-				// Double-check the move is valid before executing
-				if !isValidMove(state.Board, state.YourPlayerID, move.Position.Row, move.Position.Col) {
-					log.Printf("Skipping invalid move to (%d, %d) - cell is occupied by player %d",
-						move.Position.Row, move.Position.Col, state.Board[move.Position.Row][move.Position.Col])
-					// Get new moves excluding this invalid one
-					moves = strategy.DecideMoves(gs, 3)
-					foundValid := false
-					for _, m := range moves {
-						if isValidMove(state.Board, state.YourPlayerID, m.Position.Row, m.Position.Col) {
-							move = m
-							foundValid = true
-							break
-						}
-					}
-					if !foundValid {
-						log.Printf("No valid moves available")
-						break
-					}
-					log.Printf("Using alternative move: (%d, %d)", move.Position.Row, move.Position.Col)
-				}
+	// Check if it's still our turn
+	if !wsClient.IsMyTurn() {
+		logger.Infof("Turn ended")
+		return report
+	}
 
-				if err := wsClient.MakeMove(move.Position.Row, move.Position.Col); err != nil {
-					log.Printf("Failed to make move: %v", err)
-				} else {
-					log.Printf("Made move: (%d, %d)", move.Position.Row, move.Position.Col)
-				}
-				time.Sleep(cfg.MoveDelay)
-			}
-		}
+	// Without the bases every cell looks reachable; wait for the board
+	if state.BasesPending {
+		logger.Debugf("Base positions unknown, waiting for the server's board")
+		return report
 	}
-}
 
-// convertToGameState converts the client.GameState to game.GameState
-func convertToGameState(cs *client.GameState) *game.GameState {
-	if cs == nil {
-		return nil
+	// Convert to game state with fresh board
+	gs := state.ToGame()
+	if gs == nil || gs.Board == nil {
+		logger.Errorf("Failed to convert game state")
+		return report
 	}
 
-	// Build base positions from players if available, or discover from board
-	basePos := make(map[int]game.Position)
+	// Debug: log player positions and board state, which take a render and
+	// a search to build
+	if logging.Enabled(logger, logging.LevelDebug) {
+		logger.Debugf("Board:\n%s", gs.Board.Render())
+		logger.Debugf("Client state - Players: %v", state.Players)
+		logger.Debugf("Game state - Base positions: %v", gs.Board.BasePos)
+		logger.Debugf("Our cells (player %d): %v", state.YourPlayerID, gs.Board.GetPlayerCells(state.YourPlayerID))
+		logger.Debugf("Reachable cells: %v", gs.Board.GetReachableCells(state.YourPlayerID))
+	}
 
-	// First try to get base positions from player info
-	if cs.Players != nil {
-		for _, p := range cs.Players {
-			// Check if position is valid (not the placeholder -1, -1)
-			if p.Position.Row >= 0 && p.Position.Col >= 0 {
-				basePos[p.ID] = game.Position{
-					Row: p.Position.Row,
-					Col: p.Position.Col,
-				}
-			}
+	// Plan the rest of the turn
+	movesLeft := wsClient.GetMovesLeft()
+	if movesLeft == 0 {
+		logger.Infof("No moves left this turn")
+		return report
+	}
+	gs.MovesLeft = movesLeft
+	blockRejected(gs, wsClient.RejectedMoves())
+	remaining, budgeted := wsClient.TurnTimeLeft()
+	moves := report.decide(strategy, gs, movesLeft, remaining, budgeted)
+	if len(moves) == 0 {
+		if placeNeutrals(wsClient, strategy, gs, logger) {
+			return report
 		}
+		logger.Infof("No more valid moves, passing the rest of the turn")
+		wsClient.PassTurn()
+		return report
 	}
-
-	// If base positions are not available from player info, discover from board
-	// by finding the first cell owned by each player
-	if cs.Board != nil && len(basePos) == 0 {
-		for row := 0; row < len(cs.Board); row++ {
-			for col := 0; col < len(cs.Board[row]); col++ {
-				cellType := cs.Board[row][col]
-				// Extract player ID using Player() method (handles flag bits)
-				playerID := cellType.Player()
-				if playerID >= 1 && playerID <= 4 {
-					// Only set if not already found
-					if _, exists := basePos[playerID]; !exists {
-						basePos[playerID] = game.Position{Row: row, Col: col}
-					}
-				}
-			}
+	if moves[0].Type == game.MoveNeutral {
+		// The search chose to spend the turn on neutrals
+		if !sendNeutrals(wsClient, []game.Position{moves[0].Position, moves[0].Pair}, logger) {
+			wsClient.PassTurn()
+		}
+		return report
+	}
+	logger.Debugf("Chosen moves:\n%s", gs.Board.RenderWithMoves(moves, state.YourPlayerID))
+
+	// Double-check each move is valid before executing
+	positions := make([]game.Position, 0, len(moves))
+	planned := make(map[game.Position]bool, len(moves))
+	for _, move := range moves {
+		if planned[move.Position] || wsClient.IsRejected(move.Position.Row, move.Position.Col) ||
+			!isValidMove(state.Board, state.YourPlayerID, move.Position.Row, move.Position.Col) {
+			logger.Warnf("Skipping invalid move to (%d, %d) - off the board, taken, refused or already planned",
+				move.Position.Row, move.Position.Col)
+			continue
 		}
+		planned[move.Position] = true
+		positions = append(positions, move.Position)
+		logger.Infof("Strategy suggests: (%d, %d)", move.Position.Row, move.Position.Col)
+	}
+	if len(positions) == 0 {
+		logger.Infof("No valid moves available, passing the rest of the turn")
+		wsClient.PassTurn()
+		return report
 	}
 
-	// Handle nil Players (new protocol format)
-	var players []*game.Player
-	if cs.Players != nil {
-		players = make([]*game.Player, len(cs.Players))
-		for i, p := range cs.Players {
-			// Use discovered base position if available
-			basePosition := game.Position{Row: p.Position.Row, Col: p.Position.Col}
-			if pos, exists := basePos[p.ID]; exists {
-				basePosition = pos
-			}
-			players[i] = &game.Player{
-				ID:      p.ID,
-				Name:    p.Name,
-				Symbol:  p.Symbol,
-				BasePos: basePosition,
-				IsAlive: true,
-			}
+	if err := wsClient.MakeMoves(positions); err != nil {
+		logger.Errorf("Failed to make moves: %v", err)
+	}
+	report.Latency = wsClient.AverageMoveLatency()
+	return report
+}
+
+// blockRejected turns the cells the server refused us this turn into
+// neutrals on our copy of the board, so the strategy plans around them
+// instead of choosing them again
+func blockRejected(gs *game.GameState, rejected []protocol.Position) {
+	for _, pos := range rejected {
+		p := game.Position{Row: pos.Row, Col: pos.Col}
+		if gs.Board.IsValid(p) {
+			gs.Board.SetCell(p, protocol.CellNeutral)
 		}
 	}
+}
+
+// placeNeutrals spends our once-per-game neutrals on a turn we would
+// otherwise pass, where the strategy finds two positions for them. It
+// reports whether they were placed, which ends the turn.
+func placeNeutrals(wsClient *client.Client, strategy strategy.Strategy, gs *game.GameState, logger logging.Logger) bool {
+	neutrals := strategy.DecideNeutrals(gs)
+	if len(neutrals) < 2 {
+		return false
+	}
+	return sendNeutrals(wsClient, neutrals[:2], logger)
+}
 
-	board := game.NewBoardFromData(cs.Board, basePos)
+// sendNeutrals places neutrals on two cells, reporting whether the server
+// was sent the placement
+func sendNeutrals(wsClient *client.Client, neutrals []game.Position, logger logging.Logger) bool {
+	positions := make([]protocol.Position, 2)
+	for i, pos := range neutrals {
+		positions[i] = protocol.Position{Row: pos.Row, Col: pos.Col}
+	}
+	if err := wsClient.PlaceNeutrals(positions); err != nil {
+		logger.Errorf("Failed to place neutrals: %v", err)
+		return false
+	}
+	logger.Infof("Placed neutrals at %v", positions)
+	return true
+}
 
-	return &game.GameState{
-		Board:         board,
-		Players:       players,
-		CurrentPlayer: cs.CurrentPlayer,
-		YourPlayerID:  cs.YourPlayerID,
+// decide asks the strategy for moves, timing the decision. When the turn
+// has a time budget, strategies that can are held to what is left of it.
+func (r *turnReport) decide(s strategy.Strategy, gs *game.GameState, count int, remaining time.Duration, budgeted bool) []game.Move {
+	start := time.Now()
+	var moves []game.Move
+	if b, ok := s.(strategy.Budgeted); ok && budgeted {
+		moves = b.DecideMovesWithin(gs, count, remaining)
+	} else {
+		moves = s.DecideMoves(gs, count)
 	}
+	r.Decisions++
+	r.Thinking += time.Since(start)
+	return moves
 }
@@ -0,0 +1,590 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"virusbot/config"
+	"virusbot/internal/client"
+	"virusbot/internal/game"
+	"virusbot/internal/logging"
+	"virusbot/internal/strategy"
+
+	"github.com/gorilla/websocket"
+)
+
+// startMockServer serves a WebSocket that sends the given messages on connect
+// and forwards everything the bot sends to the returned channel
+func startMockServer(t *testing.T, messages ...string) (string, <-chan []byte) {
+	t.Helper()
+
+	received := make(chan []byte, 100)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, m := range messages {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+				return
+			}
+		}
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- data
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http"), received
+}
+
+// connectBot connects a client to the mock server and waits for game_start
+func connectBot(t *testing.T, cfg *config.Config) *client.Client {
+	t.Helper()
+
+	wsClient := client.NewClient(cfg, nil)
+	if err := wsClient.Connect(); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(wsClient.Disconnect)
+	go wsClient.Run()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for wsClient.GetGameState() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for game_start")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return wsClient
+}
+
+func TestPlayTurnPlacesNeutralsInsteadOfPassing(t *testing.T) {
+	// No grow or attack is left, but two plain cells can become neutrals
+	gameStart := `{"type":"game_start","board":[[17,34,33],[33,34,34],[1,1,18]],` +
+		`"players":[{"id":1,"position":{"row":0,"col":0}},{"id":2,"position":{"row":2,"col":2}}],` +
+		`"currentPlayer":1,"yourPlayerId":1}`
+	url, received := startMockServer(t, gameStart)
+
+	cfg := &config.Config{ServerURL: url}
+	wsClient := connectBot(t, cfg)
+	playTurn(wsClient, strategy.NewHeuristicStrategy(cfg), logging.Nop())
+
+	select {
+	case data := <-received:
+		expected := `{"type":"place_neutrals","gameId":"","positions":[{"row":2,"col":0},{"row":2,"col":1}]}`
+		if string(data) != expected {
+			t.Errorf("Expected %s, got %s", expected, data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected neutrals to be placed")
+	}
+	if wsClient.IsMyTurn() {
+		t.Error("Expected placing neutrals to end the turn")
+	}
+}
+
+func TestPlayTurnPassesOnFullBoard(t *testing.T) {
+	// Every cell is taken, none of player 2's cells can be attacked and we
+	// have no plain cells to turn into neutrals
+	gameStart := `{"type":"game_start","board":[[17,34,33],[33,34,34],[33,33,18]],` +
+		`"players":[{"id":1,"position":{"row":0,"col":0}},{"id":2,"position":{"row":2,"col":2}}],` +
+		`"currentPlayer":1,"yourPlayerId":1}`
+	url, received := startMockServer(t, gameStart)
+
+	cfg := &config.Config{ServerURL: url}
+	wsClient := connectBot(t, cfg)
+	strat := strategy.NewHeuristicStrategy(cfg)
+
+	if !wsClient.IsMyTurn() {
+		t.Fatal("Expected it to be our turn")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		playTurn(wsClient, strat, logging.Nop())
+		playTurn(wsClient, strat, logging.Nop())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("playTurn did not return on a full board")
+	}
+
+	if wsClient.IsMyTurn() {
+		t.Error("Expected the turn to be passed when no legal action exists")
+	}
+	select {
+	case data := <-received:
+		t.Errorf("Expected no move to be sent, got %s", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRunBotsPlaysEveryInstance(t *testing.T) {
+	gameStart := `{"type":"game_start","board":[[17,0,0],[0,0,0],[0,0,18]],` +
+		`"players":[{"id":1,"position":{"row":0,"col":0}},{"id":2,"position":{"row":2,"col":2}}],` +
+		`"currentPlayer":1,"yourPlayerId":1}`
+
+	// Each connection reports its first move on its own channel
+	var mu sync.Mutex
+	moves := make([]chan struct{}, 0)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		played := make(chan struct{})
+		mu.Lock()
+		moves = append(moves, played)
+		mu.Unlock()
+
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(gameStart)); err != nil {
+			return
+		}
+		var once sync.Once
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if strings.Contains(string(data), `"type":"move"`) {
+				once.Do(func() { close(played) })
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{
+		ServerURL: "ws" + strings.TrimPrefix(server.URL, "http"),
+		BotName:   "TestBot",
+		Strategy:  "heuristic",
+	}
+	bots := newBots(cfg, 2, NewSessionStats())
+	if bots[0].Name() == bots[1].Name() {
+		t.Errorf("Expected distinct bot names, both are %q", bots[0].Name())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		runBots(ctx, bots, logging.Nop())
+		close(done)
+	}()
+
+	deadline := time.After(3 * time.Second)
+	for i := 0; i < 2; i++ {
+		for {
+			mu.Lock()
+			n := len(moves)
+			mu.Unlock()
+			if n > i {
+				break
+			}
+			select {
+			case <-deadline:
+				t.Fatalf("Only %d of 2 bots connected", n)
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+		mu.Lock()
+		played := moves[i]
+		mu.Unlock()
+		select {
+		case <-played:
+		case <-deadline:
+			t.Fatalf("Bot connection %d never made a move", i)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("runBots did not return after cancellation")
+	}
+}
+
+// firstMoveStrategy plays the first valid move, one per decision
+type firstMoveStrategy struct {
+	*strategy.HeuristicStrategy
+}
+
+func (s firstMoveStrategy) DecideMoves(state *game.GameState, count int) []game.Move {
+	moves := state.Board.GetValidMoves(state.YourPlayerID)
+	if len(moves) == 0 {
+		return nil
+	}
+	return moves[:1]
+}
+
+func TestPlayTurnAvoidsRejectedCell(t *testing.T) {
+	gameStart := `{"type":"game_start","board":[[17,0,0],[0,0,0],[0,0,18]],` +
+		`"players":[{"id":1,"position":{"row":0,"col":0}},{"id":2,"position":{"row":2,"col":2}}],` +
+		`"currentPlayer":1,"yourPlayerId":1}`
+
+	// The server refuses the first move and echoes the rest
+	moves := make(chan string, 10)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(gameStart)); err != nil {
+			return
+		}
+		for sent := 0; ; {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var move struct {
+				Type string `json:"type"`
+				Row  int    `json:"row"`
+				Col  int    `json:"col"`
+			}
+			if err := json.Unmarshal(data, &move); err != nil || move.Type != "move" {
+				continue
+			}
+			moves <- fmt.Sprintf("%d,%d", move.Row, move.Col)
+			reply := `{"type":"error","message":"Invalid move"}`
+			if sent++; sent > 1 {
+				reply = fmt.Sprintf(`{"type":"move_made","row":%d,"col":%d,"player":1,"movesLeft":2}`, move.Row, move.Col)
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(reply))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{
+		ServerURL:          "ws" + strings.TrimPrefix(server.URL, "http"),
+		MoveConfirmTimeout: time.Second,
+	}
+	wsClient := connectBot(t, cfg)
+	strat := firstMoveStrategy{strategy.NewHeuristicStrategy(cfg)}
+
+	playTurn(wsClient, strat, logging.Nop())
+	rejected := <-moves
+
+	playTurn(wsClient, strat, logging.Nop())
+	select {
+	case move := <-moves:
+		if move == rejected {
+			t.Errorf("Expected a different cell than the rejected %s", rejected)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected another move instead of passing the turn")
+	}
+}
+
+func TestPlayTurnWaitsForEachMoveEcho(t *testing.T) {
+	gameStart := `{"type":"game_start","board":[[17,0,0],[0,0,0],[0,0,18]],` +
+		`"players":[{"id":1,"position":{"row":0,"col":0}},{"id":2,"position":{"row":2,"col":2}}],` +
+		`"currentPlayer":1,"yourPlayerId":1}`
+
+	// The server echoes each move after a delay and records any move that
+	// arrives while an earlier one is still unconfirmed
+	var mu sync.Mutex
+	var moves []string
+	outstanding, early := 0, 0
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(gameStart)); err != nil {
+			return
+		}
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var move struct {
+				Type string `json:"type"`
+				Row  int    `json:"row"`
+				Col  int    `json:"col"`
+			}
+			if err := json.Unmarshal(data, &move); err != nil || move.Type != "move" {
+				continue
+			}
+
+			mu.Lock()
+			if outstanding > 0 {
+				early++
+			}
+			outstanding++
+			moves = append(moves, fmt.Sprintf("%d,%d", move.Row, move.Col))
+			movesLeft := 3 - len(moves)
+			mu.Unlock()
+
+			go func() {
+				time.Sleep(30 * time.Millisecond)
+				echo := fmt.Sprintf(`{"type":"move_made","row":%d,"col":%d,"player":1,"movesLeft":%d}`,
+					move.Row, move.Col, movesLeft)
+				mu.Lock()
+				defer mu.Unlock()
+				outstanding--
+				conn.WriteMessage(websocket.TextMessage, []byte(echo))
+			}()
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{
+		ServerURL:          "ws" + strings.TrimPrefix(server.URL, "http"),
+		MoveConfirmTimeout: time.Second,
+	}
+	wsClient := connectBot(t, cfg)
+	playTurn(wsClient, strategy.NewHeuristicStrategy(cfg), logging.Nop())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(moves) != 3 {
+		t.Fatalf("Expected 3 moves, got %d: %v", len(moves), moves)
+	}
+	if early != 0 {
+		t.Errorf("Expected every move to wait for the previous echo, %d were sent early", early)
+	}
+	seen := make(map[string]bool)
+	for _, m := range moves {
+		if seen[m] {
+			t.Errorf("Move to %s was sent twice", m)
+		}
+		seen[m] = true
+	}
+}
+
+func TestBotStopsActingWhenEliminated(t *testing.T) {
+	// It is nominally our turn, but player 2 owns every live cell
+	gameStart := `{"type":"game_start","board":[[34,0,0],[0,0,0],[0,0,18]],` +
+		`"players":[{"id":1,"position":{"row":0,"col":0}},{"id":2,"position":{"row":2,"col":2}}],` +
+		`"currentPlayer":1,"yourPlayerId":1}`
+	url, received := startMockServer(t, gameStart)
+
+	bot := NewBot(&config.Config{ServerURL: url, BotName: "TestBot", Strategy: "heuristic"})
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	if err := bot.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	select {
+	case data := <-received:
+		t.Errorf("Expected an eliminated bot to send nothing, got %s", data)
+	default:
+	}
+}
+
+func TestSmallBoardHandlePathNeverPanics(t *testing.T) {
+	// Out-of-range and negative coordinates must be dropped, not indexed
+	url, received := startMockServer(t,
+		`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":3,"cols":3}`,
+		`{"type":"move_made","row":-1,"col":0,"player":2,"movesLeft":2}`,
+		`{"type":"move_made","row":0,"col":-1,"player":2,"movesLeft":2}`,
+		`{"type":"move_made","row":2,"col":9,"player":2,"movesLeft":2}`,
+		`{"type":"move_made","row":8,"col":9,"player":2,"movesLeft":2}`,
+	)
+
+	cfg := &config.Config{ServerURL: url, Debug: true, MoveConfirmTimeout: 20 * time.Millisecond}
+	wsClient := connectBot(t, cfg)
+	strat := strategy.NewHeuristicStrategy(cfg)
+
+	if err := wsClient.MakeMove(-1, 7); err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	<-received
+
+	done := make(chan struct{})
+	go func() {
+		playTurn(wsClient, strat, logging.Nop())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("playTurn did not return")
+	}
+
+	if board := wsClient.GetGameState().Board; len(board) != 3 || len(board[0]) != 3 {
+		t.Errorf("Expected the 3x3 board to be intact, got %v", board)
+	}
+}
+
+func TestSessionStatsSummarizesGames(t *testing.T) {
+	stats := NewSessionStats()
+	stats.RecordGame(GameResult{Winner: 1, YourPlayer: 1, Duration: time.Minute})
+	stats.RecordGame(GameResult{Winner: 2, YourPlayer: 1, Duration: time.Minute})
+	stats.RecordGame(GameResult{Winner: 0, YourPlayer: 2})
+	stats.RecordTurn(turnReport{Decisions: 3, Thinking: 30 * time.Millisecond})
+	stats.RecordTurn(turnReport{Decisions: 1, Thinking: 10 * time.Millisecond, Latency: 4 * time.Millisecond})
+	for i := 0; i < 5; i++ {
+		stats.RecordMove()
+	}
+	stats.RecordReconnect()
+
+	summary := stats.Summary()
+	if summary.Games != 3 || summary.Wins != 1 || summary.Losses != 1 || summary.Draws != 1 {
+		t.Errorf("Expected 3 games with 1 win, 1 loss and 1 draw, got %+v", summary)
+	}
+	if summary.Moves != 5 || summary.Reconnects != 1 {
+		t.Errorf("Expected 5 moves and 1 reconnect, got %+v", summary)
+	}
+	if summary.AverageDecision != 10*time.Millisecond {
+		t.Errorf("Expected an average decision of 10ms, got %s", summary.AverageDecision)
+	}
+	if summary.MoveLatency != 4*time.Millisecond {
+		t.Errorf("Expected turns without a latency to be left out of the 4ms average, got %s", summary.MoveLatency)
+	}
+	if summary.Uptime <= 0 {
+		t.Errorf("Expected a positive uptime, got %s", summary.Uptime)
+	}
+}
+
+func TestStatusEndpointReportsBotsAndSession(t *testing.T) {
+	stats := NewSessionStats()
+	stats.RecordGame(GameResult{Winner: 1, YourPlayer: 1})
+	bots := newBots(&config.Config{BotName: "TestBot", Strategy: "heuristic"}, 1, stats)
+	gameStart := `{"type":"game_start","gameId":"g7","board":[[17,1,0],[0,0,0],[0,0,18]],` +
+		`"players":[{"id":1,"position":{"row":0,"col":0}},{"id":2,"position":{"row":2,"col":2}}],` +
+		`"currentPlayer":1,"yourPlayerId":1}`
+	if err := bots[0].Client().HandleMessage([]byte(gameStart)); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+
+	server := httptest.NewServer(statusHandler(bots, stats))
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/status")
+	if err != nil {
+		t.Fatalf("GET /status failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON, got %q", ct)
+	}
+
+	var page struct {
+		Bots []struct {
+			Name          string         `json:"name"`
+			Connected     bool           `json:"connected"`
+			InGame        bool           `json:"inGame"`
+			GameID        string         `json:"gameId"`
+			CurrentPlayer int            `json:"currentPlayer"`
+			MyTurn        bool           `json:"myTurn"`
+			Cells         map[string]int `json:"cells"`
+			Board         string         `json:"board"`
+		} `json:"bots"`
+		Session struct {
+			Games  int    `json:"games"`
+			Wins   int    `json:"wins"`
+			Uptime string `json:"uptime"`
+		} `json:"session"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("Invalid status JSON: %v", err)
+	}
+	if len(page.Bots) != 1 {
+		t.Fatalf("Expected one bot, got %+v", page.Bots)
+	}
+	bot := page.Bots[0]
+	if bot.Name != "TestBot" || bot.Connected || !bot.InGame || bot.GameID != "g7" || !bot.MyTurn || bot.CurrentPlayer != 1 {
+		t.Errorf("Unexpected bot status %+v", bot)
+	}
+	if bot.Cells["1"] != 2 || bot.Cells["2"] != 1 || bot.Board == "" {
+		t.Errorf("Expected 2 and 1 cells and a rendered board, got %v\n%s", bot.Cells, bot.Board)
+	}
+	if page.Session.Games != 1 || page.Session.Wins != 1 || page.Session.Uptime == "" {
+		t.Errorf("Unexpected session status %+v", page.Session)
+	}
+}
+
+func TestEventsAreWrittenAsJSONLines(t *testing.T) {
+	var out bytes.Buffer
+	bot := NewBot(&config.Config{BotName: "TestBot", Strategy: "heuristic"})
+	bot.events = NewEventWriter(&out)
+
+	messages := []string{
+		`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":3,"cols":3}`,
+		`{"type":"move_made","gameId":"g1","row":2,"col":2,"player":2,"movesLeft":2}`,
+		`{"type":"game_end","winner":2}`,
+	}
+	for _, m := range messages {
+		if err := bot.Client().HandleMessage([]byte(m)); err != nil {
+			t.Fatalf("HandleMessage failed: %v", err)
+		}
+	}
+
+	var events []string
+	for scanner := bufio.NewScanner(&out); scanner.Scan(); {
+		var line struct {
+			TS    time.Time       `json:"ts"`
+			Bot   string          `json:"bot"`
+			Event string          `json:"event"`
+			Data  json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Expected a JSON object per line, got %q: %v", scanner.Text(), err)
+		}
+		if line.TS.IsZero() || line.Bot != "TestBot" {
+			t.Errorf("Expected a timestamp and the bot name, got %s", scanner.Text())
+		}
+		if line.Event == "move_made" && !bytes.Contains(line.Data, []byte(`"row":2`)) {
+			t.Errorf("Expected the move in the event data, got %s", line.Data)
+		}
+		events = append(events, line.Event)
+	}
+	if want := []string{"game_start", "move_made", "game_end"}; strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected events %v, got %v", want, events)
+	}
+}
+
+func TestEventWriterKeepsConcurrentLinesWhole(t *testing.T) {
+	var out bytes.Buffer
+	events := NewEventWriter(&out)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				events.Emit(fmt.Sprintf("bot-%d", i), "move_made", map[string]int{"row": i, "col": j})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := 0
+	for scanner := bufio.NewScanner(&out); scanner.Scan(); lines++ {
+		if !json.Valid(scanner.Bytes()) {
+			t.Fatalf("Expected whole JSON lines, got %q", scanner.Text())
+		}
+	}
+	if lines != 400 {
+		t.Errorf("Expected 400 lines, got %d", lines)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// GameResult is the outcome of one finished game
+type GameResult struct {
+	Winner     int // 0 when the game ended without a winner
+	YourPlayer int
+	Duration   time.Duration
+}
+
+// Won reports whether we won the game
+func (r GameResult) Won() bool {
+	return r.Winner != 0 && r.Winner == r.YourPlayer
+}
+
+// turnReport is how playTurn spent one turn deciding
+type turnReport struct {
+	Decisions int           // calls to the strategy
+	Thinking  time.Duration // time spent in the strategy
+	Latency   time.Duration // the client's average move round trip after the turn, zero if unknown
+}
+
+// SessionStats accumulates results across every game of a session. It is
+// shared by the bots of one process and safe for concurrent use.
+type SessionStats struct {
+	mu         sync.Mutex
+	started    time.Time
+	games      int
+	wins       int
+	losses     int
+	draws      int
+	moves      int
+	reconnects int
+	decisions  int
+	thinking   time.Duration
+	latencies  int // turns with a known move latency
+	latency    time.Duration
+}
+
+// SessionSummary is a snapshot of the session statistics
+type SessionSummary struct {
+	Games           int
+	Wins            int
+	Losses          int
+	Draws           int
+	Moves           int
+	Reconnects      int
+	Uptime          time.Duration
+	AverageDecision time.Duration
+	MoveLatency     time.Duration // average of the turns' move latencies
+}
+
+// NewSessionStats starts a session now
+func NewSessionStats() *SessionStats {
+	return &SessionStats{started: time.Now()}
+}
+
+// RecordGame adds a finished game to the win/loss record
+func (s *SessionStats) RecordGame(result GameResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.games++
+	switch {
+	case result.Winner == 0:
+		s.draws++
+	case result.Won():
+		s.wins++
+	default:
+		s.losses++
+	}
+}
+
+// RecordMove counts one of our moves confirmed by the server
+func (s *SessionStats) RecordMove() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.moves++
+}
+
+// RecordTurn adds the decision time of one turn
+func (s *SessionStats) RecordTurn(report turnReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decisions += report.Decisions
+	s.thinking += report.Thinking
+	if report.Latency > 0 {
+		s.latencies++
+		s.latency += report.Latency
+	}
+}
+
+// RecordReconnect counts a connection made after the first
+func (s *SessionStats) RecordReconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reconnects++
+}
+
+// Summary returns the statistics so far
+func (s *SessionStats) Summary() SessionSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summary := SessionSummary{
+		Games:      s.games,
+		Wins:       s.wins,
+		Losses:     s.losses,
+		Draws:      s.draws,
+		Moves:      s.moves,
+		Reconnects: s.reconnects,
+		Uptime:     time.Since(s.started),
+	}
+	if s.decisions > 0 {
+		summary.AverageDecision = s.thinking / time.Duration(s.decisions)
+	}
+	if s.latencies > 0 {
+		summary.MoveLatency = s.latency / time.Duration(s.latencies)
+	}
+	return summary
+}
+
+// String formats the summary for the shutdown log
+func (s SessionSummary) String() string {
+	return fmt.Sprintf("%d games (%d won, %d lost, %d drawn), %d moves, %d reconnects, uptime %s, average decision %s, move latency %s",
+		s.Games, s.Wins, s.Losses, s.Draws, s.Moves, s.Reconnects,
+		s.Uptime.Round(time.Second), s.AverageDecision.Round(time.Microsecond), s.MoveLatency.Round(time.Microsecond))
+}
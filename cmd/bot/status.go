@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"virusbot/internal/client"
+	"virusbot/internal/logging"
+)
+
+// botStatus is one bot's entry on the status page
+type botStatus struct {
+	Name string `json:"name"`
+	client.Status
+}
+
+// sessionStatus is the session summary as shown on the status page
+type sessionStatus struct {
+	Games           int    `json:"games"`
+	Wins            int    `json:"wins"`
+	Losses          int    `json:"losses"`
+	Draws           int    `json:"draws"`
+	Moves           int    `json:"moves"`
+	Reconnects      int    `json:"reconnects"`
+	Uptime          string `json:"uptime"`
+	AverageDecision string `json:"averageDecision"`
+	MoveLatency     string `json:"moveLatency"`
+}
+
+// statusPage is the JSON served at /status
+type statusPage struct {
+	Bots    []botStatus   `json:"bots"`
+	Session sessionStatus `json:"session"`
+}
+
+// statusHandler serves the state of every bot and the session statistics
+// as JSON at /status
+func statusHandler(bots []*Bot, stats *SessionStats) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		page := statusPage{Bots: make([]botStatus, 0, len(bots))}
+		for _, bot := range bots {
+			page.Bots = append(page.Bots, botStatus{Name: bot.Name(), Status: bot.Client().Status()})
+		}
+		summary := stats.Summary()
+		page.Session = sessionStatus{
+			Games:           summary.Games,
+			Wins:            summary.Wins,
+			Losses:          summary.Losses,
+			Draws:           summary.Draws,
+			Moves:           summary.Moves,
+			Reconnects:      summary.Reconnects,
+			Uptime:          summary.Uptime.Round(time.Second).String(),
+			AverageDecision: summary.AverageDecision.Round(time.Microsecond).String(),
+			MoveLatency:     summary.MoveLatency.Round(time.Microsecond).String(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	})
+	return mux
+}
+
+// serveStatus serves the status page on addr until ctx is cancelled
+func serveStatus(ctx context.Context, addr string, handler http.Handler, logger logging.Logger) {
+	server := &http.Server{Addr: addr, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Infof("Serving status on http://%s/status", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Errorf("Status server failed: %v", err)
+	}
+}
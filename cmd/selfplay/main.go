@@ -0,0 +1,41 @@
+// Command selfplay pits two strategies against each other offline and
+// reports their win rates. Strategy settings come from the usual
+// VIRUSBOT_* environment.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"virusbot/config"
+	"virusbot/internal/selfplay"
+	"virusbot/internal/strategy"
+)
+
+func main() {
+	first := flag.String("first", "heuristic", "First strategy")
+	second := flag.String("second", "mcts", "Second strategy")
+	size := flag.Int("size", 10, "Board size")
+	games := flag.Int("games", 20, "Number of games")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if *size < 2 || *games < 1 {
+		log.Fatalf("Invalid -size %d or -games %d", *size, *games)
+	}
+
+	a, ok := strategy.NewStrategyByName(*first, cfg)
+	if !ok {
+		log.Fatalf("Unknown strategy %q", *first)
+	}
+	b, ok := strategy.NewStrategyByName(*second, cfg)
+	if !ok {
+		log.Fatalf("Unknown strategy %q", *second)
+	}
+
+	log.Printf("Playing %d games of %s against %s on a %dx%d board", *games, a.Name(), b.Name(), *size, *size)
+	log.Printf("%s", selfplay.Play(a, b, *size, *games))
+}
@@ -1,17 +1,33 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"virusbot/internal/logging"
 )
 
 // Config holds all configuration for the bot
 type Config struct {
 	// Server connection
-	ServerURL string `env:"VIRUSBOT_SERVER_URL" default:"ws://localhost:8080/ws"`
+	ServerURL string `env:"VIRUSBOT_SERVER_URL" default:"ws://localhost:8080/ws" mask:"url"`
+	AuthToken string `env:"VIRUSBOT_AUTH_TOKEN" mask:"all"`
+
+	// Skip TLS certificate verification, for self-signed wss:// servers
+	TLSInsecure bool `env:"VIRUSBOT_TLS_INSECURE"`
+
+	// Address of the JSON status endpoint, e.g. ":8081"; empty disables it
+	HTTPAddr string `env:"VIRUSBOT_HTTP_ADDR"`
+
+	// Write every client event to stdout as a line of JSON
+	EventsJSON bool `env:"VIRUSBOT_EVENTS_JSON"`
 
 	// Bot identity
 	BotName string `env:"VIRUSBOT_NAME" default:"VirusBot"`
@@ -21,18 +37,68 @@ type Config struct {
 	AutoJoin   bool   `env:"VIRUSBOT_AUTO_JOIN"`
 	AutoCreate bool   `env:"VIRUSBOT_AUTO_CREATE"`
 
+	// Finish the current game on SIGINT/SIGTERM instead of quitting at once
+	GracefulShutdown bool          `env:"VIRUSBOT_GRACEFUL_SHUTDOWN"`
+	ShutdownTimeout  time.Duration `env:"VIRUSBOT_SHUTDOWN_TIMEOUT" default:"5m"`
+
 	// Game behavior
-	MoveDelay          time.Duration `env:"VIRUSBOT_MOVE_DELAY" default:"500ms"`
-	Debug              bool          `env:"VIRUSBOT_DEBUG"`
-	AutoAcceptChallenge bool         `env:"VIRUSBOT_AUTO_ACCEPT_CHALLENGE" default:"true"`
+	MoveDelay           time.Duration `env:"VIRUSBOT_MOVE_DELAY" default:"500ms"`
+	AdaptiveMoveDelay   bool          `env:"VIRUSBOT_ADAPTIVE_MOVE_DELAY"`
+	Debug               bool          `env:"VIRUSBOT_DEBUG"`
+	LogLevel            string        `env:"VIRUSBOT_LOG_LEVEL" default:"info"`
+	AutoAcceptChallenge bool          `env:"VIRUSBOT_AUTO_ACCEPT_CHALLENGE" default:"true"`
+	AutoDecline         bool          `env:"VIRUSBOT_AUTO_DECLINE"`
+	DeclineUsers        []string      `env:"VIRUSBOT_DECLINE_USERS"`
+	AcceptDelay         time.Duration `env:"VIRUSBOT_ACCEPT_DELAY" default:"0"`
+	ChallengeTimeout    time.Duration `env:"VIRUSBOT_CHALLENGE_TIMEOUT" default:"10s"`
+	AutoChallenge       bool          `env:"VIRUSBOT_AUTO_CHALLENGE"`
+	ChallengeTarget     string        `env:"VIRUSBOT_CHALLENGE_TARGET"`
+	MovesPerTurn        int           `env:"VIRUSBOT_MOVES_PER_TURN" default:"3"`
+	MoveConfirmTimeout  time.Duration `env:"VIRUSBOT_MOVE_CONFIRM_TIMEOUT" default:"2s"`
+	ReconnectMax        int           `env:"VIRUSBOT_RECONNECT_MAX" default:"5"`
+	PingInterval        time.Duration `env:"VIRUSBOT_PING_INTERVAL" default:"20s"`
+
+	// JSON file the final board is written to when a game ends
+	FinalBoardFile string `env:"VIRUSBOT_FINAL_BOARD_FILE"`
+
+	// Directory every game is recorded to as a newline-delimited JSON replay
+	ReplayDir string `env:"VIRUSBOT_REPLAY_DIR"`
+
+	// Board size assumed when game_start carries no dimensions
+	DefaultBoardSize int `env:"VIRUSBOT_DEFAULT_BOARD_SIZE" default:"10"`
+
+	// Neighbors per cell (8 with diagonals, 4 without) when game_start does
+	// not announce them; 0 keeps the game's default of 8
+	Adjacency int `env:"VIRUSBOT_DIAGONAL_MOVES"`
+
+	// Largest number of candidate moves scored per opening decision; 0 means
+	// no cap
+	MaxCandidates int `env:"VIRUSBOT_MAX_CANDIDATES" default:"0"`
+
+	// Scale size-dependent evaluation terms to a 10x10 board
+	NormalizeEval bool `env:"VIRUSBOT_NORMALIZE_EVAL"`
 
 	// Strategy selection
-	Strategy string `env:"VIRUSBOT_STRATEGY" default:"mcts"` // "heuristic" or "mcts"
+	Strategy string `env:"VIRUSBOT_STRATEGY" default:"mcts"` // "heuristic", "mcts", "disconnect", "policy" or "minimax"
+
+	// Fallback chain, e.g. "mcts,heuristic"; overrides Strategy when set
+	StrategyChain        []string      `env:"VIRUSBOT_STRATEGY_CHAIN"`
+	StrategyChainTimeout time.Duration `env:"VIRUSBOT_STRATEGY_CHAIN_TIMEOUT" default:"2s"`
 
 	// MCTS Configuration
 	MCTSIterations int           `env:"VIRUSBOT_MCTS_ITERATIONS" default:"1000"`
 	MCTSTimeLimit  time.Duration `env:"VIRUSBOT_MCTS_TIME_LIMIT" default:"1s"`
 	MCTSUCTConst   float64       `env:"VIRUSBOT_MCTS_UCT_CONST" default:"1.41"`
+	MCTSWarmup     int           `env:"VIRUSBOT_MCTS_WARMUP" default:"0"`
+	MCTSReuseTree  bool          `env:"VIRUSBOT_MCTS_REUSE_TREE"`
+	MCTSWorkers    int           `env:"VIRUSBOT_MCTS_WORKERS" default:"0"` // 0 = GOMAXPROCS
+
+	// Thinking time for a whole turn, split over its moves by strategies
+	// that support it (0 = per-decision limits only)
+	TurnTimeBudget time.Duration `env:"VIRUSBOT_TURN_TIME_BUDGET" default:"0"`
+
+	// Minimax search depth in turns
+	MinimaxDepth int `env:"VIRUSBOT_MINIMAX_DEPTH" default:"2"`
 
 	// Heuristic Weights
 	WeightTerritory    float64 `env:"VIRUSBOT_WGT_TERRITORY" default:"1.0"`
@@ -41,14 +107,41 @@ type Config struct {
 	WeightConnectivity float64 `env:"VIRUSBOT_WGT_CONNECTIVITY" default:"0.3"`
 	WeightExpansion    float64 `env:"VIRUSBOT_WGT_EXPANSION" default:"0.4"`
 	WeightDefensive    float64 `env:"VIRUSBOT_WGT_DEFENSIVE" default:"0.2"`
+	WeightMobility     float64 `env:"VIRUSBOT_WGT_MOBILITY" default:"0.3"`
+	WeightSpecial      float64 `env:"VIRUSBOT_WGT_SPECIAL" default:"1.0"`
+	WeightCompactness  float64 `env:"VIRUSBOT_WGT_COMPACTNESS" default:"0.5"`
+	WeightThreatDecay  float64 `env:"VIRUSBOT_WGT_THREAT_DECAY" default:"5.0"`
+
+	// Phase-dependent positional weights
+	WeightOpeningCenter float64 `env:"VIRUSBOT_WGT_OPENING_CENTER" default:"0.5"`
+	WeightEndgameEdge   float64 `env:"VIRUSBOT_WGT_ENDGAME_EDGE" default:"0.5"`
+
+	// Free-for-all weights, applied while more than two players are alive
+	WeightFFAAttack    float64 `env:"VIRUSBOT_WGT_FFA_ATTACK_DAMPING" default:"0.5"`
+	WeightFFAExpansion float64 `env:"VIRUSBOT_WGT_FFA_EXPANSION" default:"0.5"`
+
+	// JSON file overriding the weights above, reread on SIGHUP
+	WeightsFile string `env:"VIRUSBOT_WEIGHTS_FILE"`
+
+	// Recorded opponent policy: replayed by the "policy" strategy, and
+	// written from observed opponent moves when a record file is set
+	PolicyFile       string `env:"VIRUSBOT_POLICY_FILE"`
+	RecordPolicyFile string `env:"VIRUSBOT_RECORD_POLICY_FILE"`
+
+	// Chance that the heuristic plays a random legal move instead, for self-play variety
+	HeuristicEpsilon float64 `env:"VIRUSBOT_HEURISTIC_EPSILON" default:"0"`
 }
 
 // StrategyType represents the strategy to use
 type StrategyType string
 
 const (
-	StrategyHeuristic StrategyType = "heuristic"
-	StrategyMCTS      StrategyType = "mcts"
+	StrategyHeuristic  StrategyType = "heuristic"
+	StrategyMCTS       StrategyType = "mcts"
+	StrategyDisconnect StrategyType = "disconnect"
+	StrategyPolicy     StrategyType = "policy"
+	StrategyMinimax    StrategyType = "minimax"
+	StrategyGreedy     StrategyType = "greedy"
 )
 
 // Load reads configuration from environment variables
@@ -57,37 +150,229 @@ func Load() (*Config, error) {
 	_ = godotenv.Load()
 
 	cfg := &Config{
-		ServerURL:           getEnv("VIRUSBOT_SERVER_URL", "ws://localhost:8080/ws"),
-		BotName:             getEnv("VIRUSBOT_NAME", "VirusBot"),
-		LobbyID:             getEnv("VIRUSBOT_LOBBY", ""),
-		AutoJoin:            getEnvBool("VIRUSBOT_AUTO_JOIN"),
-		AutoCreate:          getEnvBool("VIRUSBOT_AUTO_CREATE"),
-		MoveDelay:           getEnvDuration("VIRUSBOT_MOVE_DELAY", 500*time.Millisecond),
-		Debug:               getEnvBool("VIRUSBOT_DEBUG"),
-		AutoAcceptChallenge: getEnvBool("VIRUSBOT_AUTO_ACCEPT_CHALLENGE"),
-		Strategy:           getEnv("VIRUSBOT_STRATEGY", "mcts"),
-		MCTSIterations:     getEnvInt("VIRUSBOT_MCTS_ITERATIONS", 1000),
-		MCTSTimeLimit:      getEnvDuration("VIRUSBOT_MCTS_TIME_LIMIT", 1*time.Second),
-		MCTSUCTConst:       getEnvFloat("VIRUSBOT_MCTS_UCT_CONST", 1.41),
-		WeightTerritory:    getEnvFloat("VIRUSBOT_WGT_TERRITORY", 1.0),
-		WeightStrategic:    getEnvFloat("VIRUSBOT_WGT_STRATEGIC", 0.5),
-		WeightThreat:       getEnvFloat("VIRUSBOT_WGT_THREAT", 1.5),
-		WeightConnectivity: getEnvFloat("VIRUSBOT_WGT_CONNECTIVITY", 0.3),
-		WeightExpansion:    getEnvFloat("VIRUSBOT_WGT_EXPANSION", 0.4),
-		WeightDefensive:    getEnvFloat("VIRUSBOT_WGT_DEFENSIVE", 0.2),
+		ServerURL:            getEnv("VIRUSBOT_SERVER_URL", "ws://localhost:8080/ws"),
+		AuthToken:            getEnv("VIRUSBOT_AUTH_TOKEN", ""),
+		TLSInsecure:          getEnvBool("VIRUSBOT_TLS_INSECURE"),
+		HTTPAddr:             getEnv("VIRUSBOT_HTTP_ADDR", ""),
+		EventsJSON:           getEnvBool("VIRUSBOT_EVENTS_JSON"),
+		GracefulShutdown:     getEnvBool("VIRUSBOT_GRACEFUL_SHUTDOWN"),
+		ShutdownTimeout:      getEnvDuration("VIRUSBOT_SHUTDOWN_TIMEOUT", 5*time.Minute),
+		BotName:              getEnv("VIRUSBOT_NAME", "VirusBot"),
+		LobbyID:              getEnv("VIRUSBOT_LOBBY", ""),
+		AutoJoin:             getEnvBool("VIRUSBOT_AUTO_JOIN"),
+		AutoCreate:           getEnvBool("VIRUSBOT_AUTO_CREATE"),
+		MoveDelay:            getEnvDuration("VIRUSBOT_MOVE_DELAY", 500*time.Millisecond),
+		AdaptiveMoveDelay:    getEnvBool("VIRUSBOT_ADAPTIVE_MOVE_DELAY"),
+		Debug:                getEnvBool("VIRUSBOT_DEBUG"),
+		LogLevel:             getEnv("VIRUSBOT_LOG_LEVEL", "info"),
+		AutoAcceptChallenge:  getEnvBool("VIRUSBOT_AUTO_ACCEPT_CHALLENGE"),
+		AutoDecline:          getEnvBool("VIRUSBOT_AUTO_DECLINE"),
+		DeclineUsers:         getEnvList("VIRUSBOT_DECLINE_USERS"),
+		AcceptDelay:          getEnvDuration("VIRUSBOT_ACCEPT_DELAY", 0),
+		ChallengeTimeout:     getEnvDuration("VIRUSBOT_CHALLENGE_TIMEOUT", 10*time.Second),
+		AutoChallenge:        getEnvBool("VIRUSBOT_AUTO_CHALLENGE"),
+		ChallengeTarget:      getEnv("VIRUSBOT_CHALLENGE_TARGET", ""),
+		MovesPerTurn:         getEnvInt("VIRUSBOT_MOVES_PER_TURN", 3),
+		MoveConfirmTimeout:   getEnvDuration("VIRUSBOT_MOVE_CONFIRM_TIMEOUT", 2*time.Second),
+		ReconnectMax:         getEnvInt("VIRUSBOT_RECONNECT_MAX", 5),
+		PingInterval:         getEnvDuration("VIRUSBOT_PING_INTERVAL", 20*time.Second),
+		FinalBoardFile:       getEnv("VIRUSBOT_FINAL_BOARD_FILE", ""),
+		ReplayDir:            getEnv("VIRUSBOT_REPLAY_DIR", ""),
+		DefaultBoardSize:     getEnvInt("VIRUSBOT_DEFAULT_BOARD_SIZE", 10),
+		Adjacency:            getEnvAdjacency("VIRUSBOT_DIAGONAL_MOVES"),
+		MaxCandidates:        getEnvInt("VIRUSBOT_MAX_CANDIDATES", 0),
+		NormalizeEval:        getEnvBool("VIRUSBOT_NORMALIZE_EVAL"),
+		Strategy:             getEnv("VIRUSBOT_STRATEGY", "mcts"),
+		StrategyChain:        getEnvList("VIRUSBOT_STRATEGY_CHAIN"),
+		StrategyChainTimeout: getEnvDuration("VIRUSBOT_STRATEGY_CHAIN_TIMEOUT", 2*time.Second),
+		MCTSIterations:       getEnvInt("VIRUSBOT_MCTS_ITERATIONS", 1000),
+		MCTSTimeLimit:        getEnvDuration("VIRUSBOT_MCTS_TIME_LIMIT", 1*time.Second),
+		MCTSUCTConst:         getEnvFloat("VIRUSBOT_MCTS_UCT_CONST", 1.41),
+		MCTSWarmup:           getEnvInt("VIRUSBOT_MCTS_WARMUP", 0),
+		MCTSReuseTree:        getEnvBool("VIRUSBOT_MCTS_REUSE_TREE"),
+		MCTSWorkers:          getEnvInt("VIRUSBOT_MCTS_WORKERS", 0),
+		TurnTimeBudget:       getEnvDuration("VIRUSBOT_TURN_TIME_BUDGET", 0),
+		MinimaxDepth:         getEnvInt("VIRUSBOT_MINIMAX_DEPTH", 2),
+		WeightTerritory:      getEnvFloat("VIRUSBOT_WGT_TERRITORY", 1.0),
+		WeightStrategic:      getEnvFloat("VIRUSBOT_WGT_STRATEGIC", 0.5),
+		WeightThreat:         getEnvFloat("VIRUSBOT_WGT_THREAT", 1.5),
+		WeightConnectivity:   getEnvFloat("VIRUSBOT_WGT_CONNECTIVITY", 0.3),
+		WeightExpansion:      getEnvFloat("VIRUSBOT_WGT_EXPANSION", 0.4),
+		WeightDefensive:      getEnvFloat("VIRUSBOT_WGT_DEFENSIVE", 0.2),
+		WeightMobility:       getEnvFloat("VIRUSBOT_WGT_MOBILITY", 0.3),
+		WeightSpecial:        getEnvFloat("VIRUSBOT_WGT_SPECIAL", 1.0),
+		WeightCompactness:    getEnvFloat("VIRUSBOT_WGT_COMPACTNESS", 0.5),
+		WeightThreatDecay:    getEnvFloat("VIRUSBOT_WGT_THREAT_DECAY", 5.0),
+		WeightOpeningCenter:  getEnvFloat("VIRUSBOT_WGT_OPENING_CENTER", 0.5),
+		WeightEndgameEdge:    getEnvFloat("VIRUSBOT_WGT_ENDGAME_EDGE", 0.5),
+		WeightFFAAttack:      getEnvFloat("VIRUSBOT_WGT_FFA_ATTACK_DAMPING", 0.5),
+		WeightFFAExpansion:   getEnvFloat("VIRUSBOT_WGT_FFA_EXPANSION", 0.5),
+		WeightsFile:          getEnv("VIRUSBOT_WEIGHTS_FILE", ""),
+		PolicyFile:           getEnv("VIRUSBOT_POLICY_FILE", ""),
+		RecordPolicyFile:     getEnv("VIRUSBOT_RECORD_POLICY_FILE", ""),
+		HeuristicEpsilon:     getEnvFloat("VIRUSBOT_HEURISTIC_EPSILON", 0),
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
+// Validate reports every setting that cannot work, joined into one error
+func (c *Config) Validate() error {
+	var errs []error
+
+	if u, err := url.Parse(c.ServerURL); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("VIRUSBOT_SERVER_URL %q must be a ws:// or wss:// URL", redactURL(c.ServerURL)))
+	}
+	if c.MoveDelay < 0 {
+		errs = append(errs, fmt.Errorf("VIRUSBOT_MOVE_DELAY must not be negative, got %v", c.MoveDelay))
+	}
+	if c.ShutdownTimeout < 0 {
+		errs = append(errs, fmt.Errorf("VIRUSBOT_SHUTDOWN_TIMEOUT must not be negative, got %v", c.ShutdownTimeout))
+	}
+	if c.AcceptDelay < 0 {
+		errs = append(errs, fmt.Errorf("VIRUSBOT_ACCEPT_DELAY must not be negative, got %v", c.AcceptDelay))
+	}
+	if c.TurnTimeBudget < 0 {
+		errs = append(errs, fmt.Errorf("VIRUSBOT_TURN_TIME_BUDGET must not be negative, got %v", c.TurnTimeBudget))
+	}
+	if c.MCTSIterations <= 0 {
+		errs = append(errs, fmt.Errorf("VIRUSBOT_MCTS_ITERATIONS must be positive, got %d", c.MCTSIterations))
+	}
+	if c.MCTSWorkers < 0 {
+		errs = append(errs, fmt.Errorf("VIRUSBOT_MCTS_WORKERS must not be negative, got %d", c.MCTSWorkers))
+	}
+	if c.MinimaxDepth <= 0 {
+		errs = append(errs, fmt.Errorf("VIRUSBOT_MINIMAX_DEPTH must be positive, got %d", c.MinimaxDepth))
+	}
+	if c.LogLevel != "" {
+		if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+			errs = append(errs, fmt.Errorf("VIRUSBOT_LOG_LEVEL: %w", err))
+		}
+	}
+	if _, err := c.GetStrategyType(); err != nil {
+		errs = append(errs, fmt.Errorf("VIRUSBOT_STRATEGY: %w", err))
+	}
+	for _, name := range c.StrategyChain {
+		if _, err := ParseStrategyType(name); err != nil {
+			errs = append(errs, fmt.Errorf("VIRUSBOT_STRATEGY_CHAIN: %w", err))
+		}
+	}
+	if c.allWeightsZero() {
+		errs = append(errs, errors.New("VIRUSBOT_WGT_* heuristic weights are all zero, so every move would score the same"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// allWeightsZero reports whether no heuristic weight is set
+func (c *Config) allWeightsZero() bool {
+	for _, w := range []float64{
+		c.WeightTerritory, c.WeightStrategic, c.WeightThreat, c.WeightConnectivity, c.WeightExpansion,
+		c.WeightDefensive, c.WeightMobility, c.WeightSpecial, c.WeightCompactness, c.WeightThreatDecay,
+	} {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // GetStrategyType returns the strategy as a typed enum
-func (c *Config) GetStrategyType() StrategyType {
-	switch c.Strategy {
-	case "mcts", "MCTS":
-		return StrategyMCTS
-	default:
-		return StrategyHeuristic
+func (c *Config) GetStrategyType() (StrategyType, error) {
+	return ParseStrategyType(c.Strategy)
+}
+
+// ParseStrategyType parses a strategy name. Names are matched
+// case-insensitively; anything else is an error rather than a silent
+// fallback, so a typo like "mtcs" is caught.
+func ParseStrategyType(name string) (StrategyType, error) {
+	switch t := StrategyType(strings.ToLower(name)); t {
+	case StrategyHeuristic, StrategyMCTS, StrategyDisconnect, StrategyPolicy, StrategyMinimax, StrategyGreedy:
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown strategy %q (want heuristic, mcts, disconnect, policy, minimax or greedy)", name)
+}
+
+// Logger returns a logger at the configured level. VIRUSBOT_DEBUG lowers
+// it to debug; an empty or unknown level means info.
+func (c *Config) Logger() logging.Logger {
+	level, err := logging.ParseLevel(c.LogLevel)
+	if err != nil {
+		level = logging.LevelInfo
+	}
+	if c.Debug {
+		level = logging.LevelDebug
+	}
+	return logging.New(level, "")
+}
+
+// maskedValue replaces credentials in logged configuration
+const maskedValue = "xxxxx"
+
+// String returns every setting as Name=value with credentials masked, so the
+// effective configuration can be logged safely. Fields tagged mask:"all" are
+// hidden entirely; mask:"url" hides the URL's userinfo and secret-looking
+// query parameters.
+func (c *Config) String() string {
+	v := reflect.ValueOf(*c)
+	t := v.Type()
+	parts := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := fmt.Sprintf("%v", v.Field(i).Interface())
+		switch field.Tag.Get("mask") {
+		case "all":
+			if value != "" {
+				value = maskedValue
+			}
+		case "url":
+			value = redactURL(value)
+		}
+		parts = append(parts, field.Name+"="+value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// redactURL masks the userinfo and any credential query parameters of a URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return maskedValue
+	}
+
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), maskedValue)
+		} else {
+			u.User = url.User(maskedValue)
+		}
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			if isSecretParam(key) {
+				query.Set(key, maskedValue)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
+
+// isSecretParam reports whether a query parameter name looks like a credential
+func isSecretParam(key string) bool {
+	key = strings.ToLower(key)
+	for _, marker := range []string{"token", "key", "secret", "pass", "auth", "sig"} {
+		if strings.Contains(key, marker) {
+			return true
+		}
 	}
+	return false
 }
 
 // Helper functions for environment variables
@@ -103,6 +388,32 @@ func getEnvBool(key string) bool {
 	return val == "true" || val == "1" || val == "yes"
 }
 
+// getEnvAdjacency maps a diagonal-moves switch to a neighbor count: 8 when
+// it is on, 4 when it is off and 0 when it is unset
+func getEnvAdjacency(key string) int {
+	switch strings.ToLower(os.Getenv(key)) {
+	case "true", "1", "yes":
+		return 8
+	case "false", "0", "no":
+		return 4
+	}
+	return 0
+}
+
+func getEnvList(key string) []string {
+	val := os.Getenv(key)
+	if val == "" {
+		return nil
+	}
+	var result []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
 	if val := os.Getenv(key); val != "" {
 		if d, err := time.ParseDuration(val); err == nil {
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"virusbot/internal/game"
+	"virusbot/internal/protocol"
+)
+
+// Clone returns a deep copy of the state. The client keeps applying server
+// messages to its own state, so everything handed out of it is a clone.
+func (cs *GameState) Clone() *GameState {
+	if cs == nil {
+		return nil
+	}
+	clone := *cs
+	if cs.Board != nil {
+		clone.Board = make([][]protocol.CellType, len(cs.Board))
+		for i, row := range cs.Board {
+			clone.Board[i] = append([]protocol.CellType(nil), row...)
+		}
+	}
+	if cs.Players != nil {
+		clone.Players = append([]protocol.PlayerInfo(nil), cs.Players...)
+	}
+	return &clone
+}
+
+// cellAt returns the cell at (row, col) and whether it lies on the board.
+// It is safe on a nil state, a missing board and rows of uneven length.
+func (cs *GameState) cellAt(row, col int) (protocol.CellType, bool) {
+	if cs == nil || row < 0 || row >= len(cs.Board) || col < 0 || col >= len(cs.Board[row]) {
+		return protocol.CellEmpty, false
+	}
+	return cs.Board[row][col], true
+}
+
+// nextPlayer returns the alive player who moves after player, in the order
+// of the player list. It reports false when the list is missing or only a
+// placeholder, or no other player is alive.
+func (cs *GameState) nextPlayer(player int) (int, bool) {
+	if cs.PlayersGuessed || len(cs.Players) == 0 {
+		return 0, false
+	}
+
+	start := -1
+	for i, p := range cs.Players {
+		if p.ID == player {
+			start = i
+			break
+		}
+	}
+	for step := 1; step <= len(cs.Players); step++ {
+		p := cs.Players[(start+step+len(cs.Players))%len(cs.Players)]
+		if !p.Eliminated && p.ID != player {
+			return p.ID, true
+		}
+	}
+	return 0, false
+}
+
+// locateBases takes the players' base positions from the base cells on the
+// board, then marks the bases the player list knows but the board does not
+// show. It clears BasesPending once our own base is known.
+func (cs *GameState) locateBases() {
+	for row := range cs.Board {
+		for col, cell := range cs.Board[row] {
+			if !cell.IsBase() {
+				continue
+			}
+			for i := range cs.Players {
+				if cs.Players[i].ID == cell.Player() {
+					cs.Players[i].Position = protocol.Position{Row: row, Col: col}
+				}
+			}
+		}
+	}
+	cs.placeKnownBases()
+}
+
+// placeKnownBases marks the bases from the player list on a board that does
+// not show them yet, and clears BasesPending once our own base is known
+func (cs *GameState) placeKnownBases() {
+	for _, p := range cs.Players {
+		if p.Position.Row < 0 || p.Position.Col < 0 {
+			continue
+		}
+		if cell, ok := cs.cellAt(p.Position.Row, p.Position.Col); ok && cell == protocol.CellEmpty {
+			cs.Board[p.Position.Row][p.Position.Col] = protocol.CellType(p.ID | int(protocol.CellFlagBase))
+		}
+		if p.ID == cs.YourPlayerID {
+			cs.BasesPending = false
+		}
+	}
+}
+
+// validateBases checks the base positions the server sent in the player
+// list; ToGame repairs them, so this is only for reporting
+func (cs *GameState) validateBases() error {
+	bases := make(map[int]game.Position)
+	for _, p := range cs.Players {
+		if p.Position.Row >= 0 && p.Position.Col >= 0 {
+			bases[p.ID] = game.Position{Row: p.Position.Row, Col: p.Position.Col}
+		}
+	}
+	rows, cols := game.Dimensions(cs.Board)
+	raw := &game.Board{Rows: rows, Cols: cols, Cells: cs.Board, BasePos: bases}
+	return raw.ValidateBases()
+}
+
+// ToGame converts the client's wire-level state into a game.GameState
+func (cs *GameState) ToGame() *game.GameState {
+	if cs == nil {
+		return nil
+	}
+
+	// Build base positions from players if available, or discover from board
+	basePos := make(map[int]game.Position)
+
+	// First try to get base positions from player info
+	if cs.Players != nil {
+		for _, p := range cs.Players {
+			// Check if position is valid (not the placeholder -1, -1)
+			if p.Position.Row >= 0 && p.Position.Col >= 0 {
+				basePos[p.ID] = game.Position{
+					Row: p.Position.Row,
+					Col: p.Position.Col,
+				}
+			}
+		}
+	}
+
+	// If base positions are not available from player info, discover from board
+	// by finding the first cell owned by each player
+	if cs.Board != nil && len(basePos) == 0 {
+		for row := 0; row < len(cs.Board); row++ {
+			for col := 0; col < len(cs.Board[row]); col++ {
+				cellType := cs.Board[row][col]
+				// Extract player ID using Player() method (handles flag bits)
+				playerID := cellType.Player()
+				if playerID >= 1 && playerID <= 4 {
+					// Only set if not already found
+					if _, exists := basePos[playerID]; !exists {
+						basePos[playerID] = game.Position{Row: row, Col: col}
+					}
+				}
+			}
+		}
+	}
+
+	board := game.NewBoardFromData(cs.Board, basePos)
+	board.Rules = game.GameConfigFromRules(cs.Rules)
+
+	// A player is alive while they own a cell. Before anyone owns one
+	// (initial placement) everyone counts as alive.
+	placed := false
+	for _, p := range cs.Players {
+		if board.IsAlive(p.ID) {
+			placed = true
+			break
+		}
+	}
+
+	// Handle nil Players (new protocol format)
+	var players []*game.Player
+	if cs.Players != nil {
+		players = make([]*game.Player, len(cs.Players))
+		for i, p := range cs.Players {
+			// Use discovered base position if available
+			basePosition := game.Position{Row: p.Position.Row, Col: p.Position.Col}
+			if pos, exists := basePos[p.ID]; exists {
+				basePosition = pos
+			}
+			cells := board.GetPlayerCells(p.ID)
+			players[i] = &game.Player{
+				ID:              p.ID,
+				Name:            p.Name,
+				Symbol:          p.Symbol,
+				BasePos:         basePosition,
+				Cells:           cells,
+				IsAlive:         !p.Eliminated && (len(cells) > 0 || !placed),
+				HasUsedNeutrals: p.ID == cs.YourPlayerID && cs.NeutralsUsed,
+			}
+		}
+	}
+
+	return &game.GameState{
+		Board:         board,
+		Players:       players,
+		CurrentPlayer: cs.CurrentPlayer,
+		YourPlayerID:  cs.YourPlayerID,
+	}
+}
@@ -0,0 +1,50 @@
+package client
+
+import "time"
+
+// Status is a snapshot of the client for monitoring
+type Status struct {
+	Connected     bool        `json:"connected"`
+	InGame        bool        `json:"inGame"`
+	GameID        string      `json:"gameId,omitempty"`
+	YourPlayer    int         `json:"yourPlayer,omitempty"`
+	CurrentPlayer int         `json:"currentPlayer,omitempty"`
+	MyTurn        bool        `json:"myTurn"`
+	Cells         map[int]int `json:"cells,omitempty"` // player ID -> cells owned
+	Board         string      `json:"board,omitempty"` // rendered as in the debug log
+	// MoveLatency is the rolling average round trip of our moves
+	MoveLatency string `json:"moveLatency,omitempty"`
+}
+
+// Status returns a snapshot of the connection and the current game, taken
+// under the read lock so it is safe to call from any goroutine
+func (c *Client) Status() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	status := Status{
+		Connected: c.connected,
+		InGame:    c.inGame,
+		GameID:    c.gameID,
+	}
+	if latency := c.averageLatency(); latency > 0 {
+		status.MoveLatency = latency.Round(time.Microsecond).String()
+	}
+	if c.gameState == nil {
+		return status
+	}
+	status.YourPlayer = c.gameState.YourPlayerID
+	status.CurrentPlayer = c.gameState.CurrentPlayer
+	status.MyTurn = !c.turnPassed && c.gameState.CurrentPlayer == c.gameState.YourPlayerID
+
+	state := c.gameState.ToGame()
+	if state == nil || state.Board == nil {
+		return status
+	}
+	status.Cells = make(map[int]int, len(c.gameState.Players))
+	for _, p := range c.gameState.Players {
+		status.Cells[p.ID] = state.Board.CountCells(p.ID)
+	}
+	status.Board = state.Board.Render()
+	return status
+}
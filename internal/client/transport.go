@@ -0,0 +1,183 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Transport is a message-oriented connection to the game server
+type Transport interface {
+	// ReadMessage blocks until the next message arrives
+	ReadMessage() ([]byte, error)
+
+	// WriteMessage sends one message
+	WriteMessage(data []byte) error
+
+	// Close closes the connection, unblocking pending reads
+	Close() error
+
+	// SetReadDeadline makes reads fail once t passes; zero means no deadline
+	SetReadDeadline(t time.Time) error
+}
+
+// pinger is implemented by transports that support keepalive pings
+type pinger interface {
+	// Ping sends a ping, failing if it cannot be written before deadline
+	Ping(deadline time.Time) error
+
+	// OnPong registers f to be called from ReadMessage for every pong
+	OnPong(f func())
+}
+
+// ErrTransportClosed is returned by a closed in-memory transport
+var ErrTransportClosed = errors.New("transport closed")
+
+// DialWebSocket connects to a WebSocket server with the default dialer
+func DialWebSocket(url string) (Transport, error) {
+	return DialWebSocketWith(websocket.DefaultDialer, url, nil)
+}
+
+// DialWebSocketWith connects to a WebSocket server with dialer, sending
+// header with the opening handshake
+func DialWebSocketWith(dialer *websocket.Dialer, url string, header http.Header) (Transport, error) {
+	conn, _, err := dialer.Dial(url, header)
+	if err != nil {
+		return nil, err
+	}
+	return &websocketTransport{conn: conn}, nil
+}
+
+// websocketTransport sends every message as a WebSocket text frame. Writes
+// are serialized so keepalive pings never interleave with messages.
+type websocketTransport struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+func (t *websocketTransport) ReadMessage() ([]byte, error) {
+	_, data, err := t.conn.ReadMessage()
+	return data, err
+}
+
+func (t *websocketTransport) WriteMessage(data []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (t *websocketTransport) Ping(deadline time.Time) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.conn.WriteControl(websocket.PingMessage, nil, deadline)
+}
+
+func (t *websocketTransport) OnPong(f func()) {
+	t.conn.SetPongHandler(func(string) error {
+		f()
+		return nil
+	})
+}
+
+func (t *websocketTransport) Close() error {
+	return t.conn.Close()
+}
+
+func (t *websocketTransport) SetReadDeadline(deadline time.Time) error {
+	return t.conn.SetReadDeadline(deadline)
+}
+
+// MemoryTransport is one end of an in-memory connection, for tests and
+// simulations that run the client without a real socket
+type MemoryTransport struct {
+	in         <-chan []byte
+	out        chan<- []byte
+	closed     chan struct{}
+	peerClosed <-chan struct{}
+	closeOnce  sync.Once
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+// NewMemoryPipe returns the two connected ends of an in-memory transport
+func NewMemoryPipe() (*MemoryTransport, *MemoryTransport) {
+	aToB := make(chan []byte, 100)
+	bToA := make(chan []byte, 100)
+	aClosed := make(chan struct{})
+	bClosed := make(chan struct{})
+
+	a := &MemoryTransport{in: bToA, out: aToB, closed: aClosed, peerClosed: bClosed}
+	b := &MemoryTransport{in: aToB, out: bToA, closed: bClosed, peerClosed: aClosed}
+	return a, b
+}
+
+// ReadMessage returns the next message from the peer. Messages already sent
+// are still delivered after the peer closes.
+func (t *MemoryTransport) ReadMessage() ([]byte, error) {
+	select {
+	case data := <-t.in:
+		return data, nil
+	default:
+	}
+
+	t.mu.Lock()
+	deadline := t.deadline
+	t.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case data := <-t.in:
+		return data, nil
+	case <-t.closed:
+		return nil, ErrTransportClosed
+	case <-t.peerClosed:
+		return nil, ErrTransportClosed
+	case <-timeout:
+		return nil, os.ErrDeadlineExceeded
+	}
+}
+
+// WriteMessage sends a message to the peer
+func (t *MemoryTransport) WriteMessage(data []byte) error {
+	select {
+	case <-t.closed:
+		return ErrTransportClosed
+	case <-t.peerClosed:
+		return ErrTransportClosed
+	default:
+	}
+
+	select {
+	case t.out <- data:
+		return nil
+	case <-t.closed:
+		return ErrTransportClosed
+	case <-t.peerClosed:
+		return ErrTransportClosed
+	}
+}
+
+// Close closes this end; the peer's reads and writes fail afterwards
+func (t *MemoryTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	return nil
+}
+
+// SetReadDeadline sets the deadline for future ReadMessage calls
+func (t *MemoryTransport) SetReadDeadline(deadline time.Time) error {
+	t.mu.Lock()
+	t.deadline = deadline
+	t.mu.Unlock()
+	return nil
+}
@@ -2,13 +2,17 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"net/http"
 	"sync"
 	"time"
 
 	"virusbot/config"
+	"virusbot/internal/game"
+	"virusbot/internal/logging"
 	"virusbot/internal/protocol"
 
 	"github.com/gorilla/websocket"
@@ -20,14 +24,68 @@ type GameState struct {
 	Players       []protocol.PlayerInfo
 	CurrentPlayer int
 	YourPlayerID  int
+
+	// Rules is the game-mode metadata from game_start
+	Rules protocol.GameRules
+
+	// PlayerOffset is added to player numbers from the server to get the
+	// internal 1-based IDs that match cell values; 1 for 0-based servers
+	PlayerOffset int
+
+	// PlayersGuessed is set while Players is a placeholder for a game_start
+	// that did not list them, until players_update sends the real list
+	PlayersGuessed bool
+
+	// BasesPending is set while a game_start without a board waits for the
+	// server to reveal the bases; no moves should be made until then
+	BasesPending bool
+
+	// SizeGuessed is set while the board has the default size because the
+	// game_start gave no dimensions; the first snapshot may resize it
+	SizeGuessed bool
+
+	// Synced is set once a game_state snapshot has been applied. From then
+	// on the board holds the server's cell types, so moves are applied by
+	// the rules instead of guessed.
+	Synced bool
+
+	// NeutralsUsed is set once we have placed our neutrals this game
+	NeutralsUsed bool
+}
+
+// normalizePlayer converts a player number from the server to the internal
+// ID. A player 0 proves the server counts from zero even if game_start did
+// not reveal it, so numbering switches to 0-based from then on.
+func (gs *GameState) normalizePlayer(serverPlayer int, logger logging.Logger) int {
+	if serverPlayer+gs.PlayerOffset == 0 {
+		logger.Warnf("Server sent player 0, switching to 0-based player numbering")
+		gs.PlayerOffset = 1
+		if gs.YourPlayerID > 0 {
+			gs.YourPlayerID++
+		}
+		gs.CurrentPlayer++
+	}
+	return serverPlayer + gs.PlayerOffset
 }
 
 // Callback is a function that handles game events
 type Callback func(event string, data interface{})
 
+// Recorder receives the messages of each game, e.g. to write a replay
+type Recorder interface {
+	// StartGame begins recording a new game
+	StartGame(gameID string, yourPlayer, rows, cols int) error
+
+	// Record stores one message of the current game
+	Record(msgType protocol.MessageType, data []byte) error
+
+	// Close finishes the current game's recording
+	Close() error
+}
+
 // Client represents a WebSocket client for the game
 type Client struct {
-	conn             *websocket.Conn
+	conn             Transport
 	config           *config.Config
 	userID           string
 	userName         string
@@ -39,93 +97,358 @@ type Client struct {
 	ctx              context.Context
 	cancel           context.CancelFunc
 	moveDelay        time.Duration
-	debug            bool
+	nextMoveAt       time.Time // moves are spaced moveDelay apart from here on
+	logger           logging.Logger
 	currentChallenge string
+	acceptCancel     chan struct{} // closed when a delayed accept must not be sent
 	gameID           string
+	lobbyID          string                     // lobby we are in, from the last lobby_update
+	lobbyBoardSize   int                        // board size of that lobby, 0 if unknown
+	rooms            map[string]*GameState      // roomID -> observed state (nil until game_start)
+	pendingMoves     []pendingMove              // our optimistic writes awaiting move_made
+	movesSent        int                        // moves sent since our turn started
+	turnBudget       int                        // moves the server allows us this turn
+	turnPassed       bool                       // we gave up the rest of the current turn
+	turnStart        time.Time                  // when our current turn began
+	inGame           bool                       // a game has started and not yet ended
+	shuttingDown     bool                       // no new games; the current one is played out
+	startWaiters     []chan struct{}            // closed when the next game_start is processed
+	confirmWaiters   []chan struct{}            // closed when no optimistic writes remain unconfirmed
+	onlineUsers      []protocol.UserInfo        // latest users_update
+	challengedUser   string                     // user we auto-challenged, cleared on game start
+	latencies        []time.Duration            // latest move round trips, oldest first
+	correctedMoves   int                        // moves the server applied elsewhere or refused
+	rejectedMoves    map[protocol.Position]bool // cells the server refused us this turn
+	turnStarted      chan struct{}              // signalled when a turn with moves to make becomes ours
+	connLost         chan error                 // receives the read error once reconnecting has failed
+	reconnectBackoff time.Duration              // wait before the first reconnect attempt, doubled per attempt
+	recorder         Recorder                   // records game messages when set
+	dialer           *websocket.Dialer          // custom dialer, nil for the configured default
+	header           http.Header                // extra handshake headers
+}
+
+// maxReconnectBackoff caps the wait between reconnect attempts
+const maxReconnectBackoff = 30 * time.Second
+
+// pendingMove is a move we applied locally before the server confirmed it
+type pendingMove struct {
+	pos      protocol.Position
+	previous protocol.CellType
+	sentAt   time.Time
 }
 
+// latencyWindow is how many move round trips the latency average covers
+const latencyWindow = 10
+
 // NewClient creates a new WebSocket client
 func NewClient(cfg *config.Config, callback Callback) *Client {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Client{
-		config:    cfg,
-		callback:  callback,
-		incoming:  make(chan []byte, 100),
-		ctx:       ctx,
-		cancel:    cancel,
-		moveDelay: cfg.MoveDelay,
-		debug:     cfg.Debug,
+		config:      cfg,
+		callback:    callback,
+		incoming:    make(chan []byte, 100),
+		ctx:         ctx,
+		cancel:      cancel,
+		moveDelay:   cfg.MoveDelay,
+		logger:      cfg.Logger(),
+		rooms:       make(map[string]*GameState),
+		turnStarted: make(chan struct{}, 1),
+		connLost:    make(chan error, 1),
+
+		reconnectBackoff: time.Second,
 	}
 }
 
 // Connect establishes a WebSocket connection
 func (c *Client) Connect() error {
-	conn, _, err := websocket.DefaultDialer.Dial(c.config.ServerURL, nil)
+	if c.dialer == nil && c.config.TLSInsecure {
+		c.logger.Warnf("TLS certificate verification is disabled")
+	}
+	conn, err := c.dial()
 	if err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
+	c.ConnectTransport(conn)
+
+	c.logger.Debugf("Connected to %s", c.config.ServerURL)
+
+	return nil
+}
+
+// ConnectTransport uses an already open transport as the server connection
+func (c *Client) ConnectTransport(conn Transport) {
+	c.mu.Lock()
 	c.conn = conn
 	c.connected = true
+	c.mu.Unlock()
 
-	if c.debug {
-		log.Printf("Connected to %s", c.config.ServerURL)
+	if p, ok := conn.(pinger); ok && c.config.PingInterval > 0 {
+		p.OnPong(func() {
+			conn.SetReadDeadline(time.Now().Add(c.pongWait()))
+		})
 	}
+}
 
-	return nil
+// pongWait is how long a connection may stay silent before it is considered
+// dead: two ping intervals, so one lost pong is tolerated
+func (c *Client) pongWait() time.Duration {
+	return 2 * c.config.PingInterval
+}
+
+// keepAlive pings the server every PingInterval so a dead connection makes
+// the next read time out instead of hanging
+func (c *Client) keepAlive() {
+	ticker := time.NewTicker(c.config.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			p, ok := c.transport().(pinger)
+			if !ok || !c.IsConnected() {
+				continue
+			}
+			if err := p.Ping(time.Now().Add(c.config.PingInterval)); err != nil {
+				c.logger.Debugf("Ping failed: %v", err)
+			}
+		}
+	}
+}
+
+// transport returns the current server connection, which a reconnect may
+// replace at any time
+func (c *Client) transport() Transport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn
 }
 
 // Run starts the message handling loop
 func (c *Client) Run() error {
+	if c.config.PingInterval > 0 {
+		go c.keepAlive()
+	}
 	go c.readLoop()
 	return c.writeLoop()
 }
 
-// readLoop continuously reads messages from the WebSocket
+// readLoop continuously reads messages from the WebSocket. When the
+// connection drops it reconnects, and gives up only once every attempt has
+// failed.
 func (c *Client) readLoop() {
 	for {
 		select {
 		case <-c.ctx.Done():
 			return
 		default:
-			_, data, err := c.conn.ReadMessage()
+			conn := c.transport()
+			if _, ok := conn.(pinger); ok && c.config.PingInterval > 0 {
+				conn.SetReadDeadline(time.Now().Add(c.pongWait()))
+			}
+			data, err := conn.ReadMessage()
 			if err != nil {
-				if c.debug {
-					log.Printf("Read error: %v", err)
+				if c.ctx.Err() != nil {
+					return
 				}
+				c.logger.Debugf("Read error: %v", err)
 				c.handleDisconnect()
-				return
+				if !c.reconnect() {
+					c.connLost <- err
+					return
+				}
+				continue
 			}
 			c.incoming <- data
 		}
 	}
 }
 
+// reconnect re-dials the server with exponential backoff, up to the
+// configured number of attempts, and resumes our game, lobby and rooms on
+// the new connection.
+func (c *Client) reconnect() bool {
+	backoff := c.reconnectBackoff
+	for attempt := 1; attempt <= c.config.ReconnectMax; attempt++ {
+		if c.callback != nil {
+			c.callback("reconnecting", attempt)
+		}
+		c.logger.Infof("Reconnecting in %v (attempt %d of %d)", backoff, attempt, c.config.ReconnectMax)
+
+		select {
+		case <-c.ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxReconnectBackoff)
+
+		conn, err := c.dial()
+		if err != nil {
+			c.logger.Warnf("Reconnect attempt %d failed: %v", attempt, err)
+			continue
+		}
+		c.transport().Close()
+		c.ConnectTransport(conn)
+		c.logger.Infof("Reconnected to %s", c.config.ServerURL)
+
+		c.resume()
+		return true
+	}
+	return false
+}
+
+// resume re-subscribes to the joined rooms, rejoins our lobby and asks for
+// the state of the game in progress, if any, after a reconnect
+func (c *Client) resume() {
+	rooms := c.GetRooms()
+	for _, roomID := range rooms {
+		if err := c.SendMessage(protocol.NewJoinRoomMessage(roomID)); err != nil {
+			c.logger.Errorf("Failed to rejoin room %s: %v", roomID, err)
+		}
+	}
+
+	c.mu.RLock()
+	lobbyID := c.lobbyID
+	resumeGame := c.inGame && c.gameID != ""
+	c.mu.RUnlock()
+	if lobbyID != "" {
+		if err := c.JoinLobby(lobbyID); err != nil {
+			c.logger.Errorf("Failed to rejoin lobby %s: %v", lobbyID, err)
+		}
+	}
+	if resumeGame {
+		if err := c.RequestStateSync(); err != nil {
+			c.logger.Errorf("Failed to resume game: %v", err)
+		}
+	}
+}
+
 // writeLoop processes incoming messages
 func (c *Client) writeLoop() error {
 	for {
 		select {
 		case <-c.ctx.Done():
 			return c.ctx.Err()
+		case err := <-c.connLost:
+			return fmt.Errorf("connection lost: %w", err)
 		case data := <-c.incoming:
 			if err := c.handleMessage(data); err != nil {
-				if c.debug {
-					log.Printf("Message handling error: %v", err)
-				}
+				c.logger.Debugf("Message handling error: %v", err)
 				return err
 			}
 		}
 	}
 }
 
+// SetRecorder records the start, moves, turn changes and end of every game
+// from now on. Set it before Run.
+func (c *Client) SetRecorder(r Recorder) {
+	c.recorder = r
+}
+
+// SetDialer replaces the dialer used to connect and reconnect, for custom
+// TLS, proxies or timeouts. It takes precedence over VIRUSBOT_TLS_INSECURE.
+// Set it before Connect.
+func (c *Client) SetDialer(d *websocket.Dialer) {
+	c.dialer = d
+}
+
+// SetHeader adds a header to the opening handshake of every connection.
+// Set it before Connect.
+func (c *Client) SetHeader(key, value string) {
+	if c.header == nil {
+		c.header = make(http.Header)
+	}
+	c.header.Set(key, value)
+}
+
+// dial opens a connection to the server. The auth token is sent as a
+// bearer token unless an Authorization header was set explicitly.
+func (c *Client) dial() (Transport, error) {
+	dialer := c.dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+		if c.config.TLSInsecure {
+			insecure := *websocket.DefaultDialer
+			insecure.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+			dialer = &insecure
+		}
+	}
+
+	header := c.header.Clone()
+	if c.config.AuthToken != "" && header.Get("Authorization") == "" {
+		if header == nil {
+			header = make(http.Header)
+		}
+		header.Set("Authorization", "Bearer "+c.config.AuthToken)
+	}
+	return DialWebSocketWith(dialer, c.config.ServerURL, header)
+}
+
+// SetLogger replaces the logger built from the configuration. Set it before
+// Run.
+func (c *Client) SetLogger(l logging.Logger) {
+	c.logger = l
+}
+
+// HandleMessage processes a server message as if it had been received,
+// without needing a connection; replays use it to rebuild a game
+func (c *Client) HandleMessage(data []byte) error {
+	return c.handleMessage(data)
+}
+
+// record passes a handled game message to the recorder
+func (c *Client) record(msgType protocol.MessageType, data []byte) {
+	var err error
+	switch msgType {
+	case protocol.MsgGameStart:
+		c.mu.RLock()
+		gameID, yourPlayer, rows, cols := c.gameID, 0, 0, 0
+		if c.gameState != nil {
+			yourPlayer, rows = c.gameState.YourPlayerID, len(c.gameState.Board)
+			if rows > 0 {
+				cols = len(c.gameState.Board[0])
+			}
+		}
+		c.mu.RUnlock()
+		if err = c.recorder.StartGame(gameID, yourPlayer, rows, cols); err == nil {
+			err = c.recorder.Record(msgType, data)
+		}
+	case protocol.MsgMoveMade, protocol.MsgTurnChange, protocol.MsgGameState:
+		err = c.recorder.Record(msgType, data)
+	case protocol.MsgGameEnd:
+		if err = c.recorder.Record(msgType, data); err == nil {
+			err = c.recorder.Close()
+		}
+	}
+	if err != nil {
+		c.logger.Errorf("Failed to record %s: %v", msgType, err)
+	}
+}
+
 // handleMessage processes a single WebSocket message
 func (c *Client) handleMessage(data []byte) error {
+	err := c.dispatch(data)
+	if err == nil && c.recorder != nil {
+		if msg, parseErr := protocol.ParseMessage(data); parseErr == nil && msg.RoomID == "" {
+			c.record(msg.Type, data)
+		}
+	}
+	return err
+}
+
+// dispatch routes a single WebSocket message to its handler
+func (c *Client) dispatch(data []byte) error {
 	msg, err := protocol.ParseMessage(data)
 	if err != nil {
 		return fmt.Errorf("failed to parse message: %w", err)
 	}
 
-	if c.debug {
-		log.Printf("Raw message: %s", string(data))
+	c.logger.Debugf("Raw message: %s", string(data))
+
+	// Messages tagged with a room belong to a room we observe, not our own game
+	if msg.RoomID != "" {
+		return c.handleRoomMessage(msg, data)
 	}
 
 	switch msg.Type {
@@ -141,29 +464,108 @@ func (c *Client) handleMessage(data []byte) error {
 	case protocol.MsgMoveMade:
 		return c.handleMoveMade(data)
 
+	case protocol.MsgGameState:
+		return c.handleGameState(data)
+
 	case protocol.MsgTurnChange:
 		return c.handleTurnChange(data)
 
 	case protocol.MsgGameEnd:
 		return c.handleGameEnd(data)
 
+	case protocol.MsgPlayersUpdate:
+		return c.handlePlayersUpdate(data)
+
 	case protocol.MsgUsersUpdate:
-		c.handleUsersUpdate(data)
+		return c.handleUsersUpdate(data)
+
+	case protocol.MsgChallengeSent:
+		return c.handleChallengeSent(data)
+
+	case protocol.MsgChallengeCancel:
+		return c.handleChallengeCancelled(data)
+
+	case protocol.MsgLobbyUpdate:
+		return c.handleLobbyUpdate(data)
+
+	case protocol.MsgError:
+		return c.handleError(data)
 
 	default:
-		if c.debug {
-			log.Printf("Unhandled message type: %s", msg.Type)
+		c.logger.Debugf("Unhandled message type: %s", msg.Type)
+	}
+
+	return nil
+}
+
+// RoomEvent is passed to the callback for messages received from a joined room
+type RoomEvent struct {
+	RoomID string
+	Type   protocol.MessageType
+	State  *GameState
+}
+
+// handleRoomMessage applies a game message to the state of the room it belongs to
+func (c *Client) handleRoomMessage(msg *protocol.Message, data []byte) error {
+	c.mu.Lock()
+	state, joined := c.rooms[msg.RoomID]
+	if !joined {
+		c.mu.Unlock()
+		c.logger.Debugf("Ignoring %s for room %s: not joined", msg.Type, msg.RoomID)
+		return nil
+	}
+
+	switch msg.Type {
+	case protocol.MsgGameStart:
+		newState, _, _, err := c.parseGameStart(data, c.config.DefaultBoardSize)
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		if newState.Rules.Adjacency == 0 {
+			newState.Rules.Adjacency = c.config.Adjacency
+		}
+		state = newState
+		c.rooms[msg.RoomID] = state
+
+	case protocol.MsgMoveMade:
+		moveMade, err := protocol.ParseMoveMade(data)
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		if prev, ok := state.cellAt(moveMade.Row, moveMade.Col); ok {
+			moveMade.Player = state.normalizePlayer(moveMade.Player, c.logger)
+			cell := protocol.CellType(moveMade.Player)
+			if prev != protocol.CellEmpty && !prev.IsSpecial() {
+				cell = protocol.CellType(moveMade.Player | int(protocol.CellFlagFortified))
+			}
+			state.Board[moveMade.Row][moveMade.Col] = cell
+		}
+
+	case protocol.MsgTurnChange:
+		turnChange, err := protocol.ParseTurnChange(data)
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		if state != nil {
+			state.CurrentPlayer = state.normalizePlayer(turnChange.Player, c.logger)
 		}
 	}
+	snapshot := state.Clone()
+	c.mu.Unlock()
+
+	if c.callback != nil {
+		c.callback("room_event", &RoomEvent{RoomID: msg.RoomID, Type: msg.Type, State: snapshot})
+	}
 
 	return nil
 }
 
 // handleWelcome handles the welcome message after connection
 func (c *Client) handleWelcome(data []byte) error {
-	if c.debug {
-		log.Printf("Welcome data: %s", string(data))
-	}
+	c.logger.Debugf("Welcome data: %s", string(data))
 
 	welcome, err := protocol.ParseWelcome(data)
 	if err != nil {
@@ -173,23 +575,28 @@ func (c *Client) handleWelcome(data []byte) error {
 	c.userID = welcome.UserID
 	c.userName = welcome.UserName
 
-	if c.debug {
-		log.Printf("Connected as %s (ID: %s)", c.userName, c.userID)
-	}
+	c.logger.Debugf("Connected as %s (ID: %s)", c.userName, c.userID)
 
 	if c.callback != nil {
 		c.callback("connected", welcome)
 	}
 
+	// After a reconnect resume has already rejoined our lobby or game
+	c.mu.RLock()
+	resuming := c.inGame || c.lobbyID != ""
+	c.mu.RUnlock()
+	if resuming {
+		c.logger.Debugf("Resuming, skipping the lobby auto-join")
+		return nil
+	}
+
 	// Auto-join or create lobby if configured
 	if c.config.LobbyID != "" {
 		return c.JoinLobby(c.config.LobbyID)
 	}
 	if c.config.AutoJoin {
 		// Would need to get lobby list first
-		if c.debug {
-			log.Println("Auto-join enabled but no lobby ID specified")
-		}
+		c.logger.Debugf("Auto-join enabled but no lobby ID specified")
 	}
 	if c.config.AutoCreate {
 		return c.CreateLobby(10)
@@ -200,74 +607,137 @@ func (c *Client) handleWelcome(data []byte) error {
 
 // handleGameStart handles the start of a game
 func (c *Client) handleGameStart(data []byte) error {
-	// Try to parse as new format first (without board data)
-	gameStartV2, err := protocol.ParseGameStartV2(data)
-	if err == nil && gameStartV2.Rows > 0 {
-		// New format: initialize board with bases in corners
-		board := make([][]protocol.CellType, gameStartV2.Rows)
-		for i := range board {
-			board[i] = make([]protocol.CellType, gameStartV2.Cols)
-		}
+	state, gameID, needsSync, err := c.parseGameStart(data, c.defaultBoardSize())
+	if err != nil {
+		return err
+	}
+	if state.Rules.Adjacency == 0 {
+		state.Rules.Adjacency = c.config.Adjacency
+	}
 
-		// Place bases in corners according to standard Virus game rules
-		// Player 1: top-left (0,0)
-		// Player 2: bottom-right (rows-1, cols-1)
-		// Player 3: top-right (0, cols-1)
-		// Player 4: bottom-left (rows-1, 0)
-		// Bases are marked with CellFlagBase (0x10) and cannot be attacked
-		board[0][0] = protocol.CellType(1 | int(protocol.CellFlagBase))
-		board[gameStartV2.Rows-1][gameStartV2.Cols-1] = protocol.CellType(2 | int(protocol.CellFlagBase))
-		if gameStartV2.Rows > 0 && gameStartV2.Cols > 0 {
-			board[0][gameStartV2.Cols-1] = protocol.CellType(3 | int(protocol.CellFlagBase))
-			board[gameStartV2.Rows-1][0] = protocol.CellType(4 | int(protocol.CellFlagBase))
-		}
+	// Cell values number players from 1, so being player 0 means the server
+	// counts from zero; shift its numbers for the rest of the game
+	if state.YourPlayerID == 0 {
+		state.PlayerOffset = 1
+		state.YourPlayerID = 1
+		state.CurrentPlayer++
+		c.logger.Debugf("Server numbers players from 0, normalizing to 1-based IDs")
+	}
 
-		// Create players with their standard corner base positions
-		players := []protocol.PlayerInfo{
-			{ID: 1, Name: "Player 1", Symbol: protocol.CellPlayer1, Position: protocol.Position{Row: 0, Col: 0}, IsAI: true},
-			{ID: 2, Name: "Player 2", Symbol: protocol.CellPlayer2, Position: protocol.Position{Row: gameStartV2.Rows - 1, Col: gameStartV2.Cols - 1}, IsAI: true},
-		}
+	if err := state.validateBases(); err != nil {
+		c.logger.Warnf("game_start has unusable bases, relocating them: %v", err)
+	}
 
-		c.mu.Lock()
-		c.gameState = &GameState{
-			Board:         board,
-			Players:       players,
-			CurrentPlayer: gameStartV2.YourPlayer,
-			YourPlayerID:  gameStartV2.YourPlayer,
+	c.mu.Lock()
+	c.gameState = state
+	c.pendingMoves = nil
+	c.notifyConfirmed()
+	c.resetTurnBudget(0)
+	if gameID != "" {
+		c.gameID = gameID
+	}
+	c.inGame = true
+	c.challengedUser = ""
+	c.currentChallenge = ""
+	for _, waiter := range c.startWaiters {
+		close(waiter)
+	}
+	c.startWaiters = nil
+	snapshot := state.Clone()
+	c.mu.Unlock()
+
+	c.logger.Debugf("Game started: you are player %d (gameId: %s)", state.YourPlayerID, gameID)
+
+	if c.callback != nil {
+		c.callback("game_start", snapshot)
+	}
+
+	if needsSync {
+		if err := c.RequestStateSync(); err != nil {
+			c.logger.Errorf("Failed to request state sync: %v", err)
 		}
-		c.gameID = gameStartV2.GameID
-		c.mu.Unlock()
+	}
+
+	return nil
+}
+
+// parseGameStart builds a game state from either game_start format. When
+// the message carries neither a board nor dimensions, a defaultSize board
+// is used instead and needsSync reports that the real one must be requested.
+func (c *Client) parseGameStart(data []byte, defaultSize int) (state *GameState, gameID string, needsSync bool, err error) {
+	// Try to parse as new format first (without board data)
+	gameStartV2, err := protocol.ParseGameStartV2(data)
+	if err == nil && gameStartV2.Rows > 0 && gameStartV2.Cols > 0 {
+		state := newV2GameState(gameStartV2.Rows, gameStartV2.Cols, gameStartV2.YourPlayer)
+		state.Rules = gameStartV2.GameRules
+		// Bases are not part of this format; the full state carries them
+		return state, gameStartV2.GameID, true, nil
+	}
+
+	// Old format with board data
+	gameStart, err := protocol.ParseGameStart(data)
+	if err != nil {
+		return nil, "", false, err
+	}
 
-		if c.debug {
-			log.Printf("Game started: you are player %d (gameId: %s)", gameStartV2.YourPlayer, gameStartV2.GameID)
-			log.Printf("Your base is at (%d, %d)", players[gameStartV2.YourPlayer-1].Position.Row, players[gameStartV2.YourPlayer-1].Position.Col)
+	if len(gameStart.Board) == 0 {
+		if defaultSize <= 0 {
+			return nil, "", false, fmt.Errorf("game_start has no board dimensions")
 		}
-	} else {
-		// Old format with board data
-		gameStart, err := protocol.ParseGameStart(data)
-		if err != nil {
-			return err
+		c.logger.Warnf("game_start has no board dimensions, assuming %dx%d until the server sends the real board", defaultSize, defaultSize)
+		// The new format may not have parsed at all, so take what we can
+		// from the old one
+		yourPlayer := gameStart.YourPlayerID
+		if gameStartV2 != nil && gameStartV2.YourPlayer != 0 {
+			yourPlayer = gameStartV2.YourPlayer
 		}
+		state := newV2GameState(defaultSize, defaultSize, yourPlayer)
+		state.Rules = gameStart.GameRules
+		state.SizeGuessed = true
+		return state, gameIDOf(gameStartV2), true, nil
+	}
 
-		c.mu.Lock()
-		c.gameState = &GameState{
-			Board:         gameStart.Board,
-			Players:       gameStart.Players,
-			CurrentPlayer: gameStart.CurrentPlayer,
-			YourPlayerID:  gameStart.YourPlayerID,
-		}
-		c.mu.Unlock()
+	return &GameState{
+		Board:         gameStart.Board,
+		Players:       gameStart.Players,
+		CurrentPlayer: gameStart.CurrentPlayer,
+		YourPlayerID:  gameStart.YourPlayerID,
+		Rules:         gameStart.GameRules,
+	}, gameIDOf(gameStartV2), false, nil
+}
 
-		if c.debug {
-			log.Printf("Game started: you are player %d", gameStart.YourPlayerID)
-		}
+// gameIDOf returns the gameId a game_start carried, if it parsed at all
+func gameIDOf(msg *protocol.GameStartV2Message) string {
+	if msg == nil {
+		return ""
 	}
+	return msg.GameID
+}
 
-	if c.callback != nil {
-		c.callback("game_start", c.gameState)
+// newV2GameState creates an empty rows x cols board for a game_start that
+// only gave its dimensions. The players and their bases are unknown until
+// the server sends the board or a player list, so the players are
+// placeholders without a base position.
+func newV2GameState(rows, cols, yourPlayer int) *GameState {
+	board := make([][]protocol.CellType, rows)
+	for i := range board {
+		board[i] = make([]protocol.CellType, cols)
 	}
 
-	return nil
+	unknown := protocol.Position{Row: -1, Col: -1}
+	players := []protocol.PlayerInfo{
+		{ID: 1, Name: "Player 1", Symbol: protocol.CellPlayer1, Position: unknown, IsAI: true},
+		{ID: 2, Name: "Player 2", Symbol: protocol.CellPlayer2, Position: unknown, IsAI: true},
+	}
+
+	return &GameState{
+		Board:          board,
+		Players:        players,
+		CurrentPlayer:  yourPlayer,
+		YourPlayerID:   yourPlayer,
+		PlayersGuessed: true,
+		BasesPending:   true,
+	}
 }
 
 // handleMoveMade handles a move being made
@@ -277,62 +747,94 @@ func (c *Client) handleMoveMade(data []byte) error {
 		return err
 	}
 
+	// Deferred before the unlock so the request goes out without the lock
+	resync := false
+	defer func() {
+		if resync {
+			if err := c.RequestStateSync(); err != nil {
+				c.logger.Errorf("Failed to request state sync: %v", err)
+			}
+		}
+	}()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if c.gameState == nil {
-		log.Printf("handleMoveMade: gameState is nil")
+		c.logger.Warnf("handleMoveMade: gameState is nil")
 		return nil
 	}
 	if c.gameState.Board == nil {
-		log.Printf("handleMoveMade: Board is nil")
+		c.logger.Warnf("handleMoveMade: Board is nil")
 		return nil
 	}
+	moveMade.Player = c.gameState.normalizePlayer(moveMade.Player, c.logger)
 
 	boardRows := len(c.gameState.Board)
-	boardCols := 0
-	if boardRows > 0 {
-		boardCols = len(c.gameState.Board[0])
-	}
-
-	if boardRows <= moveMade.Row {
-		log.Printf("handleMoveMade: Board has %d rows, but move row %d is out of bounds", boardRows, moveMade.Row)
+	if moveMade.Row < 0 || moveMade.Row >= boardRows {
+		c.logger.Warnf("handleMoveMade: Board has %d rows, but move row %d is out of bounds", boardRows, moveMade.Row)
 		return nil
 	}
-	if boardCols <= moveMade.Col {
-		log.Printf("handleMoveMade: Board row %d has %d cols, but move col %d is out of bounds", moveMade.Row, boardCols, moveMade.Col)
+	if boardCols := len(c.gameState.Board[moveMade.Row]); moveMade.Col < 0 || moveMade.Col >= boardCols {
+		c.logger.Warnf("handleMoveMade: Board row %d has %d cols, but move col %d is out of bounds", moveMade.Row, boardCols, moveMade.Col)
 		return nil
 	}
 
+	// Our own echo confirms (or corrects) an optimistic write from MakeMove.
+	// Undo that write first so the board reflects only what the server applied.
+	if moveMade.Player == c.gameState.YourPlayerID && len(c.pendingMoves) > 0 {
+		pending := c.pendingMoves[0]
+		c.pendingMoves = c.pendingMoves[1:]
+		c.gameState.Board[pending.pos.Row][pending.pos.Col] = pending.previous
+		c.recordLatency(time.Since(pending.sentAt))
+		if pending.pos.Row != moveMade.Row || pending.pos.Col != moveMade.Col {
+			c.logger.Warnf("handleMoveMade: server corrected our move (%d, %d) to (%d, %d)",
+				pending.pos.Row, pending.pos.Col, moveMade.Row, moveMade.Col)
+			c.correctedMoves++
+		}
+		c.notifyConfirmed()
+	}
+
 	// Mark the cell with the player's cell type
 	// If the cell was already occupied (attack), mark it as fortified
-	// If it was empty (place), mark it as normal
-	wasOccupied := c.gameState.Board[moveMade.Row][moveMade.Col] != protocol.CellEmpty
-	var cellType protocol.CellType
-	if wasOccupied {
-		// Attack move - cell becomes fortified (cannot be re-attacked)
-		cellType = protocol.CellType(moveMade.Player | int(protocol.CellFlagFortified))
+	// If it was empty or special (place), mark it as normal
+	previous := c.gameState.Board[moveMade.Row][moveMade.Col]
+	wasOccupied := previous != protocol.CellEmpty && !previous.IsSpecial()
+	if c.gameState.Synced && wasOccupied && !isAttackable(previous, moveMade.Player) {
+		// The board came from the server, so a move onto a cell no move
+		// may take means we missed something: fetch the board, don't guess
+		c.logger.Warnf("handleMoveMade: player %d moved onto (%d, %d) holding %d, resyncing the board",
+			moveMade.Player, moveMade.Row, moveMade.Col, previous)
+		resync = true
 	} else {
-		// Place move - cell becomes normal (can be attacked)
-		cellType = protocol.CellType(moveMade.Player | int(protocol.CellFlagNormal))
-	}
-	c.gameState.Board[moveMade.Row][moveMade.Col] = cellType
+		var cellType protocol.CellType
+		if wasOccupied {
+			// Attack move - cell becomes fortified (cannot be re-attacked)
+			cellType = protocol.CellType(moveMade.Player | int(protocol.CellFlagFortified))
+		} else {
+			// Place move - cell becomes normal (can be attacked)
+			cellType = protocol.CellType(moveMade.Player | int(protocol.CellFlagNormal))
+		}
+		c.gameState.Board[moveMade.Row][moveMade.Col] = cellType
 
-	moveTypeStr := "place"
-	if wasOccupied {
-		moveTypeStr = "attack (fortified)"
+		moveTypeStr := "place"
+		if wasOccupied {
+			moveTypeStr = "attack (fortified)"
+		} else if previous.IsSpecial() {
+			moveTypeStr = "place (special)"
+		}
+		c.logger.Debugf("handleMoveMade: %s - Updated board[%d][%d] = %d (player %d, flag %d)", moveTypeStr, moveMade.Row, moveMade.Col, cellType, moveMade.Player, cellType.Flag())
 	}
-	log.Printf("handleMoveMade: %s - Updated board[%d][%d] = %d (player %d, flag %d)", moveTypeStr, moveMade.Row, moveMade.Col, cellType, moveMade.Player, cellType.Flag())
 
 	// Update base position for player if not yet set
 	// The first move for each player establishes their base position
 	if c.gameState.Players != nil {
 		for i := range c.gameState.Players {
-			if c.gameState.Players[i].ID == moveMade.Player {
+			if c.gameState.Players[i].ID == moveMade.Player && c.gameState.Players[i].Position.Row < 0 {
 				// Check if this is the first cell for this player (base position)
 				cellCount := 0
 				for r := 0; r < boardRows; r++ {
-					for col := 0; col < boardCols; col++ {
+					for col := 0; col < len(c.gameState.Board[r]); col++ {
 						// Use Player() method to compare player IDs (ignores flags)
 						if c.gameState.Board[r][col].Player() == moveMade.Player {
 							cellCount++
@@ -345,25 +847,35 @@ func (c *Client) handleMoveMade(data []byte) error {
 						Row: moveMade.Row,
 						Col: moveMade.Col,
 					}
-					if c.debug {
-						log.Printf("Set base position for player %d to (%d, %d)", moveMade.Player, moveMade.Row, moveMade.Col)
-					}
+					c.logger.Debugf("Set base position for player %d to (%d, %d)", moveMade.Player, moveMade.Row, moveMade.Col)
 				}
 				break
 			}
 		}
 	}
 
-	// Only change turn when movesLeft reaches 0
-	if moveMade.MovesLeft == 0 {
-		log.Printf("handleMoveMade: Turn changing from %d to %d (movesLeft=0)", c.gameState.CurrentPlayer, (c.gameState.CurrentPlayer+1)%2)
-		c.gameState.CurrentPlayer = (c.gameState.CurrentPlayer + 1) % 2
+	// The server's count for our own move is authoritative: whatever it
+	// leaves, less the moves still in flight, is what we may still send
+	if moveMade.Player == c.gameState.YourPlayerID && c.gameState.CurrentPlayer == moveMade.Player {
+		c.turnBudget = c.movesSent + max(moveMade.MovesLeft-len(c.pendingMoves), 0)
 	}
 
-	if c.debug {
-		log.Printf("Player %d moved to (%d, %d), movesLeft=%d", moveMade.Player, moveMade.Row, moveMade.Col, moveMade.MovesLeft)
+	// Only change turn when movesLeft reaches 0. Without a real player list
+	// the next player is unknown, so wait for the server's turn_change.
+	if moveMade.MovesLeft == 0 {
+		if next, ok := c.gameState.nextPlayer(moveMade.Player); ok {
+			c.logger.Debugf("handleMoveMade: Turn changing from %d to %d (movesLeft=0)", moveMade.Player, next)
+			c.gameState.CurrentPlayer = next
+			if next == c.gameState.YourPlayerID {
+				c.resetTurnBudget(0)
+			}
+		} else {
+			c.logger.Debugf("handleMoveMade: player list unknown, waiting for turn_change")
+		}
 	}
 
+	c.logger.Debugf("Player %d moved to (%d, %d), movesLeft=%d", moveMade.Player, moveMade.Row, moveMade.Col, moveMade.MovesLeft)
+
 	if c.callback != nil {
 		c.callback("move_made", moveMade)
 	}
@@ -371,85 +883,454 @@ func (c *Client) handleMoveMade(data []byte) error {
 	return nil
 }
 
-// handleGameEnd handles the end of a game
-func (c *Client) handleGameEnd(data []byte) error {
-	gameEnd, err := protocol.ParseGameEnd(data)
-	if err != nil {
-		return err
+// recordLatency adds a move round trip to the rolling window and, with
+// adaptive pacing, keeps our moves to the server's pace: the delay follows
+// the average latency, never below the configured move delay.
+// The caller must hold c.mu.
+func (c *Client) recordLatency(latency time.Duration) {
+	if len(c.latencies) == latencyWindow {
+		c.latencies = append(c.latencies[:0], c.latencies[1:]...)
 	}
+	c.latencies = append(c.latencies, latency)
 
-	if c.debug {
-		log.Printf("Game ended! Winner: Player %d", gameEnd.Winner)
+	if !c.config.AdaptiveMoveDelay {
+		return
+	}
+	if delay := max(c.averageLatency(), c.config.MoveDelay); delay != c.moveDelay {
+		c.logger.Debugf("Server latency moves the move delay from %v to %v", c.moveDelay, delay)
+		c.moveDelay = delay
 	}
+}
 
-	if c.callback != nil {
-		c.callback("game_end", gameEnd)
+// averageLatency averages the recorded round trips. The caller must hold c.mu.
+func (c *Client) averageLatency() time.Duration {
+	if len(c.latencies) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, l := range c.latencies {
+		total += l
 	}
+	return total / time.Duration(len(c.latencies))
+}
 
-	return nil
+// AverageMoveLatency returns the rolling average time between sending a move
+// and receiving its move_made echo, or zero before any move was confirmed
+func (c *Client) AverageMoveLatency() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.averageLatency()
 }
 
-// handleTurnChange handles turn change notifications
-func (c *Client) handleTurnChange(data []byte) error {
-	turnChange, err := protocol.ParseTurnChange(data)
+// handleGameEnd handles the end of a game
+func (c *Client) handleGameEnd(data []byte) error {
+	gameEnd, err := protocol.ParseGameEnd(data)
 	if err != nil {
 		return err
 	}
 
 	c.mu.Lock()
-	if c.gameState != nil {
-		c.gameState.CurrentPlayer = turnChange.Player
-		log.Printf("Turn changed to player %d", turnChange.Player)
-	} else {
-		log.Printf("Turn change ignored: no game state")
-	}
+	c.inGame = false
+	c.pendingMoves = nil
+	c.notifyConfirmed()
 	c.mu.Unlock()
 
-	return nil
-}
+	c.logger.Debugf("Game ended! Winner: Player %d", gameEnd.Winner)
 
-// handleUsersUpdate handles the list of online users
-func (c *Client) handleUsersUpdate(data interface{}) {
 	if c.callback != nil {
-		c.callback("users_update", data)
+		c.callback("game_end", gameEnd)
 	}
-}
 
-// handleChallenge handles incoming challenge messages
-func (c *Client) handleChallenge(data []byte) error {
-	if c.debug {
-		log.Printf("Challenge data: %s", string(data))
-	}
+	return nil
+}
 
-	challenge, err := protocol.ParseChallenge(data)
+// handleGameState replaces our board with the server's snapshot. It is the
+// board a game_start without one waits for, and it corrects any drift in
+// the board we built from moves. Our moves still awaiting an echo are
+// forgotten: the snapshot already shows whatever the server applied.
+func (c *Client) handleGameState(data []byte) error {
+	snapshot, err := protocol.ParseGameState(data)
 	if err != nil {
 		return err
 	}
 
 	c.mu.Lock()
-	c.currentChallenge = challenge.ChallengeID
+	state := c.gameState
+	if state == nil || (snapshot.GameID != "" && c.gameID != "" && snapshot.GameID != c.gameID) {
+		c.mu.Unlock()
+		c.logger.Debugf("Ignoring game_state for game %q: not our game", snapshot.GameID)
+		return nil
+	}
+
+	if err := checkSnapshotSize(snapshot.Board, state); err != nil {
+		c.mu.Unlock()
+		c.logger.Warnf("Ignoring game_state: %v", err)
+		return nil
+	}
+
+	state.Board = snapshot.Board
+	state.SizeGuessed = false
+	state.Synced = true
+	if len(snapshot.Players) > 0 {
+		for i := range snapshot.Players {
+			snapshot.Players[i].ID = state.normalizePlayer(snapshot.Players[i].ID, c.logger)
+		}
+		state.Players = snapshot.Players
+		state.PlayersGuessed = false
+	}
+	state.locateBases()
+	if snapshot.CurrentPlayer != nil {
+		player := state.normalizePlayer(*snapshot.CurrentPlayer, c.logger)
+		turnStarts := player == state.YourPlayerID && state.CurrentPlayer != player
+		state.CurrentPlayer = player
+		if turnStarts {
+			c.resetTurnBudget(snapshot.MovesLeft)
+		}
+	}
+	c.pendingMoves = nil
+	c.notifyConfirmed()
+	synced := state.Clone()
 	c.mu.Unlock()
 
-	if c.debug {
-		log.Printf("Challenge received from %s (ID: %s)", challenge.FromUserName, challenge.ChallengeID)
+	c.logger.Debugf("Board synced from the server (%dx%d)", len(snapshot.Board), len(snapshot.Board[0]))
+
+	if c.callback != nil {
+		c.callback("game_state", synced)
+	}
+	return nil
+}
+
+// checkSnapshotSize reports a snapshot board with rows of uneven length, or
+// whose dimensions differ from the game's. A board we only assumed the
+// size of takes the snapshot's dimensions.
+func checkSnapshotSize(board [][]protocol.CellType, state *GameState) error {
+	rows, cols := game.Dimensions(board)
+	for i, row := range board {
+		if len(row) != cols {
+			return fmt.Errorf("row %d has %d cells, row 0 has %d", i, len(row), cols)
+		}
+	}
+	if state.SizeGuessed {
+		return nil
+	}
+	if wantRows, wantCols := game.Dimensions(state.Board); rows != wantRows || cols != wantCols {
+		return fmt.Errorf("board is %dx%d, the game's is %dx%d", rows, cols, wantRows, wantCols)
+	}
+	return nil
+}
+
+// isAttackable reports whether player may attack the cell: a normal cell
+// of another player
+func isAttackable(cell protocol.CellType, player int) bool {
+	owner := cell.Player()
+	return owner >= int(protocol.CellPlayer1) && owner <= int(protocol.CellPlayer4) &&
+		owner != player && cell.CanBeAttacked()
+}
+
+// handleTurnChange handles turn change notifications
+func (c *Client) handleTurnChange(data []byte) error {
+	turnChange, err := protocol.ParseTurnChange(data)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.gameState != nil {
+		turnChange.Player = c.gameState.normalizePlayer(turnChange.Player, c.logger)
+		c.gameState.CurrentPlayer = turnChange.Player
+		if turnChange.Player == c.gameState.YourPlayerID {
+			if turnChange.Skipped {
+				c.skipTurn()
+			} else {
+				c.resetTurnBudget(turnChange.MovesLeft)
+			}
+		}
+		c.logger.Infof("Turn changed to player %d", turnChange.Player)
+	} else {
+		c.logger.Warnf("Turn change ignored: no game state")
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// handlePlayersUpdate replaces our game's player list with the server's,
+// which is authoritative for eliminations and base positions
+func (c *Client) handlePlayersUpdate(data []byte) error {
+	update, err := protocol.ParsePlayersUpdate(data)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.gameState == nil {
+		c.mu.Unlock()
+		c.logger.Debugf("Ignoring players update outside a game")
+		return nil
+	}
+	for i := range update.Players {
+		update.Players[i].ID = c.gameState.normalizePlayer(update.Players[i].ID, c.logger)
+	}
+	c.gameState.Players = update.Players
+	c.gameState.PlayersGuessed = false
+	if c.gameState.BasesPending {
+		c.gameState.placeKnownBases()
+	}
+	c.mu.Unlock()
+
+	for _, p := range update.Players {
+		if p.Eliminated {
+			c.logger.Debugf("Player %d (%s) is eliminated", p.ID, p.Name)
+		}
 	}
 
 	if c.callback != nil {
-		if c.debug {
-			log.Printf("Calling challenge callback...")
+		c.callback("players_update", update)
+	}
+
+	return nil
+}
+
+// handleUsersUpdate stores the list of online users and, with auto-challenge
+// enabled, challenges an idle one while we are not playing
+func (c *Client) handleUsersUpdate(data []byte) error {
+	update, err := protocol.ParseUsersUpdate(data)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.onlineUsers = update.Users
+	target := ""
+	autoChallenge := c.config.AutoChallenge || c.config.ChallengeTarget != ""
+	if autoChallenge && !c.inGame && !c.shuttingDown && c.challengedUser == "" {
+		target = c.pickIdleUser()
+		c.challengedUser = target
+	}
+	c.mu.Unlock()
+
+	if c.callback != nil {
+		c.callback("users_update", update)
+	}
+
+	if target != "" {
+		return c.ChallengeUser(target)
+	}
+	return nil
+}
+
+// pickIdleUser returns the first idle user other than ourselves, or only
+// the configured challenge target (by name or ID) when one is set.
+// The caller must hold c.mu.
+func (c *Client) pickIdleUser() string {
+	target := c.config.ChallengeTarget
+	for _, user := range c.onlineUsers {
+		if user.Status != "idle" || user.ID == c.userID {
+			continue
 		}
-		c.callback("challenge", challenge)
-		if c.debug {
-			log.Printf("Challenge callback returned")
+		if target == "" || target == user.Name || target == user.ID {
+			return user.ID
 		}
 	}
+	return ""
+}
+
+// OnlineUsers returns a copy of the latest online user list
+func (c *Client) OnlineUsers() []protocol.UserInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	users := make([]protocol.UserInfo, len(c.onlineUsers))
+	copy(users, c.onlineUsers)
+	return users
+}
+
+// ChallengeUser challenges a user to a game. The server confirms with
+// challenge_sent, reported as a "challenge_sent" event with the challenge ID.
+func (c *Client) ChallengeUser(userID string) error {
+	c.logger.Debugf("Challenging user: %s", userID)
+
+	// Send the correct format without nested "data" field
+	msg := map[string]interface{}{
+		"type":         protocol.MsgSendChallenge,
+		"targetUserId": userID,
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal challenge: %w", err)
+	}
+
+	c.mu.RLock()
+	connected := c.connected
+	c.mu.RUnlock()
+
+	if !connected {
+		return fmt.Errorf("not connected")
+	}
+
+	if err := c.transport().WriteMessage(data); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return nil
+}
+
+// RequestStateSync asks the server to resend the full state of our game
+func (c *Client) RequestStateSync() error {
+	c.mu.RLock()
+	connected := c.connected
+	gameID := c.gameID
+	c.mu.RUnlock()
+
+	if !connected {
+		return fmt.Errorf("not connected")
+	}
+
+	msg := map[string]interface{}{
+		"type":   protocol.MsgRequestState,
+		"gameId": gameID,
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state request: %w", err)
+	}
+
+	c.logger.Debugf("Requesting state sync for game %s", gameID)
+
+	if err := c.transport().WriteMessage(data); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return nil
+}
+
+// handleChallengeSent handles the server's confirmation of our challenge
+func (c *Client) handleChallengeSent(data []byte) error {
+	sent, err := protocol.ParseChallengeSent(data)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Debugf("Challenge %s sent to %s", sent.ChallengeID, sent.TargetUserID)
+
+	if c.callback != nil {
+		c.callback("challenge_sent", sent)
+	}
+
+	return nil
+}
+
+// handleChallenge handles incoming challenge messages
+func (c *Client) handleChallenge(data []byte) error {
+	c.logger.Debugf("Challenge data: %s", string(data))
+
+	challenge, err := protocol.ParseChallenge(data)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.currentChallenge = challenge.ChallengeID
+	c.stopDelayedAccept()
+	c.mu.Unlock()
+
+	c.logger.Debugf("Challenge received from %s (ID: %s)", challenge.FromUserName, challenge.ChallengeID)
+
+	if c.callback != nil {
+		c.logger.Debugf("Calling challenge callback...")
+		c.callback("challenge", challenge)
+		c.logger.Debugf("Challenge callback returned")
+	}
 
 	// Auto-accept challenge if configured
-	if c.debug {
-		log.Printf("AutoAcceptChallenge: %v", c.config.AutoAcceptChallenge)
+	c.logger.Debugf("AutoAcceptChallenge: %v", c.config.AutoAcceptChallenge)
+	if c.ShuttingDown() {
+		c.logger.Infof("Declining challenge %s: shutting down", challenge.ChallengeID)
+		return c.DeclineChallenge(challenge.ChallengeID)
+	}
+	if c.declines(challenge) {
+		return c.DeclineChallenge(challenge.ChallengeID)
 	}
 	if c.config.AutoAcceptChallenge {
-		return c.AcceptChallenge(challenge.ChallengeID)
+		if c.config.AcceptDelay <= 0 {
+			return c.AcceptChallenge(challenge.ChallengeID)
+		}
+
+		cancel := make(chan struct{})
+		c.mu.Lock()
+		c.acceptCancel = cancel
+		c.mu.Unlock()
+		go c.acceptAfter(challenge.ChallengeID, c.config.AcceptDelay, cancel)
+	}
+
+	return nil
+}
+
+// declines reports whether a challenge is to be declined: it comes from a
+// user on the decline list, or auto-decline is on and auto-accept is off
+func (c *Client) declines(challenge *protocol.ChallengeMessage) bool {
+	for _, user := range c.config.DeclineUsers {
+		if user == challenge.FromUserName || user == challenge.FromUserID {
+			return true
+		}
+	}
+	return c.config.AutoDecline && !c.config.AutoAcceptChallenge
+}
+
+// acceptAfter accepts a challenge once the accept delay has passed, unless
+// the challenge was withdrawn or replaced in the meantime
+func (c *Client) acceptAfter(challengeID string, delay time.Duration, cancel <-chan struct{}) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-cancel:
+		c.logger.Debugf("Delayed accept of challenge %s cancelled", challengeID)
+		return
+	case <-c.ctx.Done():
+		return
+	}
+
+	c.mu.RLock()
+	current := c.currentChallenge
+	c.mu.RUnlock()
+	if current != challengeID {
+		return
+	}
+
+	if err := c.AcceptChallenge(challengeID); err != nil {
+		c.logger.Errorf("Failed to accept challenge %s: %v", challengeID, err)
+	}
+}
+
+// stopDelayedAccept cancels a pending delayed accept. Callers hold c.mu.
+func (c *Client) stopDelayedAccept() {
+	if c.acceptCancel != nil {
+		close(c.acceptCancel)
+		c.acceptCancel = nil
+	}
+}
+
+// handleChallengeCancelled forgets a challenge the challenger withdrew
+func (c *Client) handleChallengeCancelled(data []byte) error {
+	cancelled, err := protocol.ParseChallengeCancelled(data)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.currentChallenge == cancelled.ChallengeID {
+		c.currentChallenge = ""
+		c.stopDelayedAccept()
+	}
+	c.mu.Unlock()
+
+	c.logger.Debugf("Challenge %s cancelled", cancelled.ChallengeID)
+
+	if c.callback != nil {
+		c.callback("challenge_cancelled", cancelled)
 	}
 
 	return nil
@@ -457,9 +1338,7 @@ func (c *Client) handleChallenge(data []byte) error {
 
 // AcceptChallenge accepts a challenge by ID
 func (c *Client) AcceptChallenge(challengeID string) error {
-	if c.debug {
-		log.Printf("Accepting challenge: %s", challengeID)
-	}
+	c.logger.Debugf("Accepting challenge: %s", challengeID)
 
 	// Send the correct format without nested "data" field
 	msg := map[string]interface{}{
@@ -472,9 +1351,7 @@ func (c *Client) AcceptChallenge(challengeID string) error {
 		return fmt.Errorf("failed to marshal accept challenge: %w", err)
 	}
 
-	if c.debug {
-		log.Printf("Sending message: %s", string(data))
-	}
+	c.logger.Debugf("Sending message: %s", string(data))
 
 	c.mu.RLock()
 	connected := c.connected
@@ -484,13 +1361,77 @@ func (c *Client) AcceptChallenge(challengeID string) error {
 		return fmt.Errorf("not connected")
 	}
 
-	if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+	if err := c.transport().WriteMessage(data); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	if c.config.ChallengeTimeout > 0 {
+		go c.awaitAcceptedGame(challengeID, c.config.ChallengeTimeout)
+	}
+
+	return nil
+}
+
+// DeclineChallenge declines a challenge by ID
+func (c *Client) DeclineChallenge(challengeID string) error {
+	c.logger.Debugf("Declining challenge: %s", challengeID)
+
+	msg := map[string]interface{}{
+		"type":        protocol.MsgDeclineChallenge,
+		"challengeId": challengeID,
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal decline challenge: %w", err)
+	}
+
+	c.mu.Lock()
+	connected := c.connected
+	if connected && c.currentChallenge == challengeID {
+		c.currentChallenge = ""
+		c.stopDelayedAccept()
+	}
+	c.mu.Unlock()
+
+	if !connected {
+		return fmt.Errorf("not connected")
+	}
+
+	if err := c.transport().WriteMessage(data); err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 
+	if c.callback != nil {
+		c.callback("challenge_declined", challengeID)
+	}
+
 	return nil
 }
 
+// awaitAcceptedGame forgets an accepted challenge whose game does not start
+// within the timeout, e.g. because the accept was lost. The
+// "challenge_timeout" callback runs on this goroutine, not the client's.
+func (c *Client) awaitAcceptedGame(challengeID string, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	defer cancel()
+	if _, err := c.WaitForGameStart(ctx); err == nil || c.ctx.Err() != nil {
+		return
+	}
+
+	c.mu.Lock()
+	if c.currentChallenge == challengeID {
+		c.currentChallenge = ""
+	}
+	c.mu.Unlock()
+
+	c.logger.Warnf("No game started within %v of accepting challenge %s, giving up on it", timeout, challengeID)
+
+	if c.callback != nil {
+		c.callback("challenge_timeout", challengeID)
+	}
+}
+
 // handleDisconnect handles connection loss
 func (c *Client) handleDisconnect() {
 	c.mu.Lock()
@@ -517,22 +1458,76 @@ func (c *Client) SendMessage(msg *protocol.Message) error {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	if c.debug {
-		log.Printf("Sending message: %s", string(data))
-	}
+	c.logger.Debugf("Sending message: %s", string(data))
 
-	if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+	if err := c.transport().WriteMessage(data); err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 
 	return nil
 }
 
-// MakeMove sends a move to the server
+// resetTurnBudget starts a new turn for us. movesLeft is the budget announced
+// by the server, or 0 to use the game's moves per turn, falling back to the
+// configured one. Callers hold c.mu.
+func (c *Client) resetTurnBudget(movesLeft int) {
+	c.turnStart = time.Now()
+	c.movesSent = 0
+	c.turnPassed = false
+	c.rejectedMoves = nil
+	c.turnBudget = movesLeft
+	if c.turnBudget <= 0 && c.gameState != nil {
+		c.turnBudget = c.gameState.Rules.MovesPerTurn
+	}
+	if c.turnBudget <= 0 {
+		c.turnBudget = c.config.MovesPerTurn
+	}
+
+	// Wake the turn loop; a signal already queued covers this turn too
+	select {
+	case c.turnStarted <- struct{}{}:
+	default:
+	}
+}
+
+// skipTurn acknowledges a turn the server gave us without any moves, so
+// that nothing is sent until the next one. Callers hold c.mu.
+func (c *Client) skipTurn() {
+	c.logger.Infof("Our turn was skipped: no moves this turn")
+	c.movesSent = 0
+	c.turnBudget = 0
+	c.turnPassed = true
+}
+
+// MakeMove sends a move to the server. It refuses to send more moves than
+// the current turn allows, so a desynced caller cannot get us penalized.
+//
+// The move delay is a rate limit, not a pause before every move: a move is
+// sent at once unless the previous one went out less than the delay ago,
+// in which case it waits out the rest. Only the caller waits; the read loop
+// keeps running.
 func (c *Client) MakeMove(row, col int) error {
-	// Add delay if configured
-	if c.moveDelay > 0 {
-		time.Sleep(c.moveDelay)
+	c.mu.Lock()
+	movesSent, turnBudget := c.movesSent, c.turnBudget
+	if turnBudget > 0 && movesSent >= turnBudget {
+		c.mu.Unlock()
+		return fmt.Errorf("move budget exhausted: already sent %d of %d moves this turn", movesSent, turnBudget)
+	}
+	sendAt := time.Now()
+	if c.nextMoveAt.After(sendAt) {
+		sendAt = c.nextMoveAt
+	}
+	c.nextMoveAt = sendAt.Add(c.moveDelay)
+	c.mu.Unlock()
+
+	if wait := time.Until(sendAt); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-c.ctx.Done():
+			timer.Stop()
+			return c.ctx.Err()
+		}
 	}
 
 	c.mu.RLock()
@@ -552,9 +1547,7 @@ func (c *Client) MakeMove(row, col int) error {
 		return fmt.Errorf("failed to marshal move: %w", err)
 	}
 
-	if c.debug {
-		log.Printf("Sending move: %s", string(data))
-	}
+	c.logger.Debugf("Sending move: %s", string(data))
 
 	c.mu.RLock()
 	connected := c.connected
@@ -564,25 +1557,33 @@ func (c *Client) MakeMove(row, col int) error {
 		return fmt.Errorf("not connected")
 	}
 
-	if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+	if err := c.transport().WriteMessage(data); err != nil {
 		return fmt.Errorf("failed to send move: %w", err)
 	}
 
 	// Update local board state immediately after sending move
 	c.mu.Lock()
-	if c.gameState != nil && c.gameState.Board != nil {
+	c.movesSent++
+	if c.gameState != nil {
 		// Update board with our move
 		// Check if it was an attack or a place
-		wasOccupied := c.gameState.Board[row][col] != protocol.CellEmpty
-		var cellType protocol.CellType
-		if wasOccupied {
-			// Attack move - cell becomes fortified
-			cellType = protocol.CellType(c.gameState.YourPlayerID | int(protocol.CellFlagFortified))
-		} else {
-			// Place move - cell becomes normal
-			cellType = protocol.CellType(c.gameState.YourPlayerID | int(protocol.CellFlagNormal))
-		}
-		if row >= 0 && row < len(c.gameState.Board) && col >= 0 && col < len(c.gameState.Board[row]) {
+		if previous, ok := c.gameState.cellAt(row, col); ok {
+			wasOccupied := previous != protocol.CellEmpty && !previous.IsSpecial()
+			var cellType protocol.CellType
+			if wasOccupied {
+				// Attack move - cell becomes fortified
+				cellType = protocol.CellType(c.gameState.YourPlayerID | int(protocol.CellFlagFortified))
+			} else {
+				// Place move - cell becomes normal
+				cellType = protocol.CellType(c.gameState.YourPlayerID | int(protocol.CellFlagNormal))
+			}
+
+			// Remember what was there so the server's echo can correct us
+			c.pendingMoves = append(c.pendingMoves, pendingMove{
+				pos:      protocol.Position{Row: row, Col: col},
+				previous: previous,
+				sentAt:   time.Now(),
+			})
 			c.gameState.Board[row][col] = cellType
 
 			// If this is our first move, set it as our base position
@@ -602,9 +1603,7 @@ func (c *Client) MakeMove(row, col int) error {
 						// If this is the first cell, set base position
 						if cellCount == 1 {
 							c.gameState.Players[i].Position = protocol.Position{Row: row, Col: col}
-							if c.debug {
-								log.Printf("Set OUR base position to (%d, %d)", row, col)
-							}
+							c.logger.Debugf("Set OUR base position to (%d, %d)", row, col)
 						}
 						break
 					}
@@ -629,23 +1628,438 @@ func (c *Client) JoinLobby(lobbyID string) error {
 	return c.SendMessage(msg)
 }
 
-// GetGameState returns the current game state
+// handleError handles a request the server refused. While moves await
+// their echo the error is taken as the refusal of the oldest one: its
+// optimistic write is undone, the cell is remembered so the turn moves on
+// to another target, and the full state is requested in case our board has
+// drifted from the server's.
+func (c *Client) handleError(data []byte) error {
+	serverErr, err := protocol.ParseError(data)
+	if err != nil {
+		return err
+	}
+	c.logger.Warnf("Server error: %s", serverErr.Message)
+
+	// Only a move error refuses our oldest move in flight; others leave the
+	// moves to their echoes
+	c.mu.Lock()
+	rejected := serverErr.IsMoveError() && c.gameState != nil && len(c.pendingMoves) > 0
+	if rejected {
+		pending := c.pendingMoves[0]
+		c.pendingMoves = c.pendingMoves[1:]
+		c.gameState.Board[pending.pos.Row][pending.pos.Col] = pending.previous
+		c.movesSent = max(c.movesSent-1, 0)
+		if c.rejectedMoves == nil {
+			c.rejectedMoves = make(map[protocol.Position]bool)
+		}
+		c.rejectedMoves[pending.pos] = true
+		c.correctedMoves++
+		c.notifyConfirmed()
+	}
+	c.mu.Unlock()
+
+	if rejected {
+		if err := c.RequestStateSync(); err != nil {
+			c.logger.Errorf("Failed to request state sync: %v", err)
+		}
+	}
+
+	if c.callback != nil {
+		c.callback("error", serverErr)
+	}
+	return nil
+}
+
+// handleLobbyUpdate records the lobby the server says we are in and its
+// board size
+func (c *Client) handleLobbyUpdate(data []byte) error {
+	lobby, err := protocol.ParseLobby(data)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.lobbyID = lobby.LobbyID
+	c.lobbyBoardSize = lobby.BoardSize
+	c.mu.Unlock()
+
+	c.logger.Debugf("In lobby %s with %d players, %dx%d board", lobby.LobbyID, len(lobby.Players), lobby.BoardSize, lobby.BoardSize)
+
+	if c.callback != nil {
+		c.callback("lobby_update", lobby)
+	}
+
+	return nil
+}
+
+// defaultBoardSize is the board size assumed for a game_start without
+// dimensions: our lobby's, or the configured default outside a lobby
+func (c *Client) defaultBoardSize() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.lobbyBoardSize > 0 {
+		return c.lobbyBoardSize
+	}
+	return c.config.DefaultBoardSize
+}
+
+// LobbyID returns the lobby we are in, or "" outside a lobby
+func (c *Client) LobbyID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lobbyID
+}
+
+// currentLobby returns the lobby we are in, failing outside a lobby
+func (c *Client) currentLobby() (string, error) {
+	lobbyID := c.LobbyID()
+	if lobbyID == "" {
+		return "", fmt.Errorf("not in a lobby")
+	}
+	return lobbyID, nil
+}
+
+// LeaveLobby leaves the current lobby
+func (c *Client) LeaveLobby() error {
+	lobbyID, err := c.currentLobby()
+	if err != nil {
+		return err
+	}
+	if err := c.SendMessage(protocol.NewLeaveLobbyMessage(lobbyID)); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.lobbyID == lobbyID {
+		c.lobbyID = ""
+		c.lobbyBoardSize = 0
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// StartMultiplayerGame asks the server to start the current lobby's game
+func (c *Client) StartMultiplayerGame() error {
+	lobbyID, err := c.currentLobby()
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(protocol.NewStartMultiplayerMessage(lobbyID))
+}
+
+// AddBot asks the server to add one of its bots to the current lobby
+func (c *Client) AddBot() error {
+	lobbyID, err := c.currentLobby()
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(protocol.NewAddBotMessage(lobbyID))
+}
+
+// RemoveBot removes a bot from the current lobby
+func (c *Client) RemoveBot(botID string) error {
+	lobbyID, err := c.currentLobby()
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(protocol.NewRemoveBotMessage(lobbyID, botID))
+}
+
+// JoinRoom subscribes to the game messages of a room
+func (c *Client) JoinRoom(roomID string) error {
+	if err := c.SendMessage(protocol.NewJoinRoomMessage(roomID)); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if _, exists := c.rooms[roomID]; !exists {
+		c.rooms[roomID] = nil
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// LeaveRoom unsubscribes from a room and drops its observed state
+func (c *Client) LeaveRoom(roomID string) error {
+	c.mu.Lock()
+	delete(c.rooms, roomID)
+	c.mu.Unlock()
+
+	return c.SendMessage(protocol.NewLeaveRoomMessage(roomID))
+}
+
+// GetRooms returns the IDs of all joined rooms
+func (c *Client) GetRooms() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	rooms := make([]string, 0, len(c.rooms))
+	for id := range c.rooms {
+		rooms = append(rooms, id)
+	}
+	return rooms
+}
+
+// GetRoomState returns a snapshot of the observed game state of a joined
+// room, or nil before the room's game has started
+func (c *Client) GetRoomState(roomID string) *GameState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rooms[roomID].Clone()
+}
+
+// GetGameState returns a snapshot of the current game state. It is the
+// caller's to read or change while the client goes on applying moves.
 func (c *Client) GetGameState() *GameState {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.gameState
+	return c.gameState.Clone()
+}
+
+// WaitForGameStart blocks until a game is in progress and returns its
+// initial state. It returns immediately if a game has already started, and
+// fails when ctx is cancelled or its deadline passes first.
+func (c *Client) WaitForGameStart(ctx context.Context) (*game.GameState, error) {
+	c.mu.Lock()
+	if c.inGame {
+		state := c.gameState.Clone().ToGame()
+		c.mu.Unlock()
+		return state, nil
+	}
+	started := make(chan struct{})
+	c.startWaiters = append(c.startWaiters, started)
+	c.mu.Unlock()
+
+	select {
+	case <-started:
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.gameState.Clone().ToGame(), nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for game start: %w", ctx.Err())
+	}
+}
+
+// ErrMoveRejected reports that the server applied a different move than the
+// one we sent, or refused it
+var ErrMoveRejected = errors.New("move rejected by server")
+
+// MakeMoves sends a turn's moves in order. Each move is paced like MakeMove
+// and waits up to the configured confirmation timeout for its echo before
+// the next is sent; an unconfirmed move is assumed applied. It stops at the
+// first move that cannot be sent or that the server corrects, or once the
+// turn is no longer ours, leaving the rest of the batch unsent.
+func (c *Client) MakeMoves(moves []game.Position) error {
+	for i, pos := range moves {
+		if !c.IsMyTurn() {
+			return fmt.Errorf("turn ended after %d of %d moves", i, len(moves))
+		}
+
+		c.mu.RLock()
+		corrected := c.correctedMoves
+		c.mu.RUnlock()
+
+		if err := c.MakeMove(pos.Row, pos.Col); err != nil {
+			return fmt.Errorf("move %d of %d: %w", i+1, len(moves), err)
+		}
+
+		if timeout := c.config.MoveConfirmTimeout; timeout > 0 {
+			ctx, cancel := context.WithTimeout(c.ctx, timeout)
+			err := c.WaitForMoveConfirmation(ctx)
+			cancel()
+			if err != nil {
+				c.logger.Warnf("Move (%d, %d) not confirmed, continuing on the local board: %v", pos.Row, pos.Col, err)
+			}
+		}
+
+		c.mu.RLock()
+		rejected := c.correctedMoves > corrected
+		c.mu.RUnlock()
+		if rejected {
+			return fmt.Errorf("move %d of %d to (%d, %d): %w", i+1, len(moves), pos.Row, pos.Col, ErrMoveRejected)
+		}
+	}
+	return nil
+}
+
+// IsRejected reports whether the server refused our move to (row, col)
+// during the current turn
+func (c *Client) IsRejected(row, col int) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rejectedMoves[protocol.Position{Row: row, Col: col}]
+}
+
+// RejectedMoves returns the cells the server refused us during the current
+// turn
+func (c *Client) RejectedMoves() []protocol.Position {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	positions := make([]protocol.Position, 0, len(c.rejectedMoves))
+	for pos := range c.rejectedMoves {
+		positions = append(positions, pos)
+	}
+	return positions
+}
+
+// WaitForMoveConfirmation blocks until the server has echoed every move we
+// sent, so the next move is planned on the committed board
+func (c *Client) WaitForMoveConfirmation(ctx context.Context) error {
+	c.mu.Lock()
+	if len(c.pendingMoves) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	confirmed := make(chan struct{})
+	c.confirmWaiters = append(c.confirmWaiters, confirmed)
+	c.mu.Unlock()
+
+	select {
+	case <-confirmed:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for move confirmation: %w", ctx.Err())
+	}
+}
+
+// notifyConfirmed wakes confirmation waiters once nothing is pending.
+// The caller must hold c.mu.
+func (c *Client) notifyConfirmed() {
+	if len(c.pendingMoves) > 0 {
+		return
+	}
+	for _, waiter := range c.confirmWaiters {
+		close(waiter)
+	}
+	c.confirmWaiters = nil
+}
+
+// TurnStarted returns a channel signalled whenever a turn with moves to make
+// becomes ours. Signals coalesce, so the receiver should check IsMyTurn
+// rather than count them; a turn that already ended reports false.
+func (c *Client) TurnStarted() <-chan struct{} {
+	return c.turnStarted
+}
+
+// BeginShutdown stops the client from starting new games: challenges are
+// declined, pending accepts cancelled and nobody is auto-challenged. A game
+// in progress is played out.
+func (c *Client) BeginShutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.shuttingDown = true
+	c.stopDelayedAccept()
+}
+
+// ShuttingDown reports whether BeginShutdown was called
+func (c *Client) ShuttingDown() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.shuttingDown
+}
+
+// InGame reports whether a game has started and not yet ended
+func (c *Client) InGame() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.inGame
 }
 
 // IsMyTurn returns true if it's the bot's turn
 func (c *Client) IsMyTurn() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	if c.gameState == nil {
+	if c.gameState == nil || c.turnPassed {
 		return false
 	}
 	return c.gameState.CurrentPlayer == c.gameState.YourPlayerID
 }
 
+// GetMovesLeft returns how many more moves we may send this turn, as
+// counted by the server's turn_change and move_made messages, or 0 when it
+// is not our turn
+func (c *Client) GetMovesLeft() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.gameState == nil || c.turnPassed || c.gameState.CurrentPlayer != c.gameState.YourPlayerID {
+		return 0
+	}
+	budget := c.turnBudget
+	if budget <= 0 {
+		budget = game.GameConfigFromRules(c.gameState.Rules).TurnLength()
+	}
+	return max(budget-c.movesSent, 0)
+}
+
+// TurnTimeLeft returns how much of VIRUSBOT_TURN_TIME_BUDGET is left in our
+// current turn, never less than zero. It reports false when no budget is
+// configured.
+func (c *Client) TurnTimeLeft() (time.Duration, bool) {
+	budget := c.config.TurnTimeBudget
+	if budget <= 0 {
+		return 0, false
+	}
+	c.mu.RLock()
+	started := c.turnStart
+	c.mu.RUnlock()
+	return max(budget-time.Since(started), 0), true
+}
+
+// PlaceNeutrals turns two of our cells into neutrals, which we may do once
+// per game on our turn. Placing neutrals ends the turn.
+func (c *Client) PlaceNeutrals(positions []protocol.Position) error {
+	if len(positions) < 2 {
+		return fmt.Errorf("need 2 neutral positions, got %d", len(positions))
+	}
+
+	c.mu.RLock()
+	connected, gameID := c.connected, c.gameID
+	usable := c.gameState != nil && !c.turnPassed && c.gameState.CurrentPlayer == c.gameState.YourPlayerID && !c.gameState.NeutralsUsed
+	c.mu.RUnlock()
+
+	if !connected {
+		return fmt.Errorf("not connected")
+	}
+	if !usable {
+		return fmt.Errorf("cannot place neutrals: not our turn or already placed")
+	}
+
+	data, err := json.Marshal(protocol.NewPlaceNeutralsMessage(gameID, positions[:2]))
+	if err != nil {
+		return fmt.Errorf("failed to marshal neutrals: %w", err)
+	}
+
+	c.logger.Debugf("Sending neutrals: %s", string(data))
+
+	if err := c.transport().WriteMessage(data); err != nil {
+		return fmt.Errorf("failed to send neutrals: %w", err)
+	}
+
+	c.mu.Lock()
+	if c.gameState != nil {
+		c.gameState.NeutralsUsed = true
+		for _, pos := range positions[:2] {
+			if _, ok := c.gameState.cellAt(pos.Row, pos.Col); ok {
+				c.gameState.Board[pos.Row][pos.Col] = protocol.CellNeutral
+			}
+		}
+	}
+	c.turnPassed = true
+	c.mu.Unlock()
+
+	return nil
+}
+
+// PassTurn gives up the rest of our current turn when no legal action
+// exists. IsMyTurn reports false until the server hands us a new turn.
+func (c *Client) PassTurn() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.turnPassed = true
+	c.logger.Debugf("Passing the rest of the turn")
+}
+
 // GetUserID returns the user's ID
 func (c *Client) GetUserID() string {
 	return c.userID
@@ -666,7 +2080,12 @@ func (c *Client) IsConnected() bool {
 // Disconnect closes the WebSocket connection
 func (c *Client) Disconnect() {
 	c.cancel()
-	if c.conn != nil {
-		c.conn.Close()
+	if conn := c.transport(); conn != nil {
+		conn.Close()
+	}
+	if c.recorder != nil {
+		if err := c.recorder.Close(); err != nil {
+			c.logger.Errorf("Failed to close recording: %v", err)
+		}
 	}
 }
@@ -1,9 +1,23 @@
 package client
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"virusbot/config"
+	"virusbot/internal/game"
+	"virusbot/internal/logging"
 	"virusbot/internal/protocol"
+
+	"github.com/gorilla/websocket"
 )
 
 func TestGameStateInitialization(t *testing.T) {
@@ -202,3 +216,1654 @@ func TestChallengeMessageParsing(t *testing.T) {
 		t.Errorf("Expected fromUsername to be 'TestPlayer', got %s", msg.FromUserName)
 	}
 }
+
+// newTestServer starts a WebSocket server that forwards every received
+// message to the returned channel, and a client connected to it.
+func newTestServer(t *testing.T, cfg *config.Config, callback Callback) (*Client, <-chan []byte) {
+	t.Helper()
+
+	received := make(chan []byte, 100)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- data
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg.ServerURL = "ws" + strings.TrimPrefix(server.URL, "http")
+	c := NewClient(cfg, callback)
+	c.SetLogger(logging.Nop())
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Failed to connect to test server: %v", err)
+	}
+	t.Cleanup(c.Disconnect)
+
+	return c, received
+}
+
+func TestConnectSendsHandshakeHeaders(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ServerURL: "ws" + strings.TrimPrefix(server.URL, "http"), AuthToken: "s3cr3t"}
+	c := NewClient(cfg, nil)
+	c.SetLogger(logging.Nop())
+	c.SetDialer(&websocket.Dialer{HandshakeTimeout: time.Second})
+	c.SetHeader("X-Bot-Version", "1.2")
+	conn, err := c.dial()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	conn.Close()
+
+	header := <-headers
+	if got := header.Get("Authorization"); got != "Bearer s3cr3t" {
+		t.Errorf("Expected the auth token as a bearer token, got %q", got)
+	}
+	if got := header.Get("X-Bot-Version"); got != "1.2" {
+		t.Errorf("Expected the custom header, got %q", got)
+	}
+}
+
+// expectMessage waits for the next message received by the test server
+func expectMessage(t *testing.T, received <-chan []byte) []byte {
+	t.Helper()
+	select {
+	case data := <-received:
+		return data
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for message")
+		return nil
+	}
+}
+
+func TestJoinRoomSubscribesToGameMessages(t *testing.T) {
+	var events []*RoomEvent
+	callback := func(event string, data interface{}) {
+		if event == "room_event" {
+			events = append(events, data.(*RoomEvent))
+		}
+	}
+	c, received := newTestServer(t, &config.Config{}, callback)
+
+	// Messages for a room we have not joined are ignored
+	if err := c.handleMessage([]byte(`{"type":"game_start","roomId":"room-1","gameId":"g1","yourPlayer":0,"rows":3,"cols":3}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if len(events) != 0 || c.GetRoomState("room-1") != nil {
+		t.Fatal("Expected messages for unjoined room to be ignored")
+	}
+
+	if err := c.JoinRoom("room-1"); err != nil {
+		t.Fatalf("JoinRoom failed: %v", err)
+	}
+	if err := c.JoinRoom("room-2"); err != nil {
+		t.Fatalf("JoinRoom failed: %v", err)
+	}
+	if got := string(expectMessage(t, received)); got != `{"type":"join_room","data":{"roomId":"room-1"}}` {
+		t.Errorf("Unexpected join payload: %s", got)
+	}
+	expectMessage(t, received)
+
+	messages := []string{
+		`{"type":"game_start","roomId":"room-1","gameId":"g1","yourPlayer":0,"rows":3,"cols":3}`,
+		`{"type":"game_start","roomId":"room-2","gameId":"g2","yourPlayer":0,"rows":4,"cols":4}`,
+		`{"type":"move_made","roomId":"room-1","row":1,"col":1,"player":1,"movesLeft":2}`,
+		`{"type":"turn_change","roomId":"room-2","player":2,"movesLeft":3}`,
+	}
+	for _, m := range messages {
+		if err := c.handleMessage([]byte(m)); err != nil {
+			t.Fatalf("handleMessage failed: %v", err)
+		}
+	}
+
+	if len(events) != len(messages) {
+		t.Fatalf("Expected %d room events, got %d", len(messages), len(events))
+	}
+
+	room1 := c.GetRoomState("room-1")
+	if room1 == nil || room1.Board[1][1] != protocol.CellPlayer1 {
+		t.Error("Expected room-1 board to reflect its move")
+	}
+	room2 := c.GetRoomState("room-2")
+	if room2 == nil || len(room2.Board) != 4 || room2.CurrentPlayer != 2 {
+		t.Error("Expected room-2 state to be tracked independently")
+	}
+	if room2 != nil && room2.Board[1][1] != protocol.CellEmpty {
+		t.Error("Room-1 move leaked into room-2")
+	}
+
+	// Events and GetRoomState hand out snapshots, not the tracked state
+	if events[0].State.Board[1][1] != protocol.CellEmpty {
+		t.Error("Expected the game_start event to keep the board it was sent with")
+	}
+	room1.Board[0][0] = protocol.CellPlayer2
+	if c.GetRoomState("room-1").Board[0][0] != protocol.CellEmpty {
+		t.Error("Expected changes to a room snapshot not to reach the client")
+	}
+
+	// Our own game state must be untouched by room traffic
+	if c.GetGameState() != nil {
+		t.Error("Room messages should not modify our own game state")
+	}
+
+	if err := c.LeaveRoom("room-1"); err != nil {
+		t.Fatalf("LeaveRoom failed: %v", err)
+	}
+	if rooms := c.GetRooms(); len(rooms) != 1 || rooms[0] != "room-2" {
+		t.Errorf("Expected only room-2 to remain joined, got %v", rooms)
+	}
+}
+
+func TestMoveMadeEchoCorrectsOptimisticMove(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{}, nil)
+	c.gameState = &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1 | protocol.CellType(protocol.CellFlagBase), protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	if err := c.MakeMove(0, 1); err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	expectMessage(t, received)
+
+	if c.GetGameState().Board[0][1] != protocol.CellPlayer1 {
+		t.Fatal("Expected optimistic write at (0,1)")
+	}
+
+	// The server snapped our move to (1,0)
+	if err := c.handleMessage([]byte(`{"type":"move_made","row":1,"col":0,"player":1,"movesLeft":2}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+
+	board := c.GetGameState().Board
+	if board[0][1] != protocol.CellEmpty {
+		t.Errorf("Expected optimistic cell (0,1) to be reverted, got %v", board[0][1])
+	}
+	if board[1][0] != protocol.CellPlayer1 {
+		t.Errorf("Expected server cell (1,0) to be a normal player 1 cell, got %v", board[1][0])
+	}
+	if len(c.pendingMoves) != 0 {
+		t.Errorf("Expected no pending moves, got %d", len(c.pendingMoves))
+	}
+}
+
+func TestMoveMadeEchoConfirmsOptimisticMove(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{}, nil)
+	c.gameState = &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty},
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	if err := c.MakeMove(0, 1); err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	expectMessage(t, received)
+
+	if err := c.handleMessage([]byte(`{"type":"move_made","row":0,"col":1,"player":1,"movesLeft":2}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+
+	// A grow confirmed by the server must stay a normal (attackable) cell
+	if cell := c.GetGameState().Board[0][1]; cell != protocol.CellPlayer1 {
+		t.Errorf("Expected confirmed grow to be a normal player 1 cell, got %v", cell)
+	}
+}
+
+func TestMoveMadeCapturesSpecialCell(t *testing.T) {
+	c := NewClient(&config.Config{}, nil)
+	c.gameState = &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1, protocol.CellSpecial},
+			{protocol.CellEmpty, protocol.CellSpecial},
+		},
+		CurrentPlayer: 2,
+		YourPlayerID:  1,
+	}
+
+	if err := c.handleMessage([]byte(`{"type":"move_made","row":0,"col":1,"player":2,"movesLeft":2}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+
+	board := c.GetGameState().Board
+	// Growing into a special cell is a placement, not an attack
+	if board[0][1] != protocol.CellPlayer2 {
+		t.Errorf("Expected captured special cell to be a normal player 2 cell, got %v", board[0][1])
+	}
+	if !board[1][1].IsSpecial() {
+		t.Errorf("Expected untouched special marker to be preserved, got %v", board[1][1])
+	}
+}
+
+func TestAutoChallengeTargetsIdleUser(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{AutoChallenge: true}, nil)
+	c.userID = "me"
+
+	update := `{"type":"users_update","users":[` +
+		`{"id":"me","name":"VirusBot","status":"idle"},` +
+		`{"id":"busy","name":"bob","status":"in_game"},` +
+		`{"id":"free","name":"alice","status":"idle"}]}`
+	if err := c.handleMessage([]byte(update)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+
+	if users := c.OnlineUsers(); len(users) != 3 {
+		t.Errorf("Expected 3 online users, got %d", len(users))
+	}
+
+	data := expectMessage(t, received)
+	expected := `{"targetUserId":"free","type":"send_challenge"}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, string(data))
+	}
+
+	// No second challenge while the first is outstanding
+	if err := c.handleMessage([]byte(update)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	select {
+	case data := <-received:
+		t.Errorf("Expected no repeated challenge, got %s", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestChallengeTargetWaitsForNamedUser(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{ChallengeTarget: "alice"}, nil)
+	c.userID = "me"
+
+	// Other idle users are not challenged, nor is the target while busy
+	update := `{"type":"users_update","users":[` +
+		`{"id":"free","name":"bob","status":"idle"},` +
+		`{"id":"a1","name":"alice","status":"in_game"}]}`
+	if err := c.handleMessage([]byte(update)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	select {
+	case data := <-received:
+		t.Errorf("Expected no challenge before the target is idle, got %s", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	update = `{"type":"users_update","users":[` +
+		`{"id":"free","name":"bob","status":"idle"},` +
+		`{"id":"a1","name":"alice","status":"idle"}]}`
+	if err := c.handleMessage([]byte(update)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if expected, data := `{"targetUserId":"a1","type":"send_challenge"}`, expectMessage(t, received); string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+func TestMakeMoveRefusesBeyondTurnBudget(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{MovesPerTurn: 3}, nil)
+	c.gameState = &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+		},
+		CurrentPlayer: 2,
+		YourPlayerID:  1,
+	}
+
+	if err := c.handleMessage([]byte(`{"type":"turn_change","player":1,"movesLeft":3}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+
+	for i, col := range []int{1, 2} {
+		if err := c.MakeMove(0, col); err != nil {
+			t.Fatalf("Move %d was rejected: %v", i+1, err)
+		}
+		expectMessage(t, received)
+	}
+	if err := c.MakeMove(1, 0); err != nil {
+		t.Fatalf("Move 3 was rejected: %v", err)
+	}
+	expectMessage(t, received)
+
+	if err := c.MakeMove(1, 1); err == nil {
+		t.Fatal("Expected 4th move in a 3-move turn to be rejected")
+	}
+	select {
+	case data := <-received:
+		t.Errorf("Rejected move was sent anyway: %s", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A new turn restores the budget
+	if err := c.handleMessage([]byte(`{"type":"turn_change","player":1,"movesLeft":3}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if err := c.MakeMove(1, 1); err != nil {
+		t.Errorf("Expected move to be accepted after a new turn, got %v", err)
+	}
+}
+
+func TestMakeMoveSpacesMovesByDelay(t *testing.T) {
+	for _, delay := range []time.Duration{0, 100 * time.Millisecond} {
+		c, received := newTestServer(t, &config.Config{MoveDelay: delay}, nil)
+		c.gameState = &GameState{
+			Board: [][]protocol.CellType{
+				{protocol.CellPlayer1, protocol.CellEmpty, protocol.CellEmpty},
+				{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+			},
+			CurrentPlayer: 1,
+			YourPlayerID:  1,
+		}
+
+		start := time.Now()
+		if err := c.MakeMove(0, 1); err != nil {
+			t.Fatalf("MakeMove failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+			t.Errorf("Delay %v: expected the first move to be sent at once, took %v", delay, elapsed)
+		}
+		expectMessage(t, received)
+
+		if err := c.MakeMove(0, 2); err != nil {
+			t.Fatalf("MakeMove failed: %v", err)
+		}
+		expectMessage(t, received)
+		elapsed := time.Since(start)
+		if delay == 0 && elapsed > 50*time.Millisecond {
+			t.Errorf("Expected moves without a delay to be sent at once, took %v", elapsed)
+		}
+		if elapsed < delay {
+			t.Errorf("Expected the second move %v after the first, sent after %v", delay, elapsed)
+		}
+	}
+}
+
+func TestGetMovesLeftFollowsServerCounts(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{}, nil)
+	c.gameState = &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellPlayer2},
+		},
+		Players:       []protocol.PlayerInfo{{ID: 1}, {ID: 2}},
+		CurrentPlayer: 2,
+		YourPlayerID:  1,
+		Rules:         protocol.GameRules{MovesPerTurn: 2},
+	}
+
+	if got := c.GetMovesLeft(); got != 0 {
+		t.Errorf("Expected no moves on the opponent's turn, got %d", got)
+	}
+	if err := c.handleMessage([]byte(`{"type":"turn_change","player":1,"movesLeft":3}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if got := c.GetMovesLeft(); got != 3 {
+		t.Errorf("Expected the announced 3 moves, got %d", got)
+	}
+
+	if err := c.MakeMove(0, 1); err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	expectMessage(t, received)
+	if got := c.GetMovesLeft(); got != 2 {
+		t.Errorf("Expected 2 moves after sending one, got %d", got)
+	}
+
+	// The server's echo is authoritative, even when it allows fewer moves
+	if err := c.handleMessage([]byte(`{"type":"move_made","row":0,"col":1,"player":1,"movesLeft":1}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if got := c.GetMovesLeft(); got != 1 {
+		t.Errorf("Expected the server's 1 move left, got %d", got)
+	}
+
+	if err := c.MakeMove(1, 1); err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	expectMessage(t, received)
+	if err := c.handleMessage([]byte(`{"type":"move_made","row":1,"col":1,"player":1,"movesLeft":0}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if got := c.GetMovesLeft(); got != 0 {
+		t.Errorf("Expected no moves once the turn passed, got %d", got)
+	}
+
+	// Without a count the game's moves per turn apply
+	if err := c.handleMessage([]byte(`{"type":"turn_change","player":1}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if got := c.GetMovesLeft(); got != 2 {
+		t.Errorf("Expected the game's 2 moves per turn, got %d", got)
+	}
+}
+
+func TestWaitForGameStart(t *testing.T) {
+	c, _ := newTestServer(t, &config.Config{}, nil)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		c.incoming <- []byte(`{"type":"game_start","gameId":"g1","yourPlayer":2,"rows":4,"cols":4}`)
+	}()
+	go c.Run()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	state, err := c.WaitForGameStart(ctx)
+	if err != nil {
+		t.Fatalf("WaitForGameStart failed: %v", err)
+	}
+	if state.YourPlayerID != 2 || state.Board.Rows != 4 || state.Board.Cols != 4 {
+		t.Errorf("Unexpected initial state: player %d, %dx%d board", state.YourPlayerID, state.Board.Rows, state.Board.Cols)
+	}
+
+	// Once a game is running the call returns immediately
+	if _, err := c.WaitForGameStart(ctx); err != nil {
+		t.Errorf("Expected immediate return during a game, got %v", err)
+	}
+}
+
+func TestWaitForGameStartTimeout(t *testing.T) {
+	c, _ := newTestServer(t, &config.Config{}, nil)
+	go c.Run()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.WaitForGameStart(ctx); err == nil {
+		t.Fatal("Expected an error when no game_start arrives")
+	}
+}
+
+func TestMemoryTransportDrivesGame(t *testing.T) {
+	events := make(chan string, 10)
+	callback := func(event string, data interface{}) {
+		events <- event
+	}
+	clientEnd, server := NewMemoryPipe()
+	c := NewClient(&config.Config{}, callback)
+	c.ConnectTransport(clientEnd)
+	t.Cleanup(c.Disconnect)
+	go c.Run()
+
+	expectEvent := func(want string) {
+		t.Helper()
+		select {
+		case got := <-events:
+			if got != want {
+				t.Fatalf("Expected %q event, got %q", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for %q event", want)
+		}
+	}
+	send := func(msg string) {
+		t.Helper()
+		if err := server.WriteMessage([]byte(msg)); err != nil {
+			t.Fatalf("Server write failed: %v", err)
+		}
+	}
+
+	send(`{"type":"welcome","userId":"u1","username":"bot"}`)
+	expectEvent("connected")
+	send(`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":3,"cols":3}`)
+	expectEvent("game_start")
+
+	server.SetReadDeadline(time.Now().Add(2 * time.Second))
+	data, err := server.ReadMessage()
+	if err != nil {
+		t.Fatalf("Server read failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"type":"request_state"`) {
+		t.Errorf("Expected a state request for the bases, got %s", data)
+	}
+
+	if err := c.MakeMove(0, 1); err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	data, err = server.ReadMessage()
+	if err != nil {
+		t.Fatalf("Server read failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"type":"move"`) {
+		t.Errorf("Expected a move message, got %s", data)
+	}
+
+	send(`{"type":"move_made","row":0,"col":1,"player":1,"movesLeft":2}`)
+	expectEvent("move_made")
+	if cell := c.GetGameState().Board[0][1]; cell != protocol.CellPlayer1 {
+		t.Errorf("Expected (0,1) to be a player 1 cell, got %v", cell)
+	}
+
+	send(`{"type":"game_end","winner":1}`)
+	expectEvent("game_end")
+
+	// Closing the server end is seen as a disconnect
+	server.Close()
+	expectEvent("disconnected")
+	if c.IsConnected() {
+		t.Error("Expected client to be disconnected after the transport closed")
+	}
+}
+
+func TestMemoryTransportReadDeadline(t *testing.T) {
+	a, _ := NewMemoryPipe()
+	a.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err := a.ReadMessage(); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Expected deadline error, got %v", err)
+	}
+}
+
+func TestGameStartWithoutDimensionsUsesDefaultBoard(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{DefaultBoardSize: 8}, nil)
+
+	if err := c.handleMessage([]byte(`{"type":"game_start","gameId":"g1","yourPlayer":2,"rows":0,"cols":0}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+
+	state := c.GetGameState()
+	if state == nil || len(state.Board) != 8 || len(state.Board[0]) != 8 {
+		t.Fatalf("Expected a default 8x8 board, got %+v", state)
+	}
+	if state.YourPlayerID != 2 {
+		t.Errorf("Expected to be player 2, got %d", state.YourPlayerID)
+	}
+	if g := state.ToGame(); g.Board == nil || g.Board.Rows != 8 {
+		t.Error("Expected the default board to convert into a usable game state")
+	}
+
+	if got := string(expectMessage(t, received)); got != `{"gameId":"g1","type":"request_state"}` {
+		t.Errorf("Expected a state sync request, got %s", got)
+	}
+}
+
+func TestGameStartWithUnparsableGameIDDoesNotPanic(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{DefaultBoardSize: 8}, nil)
+
+	// gameId as a number fails the new format; the old one has no board
+	if err := c.handleMessage([]byte(`{"type":"game_start","gameId":123,"yourPlayerId":1}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+
+	state := c.GetGameState()
+	if state == nil || len(state.Board) != 8 || state.YourPlayerID != 1 {
+		t.Fatalf("Expected a default 8x8 board as player 1, got %+v", state)
+	}
+	expectMessage(t, received)
+}
+
+func TestV2GameStartWaitsForRealBases(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{}, nil)
+
+	if err := c.handleMessage([]byte(`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":5,"cols":5}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if got := string(expectMessage(t, received)); got != `{"gameId":"g1","type":"request_state"}` {
+		t.Errorf("Expected a state sync request, got %s", got)
+	}
+	state := c.GetGameState()
+	if !state.BasesPending || state.Board[0][0] != protocol.CellEmpty {
+		t.Fatalf("Expected no guessed bases before the board arrives, got %v", state.Board)
+	}
+
+	// The board puts the bases in the middle rows, not the corners
+	board := `{"type":"game_start","yourPlayerId":1,"currentPlayer":1,` +
+		`"players":[{"id":1,"position":{"row":2,"col":1}},{"id":2,"position":{"row":2,"col":3}}],` +
+		`"board":[[0,0,0,0,0],[0,0,0,0,0],[0,17,0,18,0],[0,1,0,0,0],[0,0,0,0,0]]}`
+	if err := c.handleMessage([]byte(board)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	state = c.GetGameState()
+	if state.BasesPending {
+		t.Fatal("Expected the board to reveal the bases")
+	}
+
+	g := state.ToGame()
+	if base := g.Board.BasePos[1]; base != (game.Position{Row: 2, Col: 1}) {
+		t.Errorf("Expected our base at (2,1), got %v", base)
+	}
+	if reachable := g.Board.GetReachableCells(1); len(reachable) != 2 {
+		t.Errorf("Expected the base and its neighbor to be connected, got %v", reachable)
+	}
+	for _, m := range g.Board.GetValidMoves(1) {
+		if m.Position.Row == 0 || m.Position.Col == 4 {
+			t.Errorf("Move %v is not adjacent to our territory", m.Position)
+		}
+	}
+}
+
+func TestGameStateSnapshotFillsV2Board(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{}, nil)
+
+	if err := c.handleMessage([]byte(`{"type":"game_start","gameId":"g1","yourPlayer":2,"rows":4,"cols":4}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	expectMessage(t, received)
+
+	// Only the board's base flags tell where the bases are
+	snapshot := `{"type":"game_state","gameId":"g1","currentPlayer":1,` +
+		`"board":[[17,1,0,0],[0,0,0,0],[0,0,2,0],[0,0,0,18]]}`
+	if err := c.handleMessage([]byte(snapshot)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	state := c.GetGameState()
+	if state.BasesPending || state.CurrentPlayer != 1 {
+		t.Fatalf("Expected the snapshot to reveal the bases and the turn, got %+v", state)
+	}
+	g := state.ToGame()
+	if base := g.Board.BasePos[2]; base != (game.Position{Row: 3, Col: 3}) {
+		t.Errorf("Expected our base at (3,3), got %v", base)
+	}
+	if reachable := g.Board.GetReachableCells(2); len(reachable) != 2 {
+		t.Errorf("Expected our base and its neighbor to be connected, got %v", reachable)
+	}
+
+	// A move onto a player who already has a base is not taken for one
+	if err := c.handleMessage([]byte(`{"type":"move_made","row":1,"col":1,"player":1,"movesLeft":2}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if base := c.GetGameState().ToGame().Board.BasePos[1]; base != (game.Position{Row: 0, Col: 0}) {
+		t.Errorf("Expected player 1's base to stay at (0,0), got %v", base)
+	}
+
+	// Snapshots of other games are ignored
+	other := `{"type":"game_state","gameId":"g2","board":[[0,0,0,0],[0,0,0,0],[0,0,0,0],[0,0,0,0]]}`
+	if err := c.handleMessage([]byte(other)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if c.GetGameState().Board[3][3] == protocol.CellEmpty {
+		t.Error("Expected a snapshot of another game to be ignored")
+	}
+}
+
+func TestGameStateSnapshotChecksDimensions(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{DefaultBoardSize: 5}, nil)
+
+	if err := c.handleMessage([]byte(`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":3,"cols":3}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	expectMessage(t, received)
+
+	for _, snapshot := range []string{
+		`{"type":"game_state","gameId":"g1","board":[[17,0,0,0],[0,0,0,0],[0,0,0,18]]}`,
+		`{"type":"game_state","gameId":"g1","board":[[17,0,0],[0,0],[0,0,18]]}`,
+	} {
+		if err := c.handleMessage([]byte(snapshot)); err != nil {
+			t.Fatalf("handleMessage failed: %v", err)
+		}
+		if state := c.GetGameState(); state.Synced || len(state.Board[0]) != 3 || state.Board[0][0] != protocol.CellEmpty {
+			t.Errorf("Expected %s to be ignored, got %v", snapshot, state.Board)
+		}
+	}
+
+	// A game_start without dimensions only assumed the board's size
+	guessed, received := newTestServer(t, &config.Config{DefaultBoardSize: 5}, nil)
+	if err := guessed.handleMessage([]byte(`{"type":"game_start","gameId":"g1","yourPlayer":1}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	expectMessage(t, received)
+	if err := guessed.handleMessage([]byte(`{"type":"game_state","gameId":"g1","board":[[17,0,0],[0,0,0],[0,0,18]]}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if state := guessed.GetGameState(); !state.Synced || len(state.Board) != 3 || state.SizeGuessed {
+		t.Errorf("Expected the snapshot to replace the assumed 5x5 board, got %v", state.Board)
+	}
+}
+
+func TestMoveMadeAfterSnapshotResyncsInsteadOfGuessing(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{}, nil)
+
+	if err := c.handleMessage([]byte(`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":3,"cols":3}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	expectMessage(t, received)
+	snapshot := `{"type":"game_state","gameId":"g1","currentPlayer":2,"board":[[17,1,0],[5,2,0],[0,0,18]]}`
+	if err := c.handleMessage([]byte(snapshot)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+
+	// Attacking a normal enemy cell still fortifies it
+	if err := c.handleMessage([]byte(`{"type":"move_made","row":0,"col":1,"player":2,"movesLeft":2}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if cell := c.GetGameState().Board[0][1]; cell != protocol.CellType(2|int(protocol.CellFlagFortified)) {
+		t.Errorf("Expected the attacked cell to be fortified, got %v", cell)
+	}
+
+	// No move may take a neutral cell, so the board is fetched again
+	if err := c.handleMessage([]byte(`{"type":"move_made","row":1,"col":0,"player":2,"movesLeft":1}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if cell := c.GetGameState().Board[1][0]; cell != protocol.CellNeutral {
+		t.Errorf("Expected the neutral cell to be left to the snapshot, got %v", cell)
+	}
+	if msg := expectMessage(t, received); !strings.Contains(string(msg), string(protocol.MsgRequestState)) {
+		t.Errorf("Expected a state request, got %s", msg)
+	}
+}
+
+func TestGameStartAdjacencyDrivesMoveGeneration(t *testing.T) {
+	c, _ := newTestServer(t, &config.Config{}, nil)
+
+	for _, msg := range []string{
+		`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":5,"cols":5,"adjacency":4,"movesPerTurn":2}`,
+		`{"type":"players_update","players":[{"id":1,"position":{"row":0,"col":0}},{"id":2,"position":{"row":4,"col":4}}]}`,
+	} {
+		if err := c.handleMessage([]byte(msg)); err != nil {
+			t.Fatalf("handleMessage failed: %v", err)
+		}
+	}
+
+	state := c.GetGameState().ToGame()
+	if got := state.Config(); got.Adjacency != game.Adjacency4 || got.TurnLength() != 2 {
+		t.Fatalf("Expected 4-directional rules with 2 moves per turn, got %+v", got)
+	}
+	if n := len(state.Board.GetNeighbors(game.Position{Row: 2, Col: 2})); n != 4 {
+		t.Errorf("Expected 4 neighbors of an interior cell, got %d", n)
+	}
+
+	// From the corner base only the two orthogonal cells are reachable
+	moves := state.Board.GetValidMoves(1)
+	if len(moves) != 2 {
+		t.Fatalf("Expected 2 moves from the base, got %v", moves)
+	}
+	for _, m := range moves {
+		if m.Position == (game.Position{Row: 1, Col: 1}) {
+			t.Errorf("Diagonal move %v generated under 4-directional adjacency", m.Position)
+		}
+	}
+}
+
+func TestConfiguredAdjacencyAppliesWhenGameStartIsSilent(t *testing.T) {
+	tests := []struct {
+		name      string
+		gameStart string
+		want      int
+	}{
+		{"unannounced", `{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":5,"cols":5}`, 4},
+		{"announced by the server", `{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":5,"cols":5,"adjacency":8}`, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestServer(t, &config.Config{Adjacency: 4}, nil)
+			if err := c.handleMessage([]byte(tt.gameStart)); err != nil {
+				t.Fatalf("handleMessage failed: %v", err)
+			}
+			board := c.GetGameState().ToGame().Board
+			if n := len(board.GetNeighbors(game.Position{Row: 2, Col: 2})); n != tt.want {
+				t.Errorf("Expected %d neighbors of an interior cell, got %d", tt.want, n)
+			}
+		})
+	}
+}
+
+func TestPlayersUpdateMarksEliminatedPlayer(t *testing.T) {
+	c := NewClient(&config.Config{}, nil)
+	c.gameState = &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1 | protocol.CellType(protocol.CellFlagBase), protocol.CellEmpty, protocol.CellPlayer3 | protocol.CellType(protocol.CellFlagBase)},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellPlayer2 | protocol.CellType(protocol.CellFlagBase)},
+		},
+		Players: []protocol.PlayerInfo{
+			{ID: 1, Position: protocol.Position{Row: 0, Col: 0}},
+			{ID: 2, Position: protocol.Position{Row: 2, Col: 2}},
+			{ID: 3, Position: protocol.Position{Row: 0, Col: 2}},
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	update := `{"type":"players_update","players":[` +
+		`{"id":1,"position":{"row":0,"col":0}},` +
+		`{"id":2,"position":{"row":2,"col":2},"eliminated":true},` +
+		`{"id":3,"position":{"row":0,"col":2}}]}`
+	if err := c.handleMessage([]byte(update)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+
+	state := c.GetGameState().ToGame()
+	for _, p := range state.GetAlivePlayers() {
+		if p.ID == 2 {
+			t.Error("Expected eliminated player 2 to be removed from the alive set")
+		}
+	}
+	if opponents := state.GetOpponents(); len(opponents) != 1 || opponents[0].ID != 3 {
+		t.Errorf("Expected player 3 as the only opponent, got %v", opponents)
+	}
+
+	// Turn order skips the eliminated player
+	state.AdvancePlayer()
+	if state.CurrentPlayer != 3 {
+		t.Errorf("Expected turn to pass from 1 to 3, got %d", state.CurrentPlayer)
+	}
+}
+
+func TestToGameMarksPlayersWithoutCellsDead(t *testing.T) {
+	cs := &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1 | protocol.CellType(protocol.CellFlagBase), protocol.CellPlayer1, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellPlayer1, protocol.CellPlayer2 | protocol.CellType(protocol.CellFlagBase)},
+		},
+		Players: []protocol.PlayerInfo{
+			{ID: 1, Position: protocol.Position{Row: 0, Col: 0}},
+			{ID: 2, Position: protocol.Position{Row: 2, Col: 2}},
+			{ID: 3, Position: protocol.Position{Row: 0, Col: 2}},
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	state := cs.ToGame()
+	if p := state.GetPlayer(3); p.IsAlive {
+		t.Error("Expected player 3, who owns no cells, to be dead")
+	}
+	if opponents := state.GetOpponents(); len(opponents) != 1 || opponents[0].ID != 2 {
+		t.Errorf("Expected player 2 as the only opponent, got %v", opponents)
+	}
+	if n := state.GetPlayer(1).CellCount(); n != 3 {
+		t.Errorf("Expected player 1 to own 3 cells, got %d", n)
+	}
+
+	// Losing the last cell now eliminates the player
+	next := state.ApplyMove(game.Move{Position: game.Position{Row: 2, Col: 2}, Type: game.MoveAttack})
+	if next.GetPlayer(2).IsAlive {
+		t.Error("Expected player 2 to die with their last cell")
+	}
+}
+
+func TestZeroBasedServerPlayersAreNormalized(t *testing.T) {
+	c := NewClient(&config.Config{}, nil)
+
+	messages := []string{
+		`{"type":"game_start","gameId":"g1","yourPlayer":0,"rows":3,"cols":3}`,
+		`{"type":"move_made","row":0,"col":1,"player":0,"movesLeft":2}`,
+		`{"type":"turn_change","player":1,"movesLeft":3}`,
+		`{"type":"move_made","row":2,"col":1,"player":1,"movesLeft":2}`,
+	}
+	for _, m := range messages {
+		if err := c.handleMessage([]byte(m)); err != nil {
+			t.Fatalf("handleMessage failed: %v", err)
+		}
+	}
+
+	state := c.GetGameState()
+	if state.YourPlayerID != 1 {
+		t.Errorf("Expected server player 0 to be internal player 1, got %d", state.YourPlayerID)
+	}
+	if cell := state.Board[0][1]; cell != protocol.CellPlayer1 {
+		t.Errorf("Expected our move to be labeled player 1, got %v", cell)
+	}
+	if cell := state.Board[2][1]; cell != protocol.CellPlayer2 {
+		t.Errorf("Expected the opponent's move to be labeled player 2, got %v", cell)
+	}
+	if state.CurrentPlayer != 2 {
+		t.Errorf("Expected server player 1's turn to be internal player 2, got %d", state.CurrentPlayer)
+	}
+}
+
+func TestAverageMoveLatencyReflectsEchoDelay(t *testing.T) {
+	clientEnd, server := NewMemoryPipe()
+	c := NewClient(&config.Config{AdaptiveMoveDelay: true}, nil)
+	c.ConnectTransport(clientEnd)
+	t.Cleanup(c.Disconnect)
+	go c.Run()
+
+	if err := server.WriteMessage([]byte(`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":3,"cols":3}`)); err != nil {
+		t.Fatalf("Server write failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := c.WaitForGameStart(ctx); err != nil {
+		t.Fatalf("WaitForGameStart failed: %v", err)
+	}
+	if got := c.AverageMoveLatency(); got != 0 {
+		t.Errorf("Expected no latency before any move, got %v", got)
+	}
+
+	const echoDelay = 50 * time.Millisecond
+	for i, col := range []int{1, 2} {
+		if err := c.MakeMove(0, col); err != nil {
+			t.Fatalf("MakeMove failed: %v", err)
+		}
+		server.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if _, err := server.ReadMessage(); err != nil {
+			t.Fatalf("Server read failed: %v", err)
+		}
+		time.Sleep(echoDelay)
+		echo := fmt.Sprintf(`{"type":"move_made","row":0,"col":%d,"player":1,"movesLeft":%d}`, col, 2-i)
+		if err := server.WriteMessage([]byte(echo)); err != nil {
+			t.Fatalf("Server write failed: %v", err)
+		}
+		if err := c.WaitForMoveConfirmation(ctx); err != nil {
+			t.Fatalf("WaitForMoveConfirmation failed: %v", err)
+		}
+	}
+
+	latency := c.AverageMoveLatency()
+	if latency < echoDelay || latency > echoDelay+500*time.Millisecond {
+		t.Errorf("Expected average latency near %v, got %v", echoDelay, latency)
+	}
+
+	// Adaptive pacing raised the move delay to the measured latency
+	c.mu.RLock()
+	moveDelay := c.moveDelay
+	c.mu.RUnlock()
+	if moveDelay < echoDelay {
+		t.Errorf("Expected adaptive pacing to raise the move delay, got %v", moveDelay)
+	}
+	if status := c.Status(); status.MoveLatency == "" {
+		t.Error("Expected the status to report the move latency")
+	}
+
+	// Once the server speeds up, the delay comes back down
+	c.mu.Lock()
+	for i := 0; i < latencyWindow; i++ {
+		c.recordLatency(time.Millisecond)
+	}
+	moveDelay = c.moveDelay
+	c.mu.Unlock()
+	if moveDelay != time.Millisecond {
+		t.Errorf("Expected the move delay to follow the latency down to 1ms, got %v", moveDelay)
+	}
+}
+
+func TestAcceptDelayWaitsAndHonoursCancellation(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	c, received := newTestServer(t, &config.Config{AutoAcceptChallenge: true, AcceptDelay: delay}, nil)
+
+	start := time.Now()
+	if err := c.handleMessage([]byte(`{"type":"challenge_received","challengeId":"c1","fromUserId":"u2"}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	data := expectMessage(t, received)
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("Expected the accept after at least %v, got it after %v", delay, elapsed)
+	}
+	if expected := `{"challengeId":"c1","type":"accept_challenge"}`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	// A challenge withdrawn during the delay is never accepted
+	if err := c.handleMessage([]byte(`{"type":"challenge_received","challengeId":"c2","fromUserId":"u3"}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if err := c.handleMessage([]byte(`{"type":"challenge_cancelled","challengeId":"c2"}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	select {
+	case data := <-received:
+		t.Errorf("Expected no accept for a cancelled challenge, got %s", data)
+	case <-time.After(2 * delay):
+	}
+}
+
+func TestAcceptedChallengeTimesOutWithoutGameStart(t *testing.T) {
+	timedOut := make(chan interface{}, 1)
+	callback := func(event string, data interface{}) {
+		if event == "challenge_timeout" {
+			timedOut <- data
+		}
+	}
+	c, received := newTestServer(t, &config.Config{AutoAcceptChallenge: true, ChallengeTimeout: 50 * time.Millisecond}, callback)
+
+	if err := c.handleMessage([]byte(`{"type":"challenge_received","challengeId":"c1","fromUserId":"u2"}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	expectMessage(t, received)
+
+	select {
+	case data := <-timedOut:
+		if data != "c1" {
+			t.Errorf("Expected a timeout for c1, got %v", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for challenge_timeout")
+	}
+
+	c.mu.RLock()
+	current := c.currentChallenge
+	c.mu.RUnlock()
+	if current != "" {
+		t.Errorf("Expected the timed out challenge to be cleared, got %q", current)
+	}
+}
+
+func TestMakeMovesStopsAfterRejectedMove(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{MovesPerTurn: 3, MoveConfirmTimeout: time.Second}, nil)
+	c.gameState = &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+	c.resetTurnBudget(3)
+
+	// Echo the first move as sent and move the second one elsewhere
+	sent := make(chan string, 3)
+	go func() {
+		for i := 0; i < 2; i++ {
+			var move struct{ Row, Col int }
+			data := <-received
+			if err := json.Unmarshal(data, &move); err != nil {
+				return
+			}
+			sent <- fmt.Sprintf("%d,%d", move.Row, move.Col)
+			if i == 1 {
+				move.Row, move.Col = 2, 2
+			}
+			c.handleMessage([]byte(fmt.Sprintf(`{"type":"move_made","row":%d,"col":%d,"player":1,"movesLeft":%d}`, move.Row, move.Col, 2-i)))
+		}
+	}()
+
+	err := c.MakeMoves([]game.Position{{Row: 0, Col: 1}, {Row: 1, Col: 1}, {Row: 1, Col: 0}})
+	if !errors.Is(err, ErrMoveRejected) {
+		t.Fatalf("Expected the batch to stop on a rejected move, got %v", err)
+	}
+
+	for _, want := range []string{"0,1", "1,1"} {
+		if got := <-sent; got != want {
+			t.Errorf("Expected move %s to be sent in order, got %s", want, got)
+		}
+	}
+	select {
+	case data := <-received:
+		t.Errorf("Expected the rest of the batch to be dropped, got %s", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRapidTurnChangesIncludingSkippedTurn(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{MovesPerTurn: 3}, nil)
+	c.gameState = &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellPlayer2},
+		},
+		CurrentPlayer: 2,
+		YourPlayerID:  1,
+	}
+
+	// Skipped over in quick succession: our zero-budget turn must not count
+	for _, msg := range []string{
+		`{"type":"turn_change","player":3,"movesLeft":3}`,
+		`{"type":"turn_change","player":1,"movesLeft":0}`,
+	} {
+		if err := c.handleMessage([]byte(msg)); err != nil {
+			t.Fatalf("handleMessage failed: %v", err)
+		}
+	}
+	if c.IsMyTurn() {
+		t.Error("Expected a skipped turn not to be ours to play")
+	}
+	select {
+	case <-c.TurnStarted():
+		t.Error("Expected no turn signal for a skipped turn")
+	default:
+	}
+	if err := c.MakeMoves([]game.Position{{Row: 0, Col: 1}}); err == nil {
+		t.Error("Expected moves during a skipped turn to be refused")
+	}
+
+	// A fleeting turn is still signalled even after it has passed on
+	for _, msg := range []string{
+		`{"type":"turn_change","player":1,"movesLeft":3}`,
+		`{"type":"turn_change","player":2,"movesLeft":3}`,
+		`{"type":"turn_change","player":1,"movesLeft":3}`,
+	} {
+		if err := c.handleMessage([]byte(msg)); err != nil {
+			t.Fatalf("handleMessage failed: %v", err)
+		}
+	}
+	select {
+	case <-c.TurnStarted():
+	default:
+		t.Fatal("Expected our turn to be signalled")
+	}
+	if !c.IsMyTurn() {
+		t.Fatal("Expected the latest turn to be ours")
+	}
+	if err := c.MakeMove(0, 1); err != nil {
+		t.Errorf("Expected a move on our turn to be accepted, got %v", err)
+	}
+	expectMessage(t, received)
+}
+
+func TestReconnectResumesGameAfterDroppedConnection(t *testing.T) {
+	received := make(chan []byte, 100)
+	conns := make(chan *websocket.Conn, 2)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conns <- conn
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- data
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	events := make(chan string, 10)
+	var attempts []int
+	callback := func(event string, data interface{}) {
+		if event == "reconnecting" {
+			attempts = append(attempts, data.(int))
+		}
+		events <- event
+	}
+	cfg := &config.Config{ServerURL: "ws" + strings.TrimPrefix(server.URL, "http"), ReconnectMax: 3}
+	c := NewClient(cfg, callback)
+	c.reconnectBackoff = 10 * time.Millisecond
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Failed to connect to test server: %v", err)
+	}
+	t.Cleanup(c.Disconnect)
+	go c.Run()
+
+	expectEvent := func(want string) {
+		t.Helper()
+		for {
+			select {
+			case got := <-events:
+				if got == want {
+					return
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("Timed out waiting for %q event", want)
+			}
+		}
+	}
+
+	first := <-conns
+	first.WriteMessage(websocket.TextMessage, []byte(`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":3,"cols":3}`))
+	expectEvent("game_start")
+
+	first.Close()
+	expectEvent("disconnected")
+	expectEvent("reconnecting")
+
+	select {
+	case second := <-conns:
+		defer second.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the client to reconnect")
+	}
+	data := expectMessage(t, received)
+	if !strings.Contains(string(data), `"type":"request_state"`) || !strings.Contains(string(data), `"g1"`) {
+		t.Errorf("Expected the game to be resumed with a state request, got %s", data)
+	}
+	if len(attempts) != 1 || attempts[0] != 1 {
+		t.Errorf("Expected one reconnect attempt, got %v", attempts)
+	}
+	if !c.IsConnected() {
+		t.Error("Expected client to be connected after reconnecting")
+	}
+}
+
+func TestReconnectRejoinsLobbyInsteadOfAutoCreate(t *testing.T) {
+	received := make(chan []byte, 100)
+	conns := make(chan *websocket.Conn, 2)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conns <- conn
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- data
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{ServerURL: "ws" + strings.TrimPrefix(server.URL, "http"), ReconnectMax: 3, AutoCreate: true}
+	c := NewClient(cfg, nil)
+	c.reconnectBackoff = 10 * time.Millisecond
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Failed to connect to test server: %v", err)
+	}
+	t.Cleanup(c.Disconnect)
+	go c.Run()
+
+	first := <-conns
+	first.WriteMessage(websocket.TextMessage, []byte(`{"type":"welcome","userId":"u1","username":"bot"}`))
+	if data := expectMessage(t, received); !strings.Contains(string(data), `"type":"create_lobby"`) {
+		t.Fatalf("Expected the first welcome to create a lobby, got %s", data)
+	}
+	first.WriteMessage(websocket.TextMessage, []byte(`{"type":"lobby_update","lobbyId":"l1","hostId":1,"boardSize":10}`))
+	deadline := time.Now().Add(2 * time.Second)
+	for c.LobbyID() != "l1" {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the lobby_update")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	first.Close()
+
+	var second *websocket.Conn
+	select {
+	case second = <-conns:
+		defer second.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the client to reconnect")
+	}
+	second.WriteMessage(websocket.TextMessage, []byte(`{"type":"welcome","userId":"u1","username":"bot"}`))
+	if data := expectMessage(t, received); !strings.Contains(string(data), `"type":"join_lobby"`) || !strings.Contains(string(data), `"l1"`) {
+		t.Errorf("Expected the lobby to be rejoined, got %s", data)
+	}
+	select {
+	case data := <-received:
+		t.Errorf("Expected nothing more after the rejoin, got %s", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestKeepAliveDropsConnectionWithoutPongs(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		answering bool
+	}{
+		{"answering server", true},
+		{"silent server", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			upgrader := websocket.Upgrader{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				if !tc.answering {
+					// Pongs are only sent while reading, so a server that
+					// stops reading looks like a dead connection
+					<-r.Context().Done()
+					return
+				}
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						return
+					}
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			disconnected := make(chan struct{}, 1)
+			callback := func(event string, data interface{}) {
+				if event == "disconnected" {
+					disconnected <- struct{}{}
+				}
+			}
+			cfg := &config.Config{ServerURL: "ws" + strings.TrimPrefix(server.URL, "http"), PingInterval: 20 * time.Millisecond}
+			c := NewClient(cfg, callback)
+			if err := c.Connect(); err != nil {
+				t.Fatalf("Failed to connect to test server: %v", err)
+			}
+			t.Cleanup(c.Disconnect)
+			go c.Run()
+
+			select {
+			case <-disconnected:
+				if tc.answering {
+					t.Error("Expected a server answering pings to stay connected")
+				}
+			case <-time.After(200 * time.Millisecond):
+				if !tc.answering {
+					t.Error("Expected a server not answering pings to be disconnected")
+				}
+			}
+		})
+	}
+}
+
+func TestThreePlayerTurnRotationSkipsEliminated(t *testing.T) {
+	c := NewClient(&config.Config{}, nil)
+	c.gameState = &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1 | protocol.CellType(protocol.CellFlagBase), protocol.CellEmpty, protocol.CellPlayer3 | protocol.CellType(protocol.CellFlagBase)},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellPlayer2 | protocol.CellType(protocol.CellFlagBase)},
+		},
+		Players:       []protocol.PlayerInfo{{ID: 1}, {ID: 2}, {ID: 3}},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	expectTurn := func(msg string, want int) {
+		t.Helper()
+		if err := c.handleMessage([]byte(msg)); err != nil {
+			t.Fatalf("handleMessage failed: %v", err)
+		}
+		if got := c.GetGameState().CurrentPlayer; got != want {
+			t.Fatalf("After %s expected player %d to move, got %d", msg, want, got)
+		}
+	}
+
+	expectTurn(`{"type":"move_made","row":0,"col":1,"player":1,"movesLeft":0}`, 2)
+	expectTurn(`{"type":"move_made","row":1,"col":2,"player":2,"movesLeft":0}`, 3)
+	expectTurn(`{"type":"move_made","row":1,"col":1,"player":3,"movesLeft":0}`, 1)
+
+	update := `{"type":"players_update","players":[{"id":1},{"id":2,"eliminated":true},{"id":3}]}`
+	if err := c.handleMessage([]byte(update)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	expectTurn(`{"type":"move_made","row":1,"col":0,"player":1,"movesLeft":0}`, 3)
+	expectTurn(`{"type":"move_made","row":2,"col":1,"player":3,"movesLeft":0}`, 1)
+}
+
+func TestGuessedPlayersWaitForTurnChange(t *testing.T) {
+	c := NewClient(&config.Config{}, nil)
+
+	messages := []string{
+		`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":3,"cols":3}`,
+		`{"type":"move_made","row":0,"col":1,"player":1,"movesLeft":0}`,
+	}
+	for _, m := range messages {
+		if err := c.handleMessage([]byte(m)); err != nil {
+			t.Fatalf("handleMessage failed: %v", err)
+		}
+	}
+	if got := c.GetGameState().CurrentPlayer; got != 1 {
+		t.Errorf("Expected the turn to stay with player 1 until turn_change, got %d", got)
+	}
+
+	if err := c.handleMessage([]byte(`{"type":"turn_change","player":3,"movesLeft":3}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if got := c.GetGameState().CurrentPlayer; got != 3 {
+		t.Errorf("Expected turn_change to hand the turn to player 3, got %d", got)
+	}
+}
+
+func TestDeclineChallenge(t *testing.T) {
+	var declined []interface{}
+	callback := func(event string, data interface{}) {
+		if event == "challenge_declined" {
+			declined = append(declined, data)
+		}
+	}
+	cfg := &config.Config{AutoAcceptChallenge: true, DeclineUsers: []string{"Pest"}}
+	c, received := newTestServer(t, cfg, callback)
+
+	// A user on the decline list is declined even with auto-accept on
+	if err := c.handleMessage([]byte(`{"type":"challenge_received","challengeId":"c1","fromUserId":"u2","fromUsername":"Pest"}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if expected, data := `{"challengeId":"c1","type":"decline_challenge"}`, expectMessage(t, received); string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	// With auto-accept off, auto-decline answers everyone else too
+	cfg.AutoAcceptChallenge = false
+	cfg.AutoDecline = true
+	if err := c.handleMessage([]byte(`{"type":"challenge_received","challengeId":"c2","fromUserId":"u3","fromUsername":"Friend"}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if expected, data := `{"challengeId":"c2","type":"decline_challenge"}`, expectMessage(t, received); string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	if len(declined) != 2 || declined[0] != "c1" || declined[1] != "c2" {
+		t.Errorf("Expected challenge_declined events for c1 and c2, got %v", declined)
+	}
+	if c.currentChallenge != "" {
+		t.Errorf("Expected no pending challenge after declining, got %q", c.currentChallenge)
+	}
+}
+
+func TestLobbyMethodsUseCurrentLobby(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{}, nil)
+
+	if err := c.AddBot(); err == nil {
+		t.Error("Expected lobby methods to fail outside a lobby")
+	}
+
+	if err := c.handleMessage([]byte(`{"type":"lobby_update","lobbyId":"l1","hostId":1,"boardSize":10}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if got := c.LobbyID(); got != "l1" {
+		t.Fatalf("Expected lobby l1 from lobby_update, got %q", got)
+	}
+	if got := c.defaultBoardSize(); got != 10 {
+		t.Errorf("Expected the lobby's board size 10, got %d", got)
+	}
+
+	for _, tc := range []struct {
+		send     func() error
+		expected string
+	}{
+		{c.AddBot, `{"type":"add_bot","data":{"lobbyId":"l1"}}`},
+		{func() error { return c.RemoveBot("b7") }, `{"type":"remove_bot","data":{"lobbyId":"l1","botId":"b7"}}`},
+		{c.StartMultiplayerGame, `{"type":"start_multiplayer_game","data":{"lobbyId":"l1"}}`},
+		{c.LeaveLobby, `{"type":"leave_lobby","data":{"lobbyId":"l1"}}`},
+	} {
+		if err := tc.send(); err != nil {
+			t.Fatalf("Sending %s failed: %v", tc.expected, err)
+		}
+		if data := expectMessage(t, received); string(data) != tc.expected {
+			t.Errorf("Expected %s, got %s", tc.expected, data)
+		}
+	}
+
+	if got := c.LobbyID(); got != "" {
+		t.Errorf("Expected no lobby after leaving, got %q", got)
+	}
+}
+
+func TestPlaceNeutralsOncePerGame(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{}, nil)
+	c.gameID = "g1"
+	c.gameState = &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1 | protocol.CellType(protocol.CellFlagBase), protocol.CellPlayer1, protocol.CellPlayer1},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellEmpty, protocol.CellPlayer2 | protocol.CellType(protocol.CellFlagBase)},
+		},
+		Players:       []protocol.PlayerInfo{{ID: 1}, {ID: 2}},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	if err := c.PlaceNeutrals([]protocol.Position{{Row: 0, Col: 1}}); err == nil {
+		t.Error("Expected a single neutral position to be refused")
+	}
+
+	if err := c.PlaceNeutrals([]protocol.Position{{Row: 0, Col: 1}, {Row: 0, Col: 2}}); err != nil {
+		t.Fatalf("PlaceNeutrals failed: %v", err)
+	}
+	expected := `{"type":"place_neutrals","gameId":"g1","positions":[{"row":0,"col":1},{"row":0,"col":2}]}`
+	if data := expectMessage(t, received); string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	state := c.GetGameState()
+	if state.Board[0][1] != protocol.CellNeutral || state.Board[0][2] != protocol.CellNeutral {
+		t.Errorf("Expected the placed cells to be neutral, got %v", state.Board[0])
+	}
+	if c.IsMyTurn() {
+		t.Error("Expected placing neutrals to end our turn")
+	}
+	if !state.ToGame().GetYourPlayer().HasUsedNeutrals {
+		t.Error("Expected the game state to record that our neutrals are used")
+	}
+
+	// Not again, even on a later turn
+	if err := c.handleMessage([]byte(`{"type":"turn_change","player":1,"movesLeft":3}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if err := c.PlaceNeutrals([]protocol.Position{{Row: 1, Col: 0}, {Row: 1, Col: 1}}); err == nil {
+		t.Error("Expected a second neutral placement to be refused")
+	}
+}
+
+func TestServerErrorUndoesRefusedMove(t *testing.T) {
+	var events []interface{}
+	callback := func(event string, data interface{}) {
+		if event == "error" {
+			events = append(events, data)
+		}
+	}
+	c, received := newTestServer(t, &config.Config{MovesPerTurn: 3}, callback)
+	c.gameState = &GameState{
+		Board: [][]protocol.CellType{
+			{protocol.CellPlayer1, protocol.CellEmpty},
+			{protocol.CellEmpty, protocol.CellPlayer2},
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+	c.gameID = "g1"
+	c.resetTurnBudget(3)
+
+	if err := c.MakeMove(0, 1); err != nil {
+		t.Fatalf("MakeMove failed: %v", err)
+	}
+	<-received
+
+	// An error about something else leaves the move in flight
+	if err := c.HandleMessage([]byte(`{"type":"error","message":"Rate limited","code":"rate_limit"}`)); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	if c.IsRejected(0, 1) || c.GetGameState().Board[0][1] != protocol.CellPlayer1 {
+		t.Fatal("Expected an error unrelated to moves not to refuse the move")
+	}
+	events = nil
+
+	if err := c.HandleMessage([]byte(`{"type":"error","message":"Invalid move"}`)); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+
+	state := c.GetGameState()
+	if state.Board[0][1] != protocol.CellEmpty {
+		t.Errorf("Expected the refused move to be undone, got %v", state.Board[0][1])
+	}
+	if got := c.GetMovesLeft(); got != 3 {
+		t.Errorf("Expected the refused move not to count, got %d moves left", got)
+	}
+	if !c.IsRejected(0, 1) || c.IsRejected(1, 0) {
+		t.Error("Expected only (0, 1) to be remembered as refused")
+	}
+	if len(events) != 1 || events[0].(*protocol.ErrorMessage).Message != "Invalid move" {
+		t.Errorf("Expected one error callback with the server's text, got %v", events)
+	}
+
+	select {
+	case data := <-received:
+		if !strings.Contains(string(data), string(protocol.MsgRequestState)) {
+			t.Errorf("Expected a state request, got %s", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the client to request the full state")
+	}
+
+	c.mu.Lock()
+	c.resetTurnBudget(3)
+	c.mu.Unlock()
+	if c.IsRejected(0, 1) {
+		t.Error("Expected refusals to be forgotten on the next turn")
+	}
+}
+
+func TestShutdownFinishesGameAndDeclinesNewOnes(t *testing.T) {
+	c, received := newTestServer(t, &config.Config{AutoAcceptChallenge: true}, nil)
+
+	gameStart := `{"type":"game_start","gameId":"g1","board":[[17,0],[0,18]],` +
+		`"players":[{"id":1,"position":{"row":0,"col":0}},{"id":2,"position":{"row":1,"col":1}}],` +
+		`"currentPlayer":2,"yourPlayerId":1}`
+	if err := c.handleMessage([]byte(gameStart)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	c.BeginShutdown()
+	if !c.ShuttingDown() || !c.InGame() {
+		t.Fatal("Expected the game to go on after shutdown begins")
+	}
+
+	if err := c.handleMessage([]byte(`{"type":"challenge_received","challengeId":"c1","fromUserId":"u2"}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if expected, data := `{"challengeId":"c1","type":"decline_challenge"}`, expectMessage(t, received); string(data) != expected {
+		t.Errorf("Expected %s while shutting down, got %s", expected, data)
+	}
+
+	if err := c.handleMessage([]byte(`{"type":"game_end","winner":1}`)); err != nil {
+		t.Fatalf("handleMessage failed: %v", err)
+	}
+	if c.InGame() || !c.ShuttingDown() {
+		t.Error("Expected the client to be idle and still shutting down after game_end")
+	}
+}
+
+// Run with -race: strategies read their snapshot while moves keep arriving
+func TestGameStateSnapshotIsNotSharedWithMoves(t *testing.T) {
+	c := NewClient(&config.Config{DefaultBoardSize: 8}, nil)
+	if err := c.HandleMessage([]byte(`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":8,"cols":8}`)); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+
+	snapshot := c.GetGameState()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for row := 0; row < 8; row++ {
+			msg := fmt.Sprintf(`{"type":"move_made","gameId":"g1","row":%d,"col":1,"player":2,"movesLeft":2}`, row)
+			if err := c.HandleMessage([]byte(msg)); err != nil {
+				t.Errorf("HandleMessage failed: %v", err)
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		snapshot.ToGame().Board.GetValidMoves(1)
+		c.GetGameState().ToGame().Board.CountCells(2)
+	}
+	<-done
+
+	if cell := snapshot.Board[3][1]; cell != protocol.CellEmpty {
+		t.Errorf("Expected the snapshot to keep its empty cell, got %v", cell)
+	}
+	if cell := c.GetGameState().Board[3][1]; cell.Player() != 2 {
+		t.Errorf("Expected the client to have applied the move, got %v", cell)
+	}
+}
@@ -0,0 +1,473 @@
+package game
+
+import (
+	"virusbot/internal/protocol"
+)
+
+// MoveFeatures holds the board facts the evaluator needs about a single move
+type MoveFeatures struct {
+	Move                Move
+	IsAttack            bool
+	IsEdge              bool
+	IsCorner            bool
+	IsSpecial           bool    // target is an uncaptured special cell
+	CapturesBase        bool    // attack on an opponent's base cell
+	EmptyNeighbors      int     // empty cells adjacent to the target
+	ConnectsToTerritory bool    // target is not base-connected but touches our reachable cells
+	Mobility            int     // distinct cells we could target after the move
+	FillsHole           bool    // target is an internal hole in our territory
+	CompactnessDelta    float64 // change in our area-to-perimeter ratio
+	PerimeterDelta      int     // change in our number of perimeter cells
+	Contested           bool    // our territory already borders an opponent
+	BaseThreat          float64 // opponent pressure on our base after the move, see Board.BaseThreat
+	BridgeDelta         int     // change in our number of bridges, see Board.ArticulationCells
+}
+
+// moveContext holds the structures shared by every move annotated in one pass
+type moveContext struct {
+	playerID    int
+	reachable   map[Position]bool
+	connected   map[Position]bool
+	targets     map[Position]bool
+	incremental bool // targets can be updated per move instead of re-generated
+	holes       map[Position]bool
+	area        int
+	perimeter   int
+	contested   bool
+	baseThreat  float64
+	bridges     int
+}
+
+// AnnotateMoves computes the features of every move in a single pass over
+// precomputed reachability, frontier and distance data, so the evaluator
+// does not re-query the board once per factor.
+func (b *Board) AnnotateMoves(moves []Move, playerID int) []MoveFeatures {
+	ctx := b.newMoveContext(playerID)
+
+	features := make([]MoveFeatures, len(moves))
+	for i, move := range moves {
+		features[i] = b.annotateMove(move, ctx)
+	}
+	return features
+}
+
+// newMoveContext precomputes the per-board data used by annotateMove
+func (b *Board) newMoveContext(playerID int) *moveContext {
+	ctx := &moveContext{
+		playerID:  playerID,
+		reachable: make(map[Position]bool),
+		connected: make(map[Position]bool),
+		targets:   make(map[Position]bool),
+	}
+
+	reachable := b.GetReachableCells(playerID)
+	for _, pos := range reachable {
+		ctx.reachable[pos] = true
+	}
+
+	// Same traversal as IsConnectedToBase: start at the base whoever owns it
+	if basePos, exists := b.BasePos[playerID]; exists {
+		ctx.connected[basePos] = true
+		queue := []Position{basePos}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, neighbor := range b.GetNeighbors(current) {
+				if !ctx.connected[neighbor] && b.IsOwnedBy(neighbor, playerID) {
+					ctx.connected[neighbor] = true
+					queue = append(queue, neighbor)
+				}
+			}
+		}
+
+		// Mobility can be updated incrementally only while the base anchors
+		// our territory; otherwise GetValidMoves takes its fallback paths
+		ctx.incremental = len(reachable) > 0 && b.IsOwnedBy(basePos, playerID)
+	}
+
+	for _, move := range b.GetValidMoves(playerID) {
+		ctx.targets[move.Position] = true
+	}
+
+	ctx.holes = make(map[Position]bool)
+	for _, region := range b.emptyRegions(playerID) {
+		if region.enclosed && !region.touchesEdge {
+			for _, pos := range region.cells {
+				ctx.holes[pos] = true
+			}
+		}
+	}
+	ctx.area, ctx.perimeter = b.areaAndPerimeter(playerID)
+	ctx.contested = b.touchesOpponent(playerID)
+	ctx.baseThreat = b.BaseThreat(playerID)
+	ctx.bridges = len(b.ArticulationCells(playerID))
+
+	return ctx
+}
+
+// LocalFeatures computes the features of a move that depend only on its
+// cell and the neighbors: the type, edge and corner, special and base
+// captures and the empty neighbors. The rest stay zero. It is cheap enough
+// for moves too many to annotate.
+func (b *Board) LocalFeatures(move Move) MoveFeatures {
+	pos := move.Position
+	f := MoveFeatures{
+		Move:      move,
+		IsAttack:  move.Type == MoveAttack,
+		IsEdge:    b.IsEdgePosition(pos),
+		IsCorner:  b.IsCornerPosition(pos),
+		IsSpecial: b.IsSpecial(pos),
+	}
+	for _, neighbor := range b.GetNeighbors(pos) {
+		if b.IsEmpty(neighbor) {
+			f.EmptyNeighbors++
+		}
+	}
+	if f.IsAttack {
+		f.CapturesBase = b.IsBaseCell(pos)
+	}
+	return f
+}
+
+// annotateMove computes the features of one move using a shared context
+func (b *Board) annotateMove(move Move, ctx *moveContext) MoveFeatures {
+	pos := move.Position
+	f := b.LocalFeatures(move)
+
+	touchesReachable := false
+	reconnects := false
+	for _, neighbor := range b.GetNeighbors(pos) {
+		if ctx.reachable[neighbor] {
+			touchesReachable = true
+		} else if b.IsOwnedBy(neighbor, ctx.playerID) {
+			reconnects = true
+		}
+	}
+	f.ConnectsToTerritory = !ctx.connected[pos] && touchesReachable
+
+	f.FillsHole = ctx.holes[pos]
+	f.CompactnessDelta = ctx.compactnessAfter(b.ownSides(pos, ctx.playerID)) - ctx.compactness()
+	f.PerimeterDelta = b.perimeterDelta(pos, ctx.playerID)
+	f.Contested = ctx.contested
+
+	// Only an attack changes the threat: the captured cell stops counting
+	f.BaseThreat = ctx.baseThreat
+	if f.IsAttack && b.isLiveOpponent(pos, ctx.playerID) {
+		f.BaseThreat -= b.threatFrom(pos, ctx.playerID)
+	}
+
+	after := b.ApplyMove(pos, ctx.playerID, move.Fortifies())
+	f.BridgeDelta = len(after.ArticulationCells(ctx.playerID)) - ctx.bridges
+
+	if ctx.incremental && !reconnects && !ctx.reachable[pos] {
+		f.Mobility = b.mobilityAfter(pos, ctx)
+	} else {
+		f.Mobility = b.mobilityAfterClone(move, ctx.playerID)
+	}
+
+	return f
+}
+
+// compactness is the area-to-perimeter ratio of our current territory
+func (ctx *moveContext) compactness() float64 {
+	if ctx.perimeter == 0 {
+		return 0
+	}
+	return float64(ctx.area) / float64(ctx.perimeter)
+}
+
+// compactnessAfter is the ratio once a cell sharing ownSides sides with our
+// territory is added: each shared side stops being perimeter on both cells
+func (ctx *moveContext) compactnessAfter(ownSides int) float64 {
+	perimeter := ctx.perimeter + 4 - 2*ownSides
+	if perimeter <= 0 {
+		return 0
+	}
+	return float64(ctx.area+1) / float64(perimeter)
+}
+
+// mobilityAfter updates the current target set for a capture of pos
+func (b *Board) mobilityAfter(pos Position, ctx *moveContext) int {
+	count := len(ctx.targets)
+	if ctx.targets[pos] {
+		count--
+	}
+	for _, neighbor := range b.GetNeighbors(pos) {
+		if ctx.targets[neighbor] || ctx.reachable[neighbor] {
+			continue
+		}
+		if b.IsGrowable(neighbor) || b.IsOpponent(neighbor, ctx.playerID) {
+			count++
+		}
+	}
+	return count
+}
+
+// mobilityAfterClone regenerates the target set on a board with the move applied
+func (b *Board) mobilityAfterClone(move Move, playerID int) int {
+	next := b.ApplyMove(move.Position, playerID, move.Fortifies())
+
+	targets := make(map[Position]bool)
+	for _, m := range next.GetValidMoves(playerID) {
+		targets[m.Position] = true
+	}
+	return len(targets)
+}
+
+// BaseThreat sums 1/d² over the live opponent cells, where d is a cell's
+// step distance to the player's base, so the threat rises smoothly as the
+// enemy closes in. It is 0 when the player has no base.
+func (b *Board) BaseThreat(playerID int) float64 {
+	if _, exists := b.BasePos[playerID]; !exists {
+		return 0
+	}
+
+	threat := 0.0
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
+			pos := Position{Row: row, Col: col}
+			if b.IsValid(pos) && b.isLiveOpponent(pos, playerID) {
+				threat += b.threatFrom(pos, playerID)
+			}
+		}
+	}
+	return threat
+}
+
+// threatFrom is the share of BaseThreat contributed by an opponent cell at pos
+func (b *Board) threatFrom(pos Position, playerID int) float64 {
+	base, exists := b.BasePos[playerID]
+	if !exists {
+		return 0
+	}
+	d := b.StepDistance(pos, base)
+	if d == 0 {
+		return 0
+	}
+	return 1 / float64(d*d)
+}
+
+// StepDistance returns the number of single-cell steps between two
+// positions on an open board under the game's adjacency rule
+func (b *Board) StepDistance(from, to Position) int {
+	dr := abs(from.Row - to.Row)
+	dc := abs(from.Col - to.Col)
+	if b.Rules.Adjacency == Adjacency4 {
+		return dr + dc
+	}
+	return max(dr, dc)
+}
+
+// DistanceMap returns the number of steps from the nearest source to every
+// cell, moving through any cell that is not an obstacle (neutral or killed).
+// Unreachable cells are -1.
+func (b *Board) DistanceMap(sources []Position) [][]int {
+	dist := make([][]int, b.Rows)
+	for i := range dist {
+		dist[i] = make([]int, b.Cols)
+		for j := range dist[i] {
+			dist[i][j] = -1
+		}
+	}
+
+	queue := make([]Position, 0, len(sources))
+	for _, src := range sources {
+		if b.IsValid(src) && dist[src.Row][src.Col] == -1 {
+			dist[src.Row][src.Col] = 0
+			queue = append(queue, src)
+		}
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, neighbor := range b.GetNeighbors(current) {
+			if dist[neighbor.Row][neighbor.Col] != -1 || b.IsObstacle(neighbor) {
+				continue
+			}
+			dist[neighbor.Row][neighbor.Col] = dist[current.Row][current.Col] + 1
+			queue = append(queue, neighbor)
+		}
+	}
+
+	return dist
+}
+
+// WouldDisconnect returns how many of the victim's cells would lose their
+// connection to base if the cell at pos were taken from them
+func (b *Board) WouldDisconnect(pos Position, victimID int) int {
+	if !b.IsOwnedBy(pos, victimID) {
+		return 0
+	}
+
+	before := len(b.GetReachableCells(victimID))
+
+	after := b.Clone()
+	after.SetCell(pos, protocol.CellNeutral)
+	lost := before - len(after.GetReachableCells(victimID)) - 1
+	if lost < 0 {
+		return 0
+	}
+	return lost
+}
+
+// LossCosts maps each of the player's cells to how many cells the player
+// would lose with it: the cell itself plus everything it alone connects to
+// the base. Losing the base costs everything connected to it. One
+// articulation-point pass over the base-connected territory computes every
+// cost; cells already cut off from the base cost only themselves.
+func (b *Board) LossCosts(playerID int) map[Position]int {
+	costs := make(map[Position]int)
+	for _, pos := range b.GetPlayerCells(playerID) {
+		costs[pos] = 1
+	}
+
+	base, exists := b.BasePos[playerID]
+	if !exists || !b.IsOwnedBy(base, playerID) {
+		return costs
+	}
+
+	disc := make(map[Position]int)
+	low := make(map[Position]int)
+	size := make(map[Position]int)
+	timer := 0
+
+	var visit func(v Position)
+	visit = func(v Position) {
+		timer++
+		disc[v], low[v], size[v] = timer, timer, 1
+		for _, w := range b.GetNeighbors(v) {
+			if !b.IsOwnedBy(w, playerID) {
+				continue
+			}
+			if disc[w] == 0 {
+				visit(w)
+				size[v] += size[w]
+				low[v] = min(low[v], low[w])
+				// w's subtree reaches the base only through v
+				if low[w] >= disc[v] {
+					costs[v] += size[w]
+				}
+			} else {
+				low[v] = min(low[v], disc[w])
+			}
+		}
+	}
+	visit(base)
+	costs[base] = size[base]
+
+	return costs
+}
+
+// ArticulationCells returns the player's bridges: the base-connected cells
+// other than the base whose loss would cut off part of the territory from
+// the base, in row-major order
+func (b *Board) ArticulationCells(playerID int) []Position {
+	base := b.BasePos[playerID]
+	costs := b.LossCosts(playerID)
+	var bridges []Position
+	for _, pos := range b.GetPlayerCells(playerID) {
+		if costs[pos] > 1 && pos != base {
+			bridges = append(bridges, pos)
+		}
+	}
+	return bridges
+}
+
+// frontlineTolerance is the largest gap between the two players' distances
+// at which an empty cell still counts as part of the frontline
+const frontlineTolerance = 1
+
+// FrontlineCells returns the empty cells roughly equidistant from our
+// territory and the opponent's: the midline along which a wall of neutrals
+// or defensive growth would separate the two
+func (b *Board) FrontlineCells(ourID, oppID int) []Position {
+	ours := b.GetPlayerCells(ourID)
+	theirs := b.GetPlayerCells(oppID)
+	if len(ours) == 0 || len(theirs) == 0 {
+		return nil
+	}
+
+	ourDist := b.DistanceMap(ours)
+	oppDist := b.DistanceMap(theirs)
+
+	frontline := make([]Position, 0)
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
+			pos := Position{Row: row, Col: col}
+			d1, d2 := ourDist[row][col], oppDist[row][col]
+			if !b.IsEmpty(pos) || d1 < 0 || d2 < 0 {
+				continue
+			}
+			if gap := d1 - d2; gap >= -frontlineTolerance && gap <= frontlineTolerance {
+				frontline = append(frontline, pos)
+			}
+		}
+	}
+	return frontline
+}
+
+// BoardMetrics summarizes a player's territory
+type BoardMetrics struct {
+	Cells        int // cells the player owns
+	Frontier     int // owned cells next to an empty, special or live opponent cell
+	Components   int // groups of owned cells connected to each other
+	Reachable    int // cells connected to the base, as GetReachableCells counts them
+	Disconnected int // owned cells cut off from the base
+}
+
+// Analyze computes the BoardMetrics of a player in one flood fill over
+// their cells. As in GetReachableCells, a player without a base position
+// has nothing reachable, and one whose base was captured counts the group
+// of their first remaining cell.
+func (b *Board) Analyze(playerID int) BoardMetrics {
+	var m BoardMetrics
+	seen := make(map[Position]bool)
+	base, hasBase := b.BasePos[playerID]
+	baseLost := hasBase && !b.IsOwnedBy(base, playerID)
+
+	var neighbors []Position
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
+			start := Position{Row: row, Col: col}
+			if seen[start] || !b.IsOwnedBy(start, playerID) {
+				continue
+			}
+
+			// Flood the group containing start
+			m.Components++
+			size, anchored := 0, false
+			seen[start] = true
+			queue := []Position{start}
+			for len(queue) > 0 {
+				current := queue[0]
+				queue = queue[1:]
+				size++
+				anchored = anchored || current == base
+				frontier := false
+				neighbors = b.AppendNeighbors(neighbors[:0], current)
+				for _, n := range neighbors {
+					switch {
+					case b.IsOwnedBy(n, playerID):
+						if !seen[n] {
+							seen[n] = true
+							queue = append(queue, n)
+						}
+					case b.IsGrowable(n) || b.isLiveOpponent(n, playerID):
+						frontier = true
+					}
+				}
+				if frontier {
+					m.Frontier++
+				}
+			}
+
+			m.Cells += size
+			if (hasBase && anchored) || (baseLost && m.Components == 1) {
+				m.Reachable = size
+			}
+		}
+	}
+	m.Disconnected = m.Cells - m.Reachable
+	return m
+}
@@ -0,0 +1,305 @@
+package game
+
+import (
+	"testing"
+
+	"virusbot/internal/protocol"
+)
+
+// createMidGameBoard builds a 6x6 board with two players, an attackable
+// opponent arm and a cut-off group of our own
+func createMidGameBoard() *Board {
+	board := NewBoard(6)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 5, Col: 5}
+
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(1|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 1, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 2, Col: 2}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 0, Col: 5}, protocol.CellPlayer1) // cut off from base
+
+	board.SetCell(Position{Row: 5, Col: 5}, protocol.CellType(2|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellPlayer2)
+	board.SetCell(Position{Row: 3, Col: 3}, protocol.CellPlayer2)
+	board.SetCell(Position{Row: 3, Col: 2}, protocol.CellPlayer2)
+	board.SetCell(Position{Row: 1, Col: 4}, protocol.CellPlayer2) // cut off from base
+	board.SetCell(Position{Row: 2, Col: 0}, protocol.CellNeutral)
+
+	return board
+}
+
+func TestAnnotateMovesMatchesPerMoveQueries(t *testing.T) {
+	board := createMidGameBoard()
+	moves := board.GetValidMoves(1)
+	if len(moves) == 0 {
+		t.Fatal("Expected valid moves on mid-game board")
+	}
+
+	features := board.AnnotateMoves(moves, 1)
+	if len(features) != len(moves) {
+		t.Fatalf("Expected %d features, got %d", len(moves), len(features))
+	}
+
+	for i, f := range features {
+		move := moves[i]
+		if f.Move != move {
+			t.Errorf("Feature %d is for %v, want %v", i, f.Move, move)
+		}
+		if f.EmptyNeighbors != len(board.GetEmptyNeighbors(move.Position)) {
+			t.Errorf("%v: EmptyNeighbors = %d, want %d", move.Position, f.EmptyNeighbors, len(board.GetEmptyNeighbors(move.Position)))
+		}
+		if f.IsEdge != board.IsEdgePosition(move.Position) || f.IsCorner != board.IsCornerPosition(move.Position) {
+			t.Errorf("%v: edge/corner mismatch", move.Position)
+		}
+		if want := board.mobilityAfterClone(move, 1); f.Mobility != want {
+			t.Errorf("%v: Mobility = %d, want %d", move.Position, f.Mobility, want)
+		}
+	}
+}
+
+func TestDistanceMap(t *testing.T) {
+	board := NewBoard(3)
+	board.SetCell(Position{Row: 1, Col: 1}, protocol.CellNeutral)
+
+	dist := board.DistanceMap([]Position{{Row: 0, Col: 0}})
+
+	if dist[0][0] != 0 {
+		t.Errorf("Expected source distance 0, got %d", dist[0][0])
+	}
+	if dist[1][1] != -1 {
+		t.Errorf("Expected neutral cell to be unreachable, got %d", dist[1][1])
+	}
+	if dist[0][2] != 2 {
+		t.Errorf("Expected distance 2 to (0,2), got %d", dist[0][2])
+	}
+}
+
+func TestFrontlineCells(t *testing.T) {
+	board := NewBoard(7)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 6, Col: 6}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(1|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 6, Col: 6}, protocol.CellType(2|int(protocol.CellFlagBase)))
+
+	frontline := board.FrontlineCells(1, 2)
+	if len(frontline) == 0 {
+		t.Fatal("Expected frontline cells between the bases")
+	}
+
+	foundCenter := false
+	for _, pos := range frontline {
+		if !board.IsEmpty(pos) {
+			t.Errorf("Frontline cell %v is not empty", pos)
+		}
+		// The midline of a diagonal matchup runs across the anti-diagonal
+		if sum := pos.Row + pos.Col; sum < 5 || sum > 7 {
+			t.Errorf("Frontline cell %v is not between the bases", pos)
+		}
+		if pos == (Position{Row: 3, Col: 3}) {
+			foundCenter = true
+		}
+	}
+	if !foundCenter {
+		t.Errorf("Expected the center (3,3) on the frontline, got %v", frontline)
+	}
+
+	if got := board.FrontlineCells(1, 3); got != nil {
+		t.Errorf("Expected no frontline against a player without cells, got %v", got)
+	}
+}
+
+func TestWouldDisconnect(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[2] = Position{Row: 0, Col: 0}
+
+	// A straight arm: base - (0,1) - (0,2) - (0,3)
+	for c := 0; c <= 3; c++ {
+		board.SetCell(Position{Row: 0, Col: c}, protocol.CellPlayer2)
+	}
+
+	if got := board.WouldDisconnect(Position{Row: 0, Col: 1}, 2); got != 2 {
+		t.Errorf("Taking (0,1) should cut off 2 cells, got %d", got)
+	}
+	if got := board.WouldDisconnect(Position{Row: 0, Col: 3}, 2); got != 0 {
+		t.Errorf("Taking the arm's tip should cut off nothing, got %d", got)
+	}
+}
+
+func TestMostCriticalCellPrefersArticulation(t *testing.T) {
+	board := NewBoard(6)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(1|int(protocol.CellFlagBase)))
+	// A neck at (0,1) holds a blob of four cells; (1,0) is a leaf
+	for _, pos := range []Position{{Row: 0, Col: 1}, {Row: 0, Col: 2}, {Row: 0, Col: 3}, {Row: 1, Col: 3}, {Row: 1, Col: 2}, {Row: 1, Col: 0}} {
+		board.SetCell(pos, protocol.CellPlayer1)
+	}
+	state := &GameState{Board: board, YourPlayerID: 1}
+
+	costs := board.LossCosts(1)
+	neck, leaf := Position{Row: 0, Col: 1}, Position{Row: 1, Col: 0}
+	if costs[neck] <= costs[leaf] {
+		t.Errorf("Expected the articulation cell (%d) to cost more than the leaf (%d)", costs[neck], costs[leaf])
+	}
+	if costs[board.BasePos[1]] != 7 {
+		t.Errorf("Expected the base to cost all 7 cells, got %d", costs[board.BasePos[1]])
+	}
+
+	pos, cost := state.MostCriticalCell(1)
+	if pos != neck || cost != 5 {
+		t.Errorf("Expected (0,1) holding 5 cells, got %v holding %.0f", pos, cost)
+	}
+}
+
+// bridgedBoard has player 1's territory in two bands, rows 0-1 holding the
+// base and rows 3-4, joined only by (2,2)
+func bridgedBoard() *Board {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	for row := 0; row < 5; row++ {
+		for col := 0; col < 5; col++ {
+			if row != 2 {
+				board.SetCell(Position{Row: row, Col: col}, protocol.CellPlayer1)
+			}
+		}
+	}
+	board.SetCell(board.BasePos[1], protocol.CellType(1|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 2, Col: 2}, protocol.CellPlayer1)
+	return board
+}
+
+func TestArticulationCellsFindsSingleBridge(t *testing.T) {
+	board := bridgedBoard()
+
+	bridges := board.ArticulationCells(1)
+	if len(bridges) != 1 || bridges[0] != (Position{Row: 2, Col: 2}) {
+		t.Fatalf("Expected (2,2) as the only bridge, got %v", bridges)
+	}
+
+	// A second crossing leaves nothing a single attack could cut off
+	board.SetCell(Position{Row: 2, Col: 0}, protocol.CellPlayer1)
+	if bridges := board.ArticulationCells(1); len(bridges) != 0 {
+		t.Errorf("Expected no bridges with two crossings, got %v", bridges)
+	}
+}
+
+func TestAnnotateMovesCountsBridges(t *testing.T) {
+	board := bridgedBoard()
+	crossing := Move{Position: Position{Row: 2, Col: 0}, Type: MoveGrow}
+	if f := board.AnnotateMoves([]Move{crossing}, 1)[0]; f.BridgeDelta != -1 {
+		t.Errorf("Expected a second crossing to remove the bridge, got delta %d", f.BridgeDelta)
+	}
+
+	// Growing past the end of a line makes its last cell a bridge
+	line := NewBoard(4)
+	line.BasePos[1] = Position{Row: 0, Col: 0}
+	line.SetCell(line.BasePos[1], protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	line.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+	tip := Move{Position: Position{Row: 0, Col: 2}, Type: MoveGrow}
+	if f := line.AnnotateMoves([]Move{tip}, 1)[0]; f.BridgeDelta != 1 {
+		t.Errorf("Expected extending the line to add a bridge, got delta %d", f.BridgeDelta)
+	}
+}
+
+func BenchmarkAnnotateMoves(b *testing.B) {
+	board := GenerateBoard(20, 0.4, 1)
+	moves := board.GetValidMoves(1)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		board.AnnotateMoves(moves, 1)
+	}
+}
+
+func BenchmarkPerMoveMobility(b *testing.B) {
+	board := GenerateBoard(20, 0.4, 1)
+	moves := board.GetValidMoves(1)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, move := range moves {
+			board.mobilityAfterClone(move, 1)
+		}
+	}
+}
+
+func TestDistanceMapRoutesAroundKilledCells(t *testing.T) {
+	board := NewBoard(5)
+	killed := protocol.CellType(int(protocol.CellPlayer2) | int(protocol.CellFlagKilled))
+
+	// A wall of killed cells across row 1, leaving a gap at (1,4)
+	for c := 0; c < 4; c++ {
+		board.SetCell(Position{Row: 1, Col: c}, killed)
+	}
+
+	dist := board.DistanceMap([]Position{{Row: 0, Col: 0}})
+
+	if dist[1][0] != -1 {
+		t.Errorf("Expected killed cell to be impassable, got distance %d", dist[1][0])
+	}
+	// Without the wall (2,0) is 2 steps away; around it the path goes via (1,4)
+	if dist[2][0] <= 2 {
+		t.Errorf("Expected path to (2,0) to route around killed cells, got distance %d", dist[2][0])
+	}
+	if dist[2][0] == -1 {
+		t.Error("Expected (2,0) to be reachable through the gap")
+	}
+}
+
+func TestKilledCellsBlockConnectivity(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellPlayer1)
+
+	// A killed cell with our player bits sits between the base and our cell
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagKilled)))
+	board.SetCell(Position{Row: 0, Col: 2}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 1, Col: 0}, protocol.CellNeutral)
+	board.SetCell(Position{Row: 1, Col: 1}, protocol.CellNeutral)
+	board.SetCell(Position{Row: 1, Col: 2}, protocol.CellNeutral)
+
+	if board.IsConnectedToBase(1, Position{Row: 0, Col: 2}) {
+		t.Error("Expected killed cell to break the connection to base")
+	}
+	if got := len(board.GetReachableCells(1)); got != 1 {
+		t.Errorf("Expected only the base to be reachable, got %d cells", got)
+	}
+	if got := board.CountCells(1); got != 2 {
+		t.Errorf("Expected killed cell not to count as territory, got %d cells", got)
+	}
+}
+
+func TestAnalyzeCountsDisconnectedGroup(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 4, Col: 0}
+	board.SetCell(board.BasePos[2], protocol.CellType(2|int(protocol.CellFlagBase)))
+	// A 3x3 block around the base; its top-left four cells touch only our own
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			board.SetCell(Position{Row: row, Col: col}, protocol.CellPlayer1)
+		}
+	}
+	board.SetCell(board.BasePos[1], protocol.CellType(1|int(protocol.CellFlagBase)))
+	// A group of two sealed off by neutrals in the far corner, so no frontier
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 3, Col: 4}, protocol.CellPlayer1)
+	for _, pos := range []Position{{Row: 2, Col: 3}, {Row: 2, Col: 4}, {Row: 3, Col: 3}, {Row: 4, Col: 3}} {
+		board.SetCell(pos, protocol.CellNeutral)
+	}
+
+	want := BoardMetrics{Cells: 11, Frontier: 5, Components: 2, Reachable: 9, Disconnected: 2}
+	if got := board.Analyze(1); got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	// Once the base falls, the first remaining group counts as reachable
+	board.SetCell(board.BasePos[1], protocol.CellType(2|int(protocol.CellFlagFortified)))
+	got := board.Analyze(1)
+	if got.Reachable != len(board.GetReachableCells(1)) || got.Cells != board.CountCells(1) {
+		t.Errorf("Expected Analyze to agree with GetReachableCells and CountCells, got %+v", got)
+	}
+	if got.Reachable != 8 || got.Disconnected != 2 {
+		t.Errorf("Expected 8 reachable and 2 cut-off cells after losing the base, got %+v", got)
+	}
+}
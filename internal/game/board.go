@@ -1,6 +1,17 @@
+// Package game models the board, the rules and the state of a game.
+//
+// Players are identified by 1-based IDs (1-4) that equal the player bits of
+// their cells: a protocol.CellPlayer1 cell belongs to player 1, and
+// CellType.Player() of an owned cell is its owner's ID. Board.BasePos keys,
+// Player.ID and every playerID argument use this numbering; 0 is no player.
 package game
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
 	"virusbot/internal/protocol"
 )
 
@@ -9,38 +20,148 @@ type Position struct {
 	Row, Col int
 }
 
-// Board represents the game board
+// Board represents the game board, a grid of Rows by Cols cells
 type Board struct {
-	Size    int
+	Rows    int
+	Cols    int
 	Cells   [][]protocol.CellType
 	BasePos map[int]Position // playerID -> base position
+	Rules   GameConfig       // rule variant the game is played with
 }
 
-// NewBoard creates a new empty board
+// NewBoard creates a new empty square board
 func NewBoard(size int) *Board {
-	cells := make([][]protocol.CellType, size)
+	return NewRectBoard(size, size)
+}
+
+// NewRectBoard creates a new empty board of rows by cols cells
+func NewRectBoard(rows, cols int) *Board {
+	cells := make([][]protocol.CellType, rows)
 	for i := range cells {
-		cells[i] = make([]protocol.CellType, size)
+		cells[i] = make([]protocol.CellType, cols)
 		for j := range cells[i] {
 			cells[i][j] = protocol.CellEmpty
 		}
 	}
 
 	return &Board{
-		Size:    size,
+		Rows:    rows,
+		Cols:    cols,
 		Cells:   cells,
 		BasePos: make(map[int]Position),
 	}
 }
 
-// NewBoardFromData creates a board from existing data
+// Dimensions returns the rows of cells and the length of the first one
+func Dimensions(cells [][]protocol.CellType) (rows, cols int) {
+	if len(cells) == 0 {
+		return 0, 0
+	}
+	return len(cells), len(cells[0])
+}
+
+// NewBoardFromData creates a board from existing data. Bases that are off
+// the board or shared by several players are moved to a cell the player
+// owns, or dropped; ValidateBases on the input reports such problems.
 func NewBoardFromData(cells [][]protocol.CellType, basePos map[int]Position) *Board {
-	size := len(cells)
-	return &Board{
-		Size:    size,
+	rows, cols := Dimensions(cells)
+	b := &Board{
+		Rows:    rows,
+		Cols:    cols,
 		Cells:   cells,
 		BasePos: basePos,
 	}
+	b.repairBases()
+	return b
+}
+
+// ErrInvalidBase reports a base position that cannot be used
+var ErrInvalidBase = errors.New("invalid base position")
+
+// ValidateBases reports every base that lies off the board or that several
+// players share, since either corrupts connectivity for the players involved
+func (b *Board) ValidateBases() error {
+	invalid := b.invalidBases()
+	if len(invalid) == 0 {
+		return nil
+	}
+
+	problems := make([]error, 0, len(invalid))
+	for _, id := range sortedIDs(invalid) {
+		problems = append(problems, fmt.Errorf("player %d base %v %s: %w", id, b.BasePos[id], invalid[id], ErrInvalidBase))
+	}
+	return errors.Join(problems...)
+}
+
+// invalidBases maps each player with an unusable base to the reason. Of
+// players sharing a base, the one owning the cell keeps it.
+func (b *Board) invalidBases() map[int]string {
+	invalid := make(map[int]string)
+	claims := make(map[Position][]int)
+	for _, id := range sortedIDs(b.BasePos) {
+		pos := b.BasePos[id]
+		if !b.IsValid(pos) {
+			invalid[id] = "is off the board"
+			continue
+		}
+		claims[pos] = append(claims[pos], id)
+	}
+
+	for pos, ids := range claims {
+		if len(ids) < 2 {
+			continue
+		}
+		owner := b.GetCell(pos).Player()
+		for _, id := range ids {
+			if id != owner {
+				invalid[id] = fmt.Sprintf("is shared by players %v", ids)
+			}
+		}
+	}
+	return invalid
+}
+
+// repairBases moves every invalid base to the player's base-flagged cell,
+// or their first cell, and drops it if the player owns nothing
+func (b *Board) repairBases() {
+	for id := range b.invalidBases() {
+		if pos, ok := b.findBase(id); ok {
+			b.BasePos[id] = pos
+		} else {
+			delete(b.BasePos, id)
+		}
+	}
+}
+
+// findBase locates a player's base on the board, falling back to the first
+// cell they own
+func (b *Board) findBase(playerID int) (Position, bool) {
+	first, found := Position{}, false
+	for row := range b.Cells {
+		for col := range b.Cells[row] {
+			pos := Position{Row: row, Col: col}
+			if !b.IsOwnedBy(pos, playerID) {
+				continue
+			}
+			if b.Cells[row][col].Flag() == protocol.CellFlagBase {
+				return pos, true
+			}
+			if !found {
+				first, found = pos, true
+			}
+		}
+	}
+	return first, found
+}
+
+// sortedIDs returns the keys of a player map in ascending order
+func sortedIDs[T any](m map[int]T) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
 }
 
 // GetCell returns the cell type at the given position
@@ -58,10 +179,11 @@ func (b *Board) SetCell(pos Position, cellType protocol.CellType) {
 	}
 }
 
-// IsValid checks if a position is within the board
+// IsValid checks if a position is within the board. Rows shorter than the
+// board is wide (boards built from uneven server data) are respected.
 func (b *Board) IsValid(pos Position) bool {
-	return pos.Row >= 0 && pos.Row < b.Size &&
-		pos.Col >= 0 && pos.Col < b.Size
+	return pos.Row >= 0 && pos.Row < b.Rows && pos.Row < len(b.Cells) &&
+		pos.Col >= 0 && pos.Col < b.Cols && pos.Col < len(b.Cells[pos.Row])
 }
 
 // IsEmpty checks if a cell is empty
@@ -71,9 +193,24 @@ func (b *Board) IsEmpty(pos Position) bool {
 
 // IsOwnedBy checks if a cell is owned by a specific player
 func (b *Board) IsOwnedBy(pos Position, playerID int) bool {
-	cell := b.GetCell(pos)
-	// Extract player ID from cell value (handles flag bits)
-	return cell.Player() == playerID && cell != protocol.CellEmpty && cell != protocol.CellNeutral
+	return ownedBy(b.GetCell(pos), playerID)
+}
+
+// ownedBy reports whether a cell belongs to a player. The player bits of a
+// cell are its owner's ID whatever its base or fortified flag; killed cells
+// belong to no one, and neither do cells whose bits name no player (empty,
+// neutral, special), whatever their flags.
+func ownedBy(cell protocol.CellType, playerID int) bool {
+	return isPlayerID(playerID) && cell.Player() == playerID && !cell.IsKilled()
+}
+
+// maxPlayers is the most players a game has; player bits above it mark
+// neutral and special cells
+const maxPlayers = 4
+
+// isPlayerID reports whether id is a player that can own cells
+func isPlayerID(id int) bool {
+	return id >= 1 && id <= maxPlayers
 }
 
 // IsNeutral checks if a cell is neutral
@@ -81,40 +218,77 @@ func (b *Board) IsNeutral(pos Position) bool {
 	return b.GetCell(pos) == protocol.CellNeutral
 }
 
+// IsSpecial checks if a cell is an uncaptured special (power-up) cell
+func (b *Board) IsSpecial(pos Position) bool {
+	return b.GetCell(pos).IsSpecial()
+}
+
+// IsGrowable checks if a cell can be captured with a grow move
+func (b *Board) IsGrowable(pos Position) bool {
+	return b.IsEmpty(pos) || b.IsSpecial(pos)
+}
+
+// IsBaseCell checks if a cell is a player's base
+func (b *Board) IsBaseCell(pos Position) bool {
+	return b.GetCell(pos).IsBase()
+}
+
+// IsKilled checks if a cell was killed (a permanent dead cell)
+func (b *Board) IsKilled(pos Position) bool {
+	return b.GetCell(pos).IsKilled()
+}
+
+// IsObstacle checks if a cell can never be entered or passed through
+func (b *Board) IsObstacle(pos Position) bool {
+	return b.IsNeutral(pos) || b.IsKilled(pos)
+}
+
 // IsOpponent checks if a cell is owned by an opponent AND can be attacked
 func (b *Board) IsOpponent(pos Position, playerID int) bool {
 	cell := b.GetCell(pos)
-	if cell == protocol.CellEmpty || cell == protocol.CellNeutral {
+	// Extract player ID from cell value (handles flag bits); cells of no
+	// player, such as neutrals with flag bits, are never opponents
+	if owner := cell.Player(); !isPlayerID(owner) || owner == playerID {
 		return false
 	}
-	// Extract player ID from cell value (handles flag bits)
 	// Only return true if it's an opponent's cell AND it can be attacked (not base/fortified/killed)
-	return cell.Player() != playerID && cell.CanBeAttacked()
+	return cell.CanBeAttacked() || (b.Rules.BasesAttackable && cell.IsBase())
+}
+
+// neighborDirections are the 8 directions: up, down, left, right, and 4
+// diagonals. The orthogonal ones come first so 4-directional games can use
+// a prefix.
+var neighborDirections = [8]struct{ dr, dc int }{
+	{-1, 0},  // up
+	{1, 0},   // down
+	{0, -1},  // left
+	{0, 1},   // right
+	{-1, -1}, // up-left
+	{-1, 1},  // up-right
+	{1, -1},  // down-left
+	{1, 1},   // down-right
 }
 
 // GetNeighbors returns all adjacent positions (8-directional: orthogonal + diagonal)
 func (b *Board) GetNeighbors(pos Position) []Position {
-	neighbors := make([]Position, 0, 8)
-	// 8 directions: up, down, left, right, and 4 diagonals
-	directions := []struct{ dr, dc int }{
-		{-1, 0},  // up
-		{1, 0},   // down
-		{0, -1},  // left
-		{0, 1},   // right
-		{-1, -1}, // up-left
-		{-1, 1},  // up-right
-		{1, -1},  // down-left
-		{1, 1},   // down-right
-	}
-
-	for _, d := range directions {
+	return b.AppendNeighbors(make([]Position, 0, 8), pos)
+}
+
+// AppendNeighbors appends the positions adjacent to pos to dst and returns
+// the extended slice. Hot loops pass dst[:0] of a reused buffer so that
+// neighbor lookups do not allocate.
+func (b *Board) AppendNeighbors(dst []Position, pos Position) []Position {
+	dirs := neighborDirections[:]
+	if b.Rules.Adjacency == Adjacency4 {
+		dirs = dirs[:4]
+	}
+	for _, d := range dirs {
 		n := Position{Row: pos.Row + d.dr, Col: pos.Col + d.dc}
 		if b.IsValid(n) {
-			neighbors = append(neighbors, n)
+			dst = append(dst, n)
 		}
 	}
-
-	return neighbors
+	return dst
 }
 
 // GetAdjacentCells returns adjacent positions filtered by cell type
@@ -148,9 +322,9 @@ func (b *Board) GetOpponentNeighbors(pos Position, playerID int) []Position {
 
 // Clone creates a deep copy of the board
 func (b *Board) Clone() *Board {
-	newCells := make([][]protocol.CellType, b.Size)
+	newCells := make([][]protocol.CellType, b.Rows)
 	for i := range newCells {
-		newCells[i] = make([]protocol.CellType, b.Size)
+		newCells[i] = make([]protocol.CellType, b.Cols)
 		copy(newCells[i], b.Cells[i])
 	}
 
@@ -160,16 +334,23 @@ func (b *Board) Clone() *Board {
 	}
 
 	return &Board{
-		Size:    b.Size,
+		Rows:    b.Rows,
+		Cols:    b.Cols,
 		Cells:   newCells,
 		BasePos: newBasePos,
+		Rules:   b.Rules,
 	}
 }
 
-// ApplyMove applies a move to the board and returns a new board
-func (b *Board) ApplyMove(pos Position, playerID int, isAttack bool) *Board {
+// ApplyMove applies a move to a copy of the board and returns the copy; the
+// receiver is left unchanged. fortify marks the cell fortified, as attacks
+// and fortify moves do (see Move.Fortifies).
+func (b *Board) ApplyMove(pos Position, playerID int, fortify bool) *Board {
 	newBoard := b.Clone()
 	cellType := protocol.CellType(playerID) // Player 1 → CellPlayer1 (1), Player 2 → CellPlayer2 (2)
+	if fortify {
+		cellType = protocol.CellType(playerID | int(protocol.CellFlagFortified))
+	}
 	newBoard.SetCell(pos, cellType)
 	return newBoard
 }
@@ -177,10 +358,9 @@ func (b *Board) ApplyMove(pos Position, playerID int, isAttack bool) *Board {
 // CountCells counts the number of cells owned by a player
 func (b *Board) CountCells(playerID int) int {
 	count := 0
-	for row := 0; row < b.Size; row++ {
-		for col := 0; col < b.Size; col++ {
-			// Use Player() method to extract player ID from cell value
-			if b.Cells[row][col].Player() == playerID {
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
+			if ownedBy(b.Cells[row][col], playerID) {
 				count++
 			}
 		}
@@ -191,10 +371,9 @@ func (b *Board) CountCells(playerID int) int {
 // GetPlayerCells returns all positions owned by a player
 func (b *Board) GetPlayerCells(playerID int) []Position {
 	cells := make([]Position, 0)
-	for row := 0; row < b.Size; row++ {
-		for col := 0; col < b.Size; col++ {
-			// Use Player() method to extract player ID from cell value
-			if b.Cells[row][col].Player() == playerID {
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
+			if ownedBy(b.Cells[row][col], playerID) {
 				cells = append(cells, Position{Row: row, Col: col})
 			}
 		}
@@ -205,8 +384,8 @@ func (b *Board) GetPlayerCells(playerID int) []Position {
 // GetEmptyCells returns all empty positions
 func (b *Board) GetEmptyCells() []Position {
 	cells := make([]Position, 0)
-	for row := 0; row < b.Size; row++ {
-		for col := 0; col < b.Size; col++ {
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
 			if b.Cells[row][col] == protocol.CellEmpty {
 				cells = append(cells, Position{Row: row, Col: col})
 			}
@@ -217,12 +396,295 @@ func (b *Board) GetEmptyCells() []Position {
 
 // IsEdgePosition checks if a position is on the edge of the board
 func (b *Board) IsEdgePosition(pos Position) bool {
-	return pos.Row == 0 || pos.Row == b.Size-1 ||
-		pos.Col == 0 || pos.Col == b.Size-1
+	return pos.Row == 0 || pos.Row == b.Rows-1 ||
+		pos.Col == 0 || pos.Col == b.Cols-1
 }
 
 // IsCornerPosition checks if a position is in a corner of the board
 func (b *Board) IsCornerPosition(pos Position) bool {
-	return (pos.Row == 0 || pos.Row == b.Size-1) &&
-		(pos.Col == 0 || pos.Col == b.Size-1)
+	return (pos.Row == 0 || pos.Row == b.Rows-1) &&
+		(pos.Col == 0 || pos.Col == b.Cols-1)
+}
+
+// EnclosedEmptyCells returns empty cells in regions bordered only by the
+// player's cells and the board edges. Filling them is safe expansion that
+// the opponent can never contest.
+func (b *Board) EnclosedEmptyCells(playerID int) []Position {
+	result := make([]Position, 0)
+	for _, region := range b.emptyRegions(playerID) {
+		if region.enclosed {
+			result = append(result, region.cells...)
+		}
+	}
+	return result
+}
+
+// InternalHoles counts empty cells completely surrounded by the player's
+// territory, away from the board edges
+func (b *Board) InternalHoles(playerID int) int {
+	holes := 0
+	for _, region := range b.emptyRegions(playerID) {
+		if region.enclosed && !region.touchesEdge {
+			holes += len(region.cells)
+		}
+	}
+	return holes
+}
+
+// emptyRegion is a connected group of empty cells
+type emptyRegion struct {
+	cells       []Position
+	enclosed    bool // every non-empty neighbor belongs to the player
+	touchesEdge bool
+}
+
+// emptyRegions flood fills the board's empty cells into connected regions
+func (b *Board) emptyRegions(playerID int) []emptyRegion {
+	regions := make([]emptyRegion, 0)
+	visited := make(map[Position]bool)
+
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
+			start := Position{Row: row, Col: col}
+			if visited[start] || !b.IsEmpty(start) {
+				continue
+			}
+
+			// Flood fill the empty region, noting whether it touches anything
+			// other than our own cells
+			region := emptyRegion{cells: make([]Position, 0), enclosed: true}
+			queue := []Position{start}
+			visited[start] = true
+
+			for len(queue) > 0 {
+				current := queue[0]
+				queue = queue[1:]
+				region.cells = append(region.cells, current)
+				if b.IsEdgePosition(current) {
+					region.touchesEdge = true
+				}
+
+				for _, neighbor := range b.GetNeighbors(current) {
+					if b.IsEmpty(neighbor) {
+						if !visited[neighbor] {
+							visited[neighbor] = true
+							queue = append(queue, neighbor)
+						}
+					} else if !b.IsOwnedBy(neighbor, playerID) {
+						region.enclosed = false
+					}
+				}
+			}
+
+			regions = append(regions, region)
+		}
+	}
+
+	return regions
+}
+
+// Compactness returns the area-to-perimeter ratio of the player's territory,
+// the inverse of the perimeter-to-area ratio. Higher is more compact; 0 if
+// the player has no cells.
+func (b *Board) Compactness(playerID int) float64 {
+	area, perimeter := b.areaAndPerimeter(playerID)
+	if perimeter == 0 {
+		return 0
+	}
+	return float64(area) / float64(perimeter)
+}
+
+// PerimeterCells returns the player's cells that touch a cell they do not
+// own: the cells that must be defended
+func (b *Board) PerimeterCells(playerID int) []Position {
+	cells := make([]Position, 0)
+	for _, pos := range b.GetPlayerCells(playerID) {
+		if b.foreignNeighbors(pos, playerID) > 0 {
+			cells = append(cells, pos)
+		}
+	}
+	return cells
+}
+
+// PerimeterLength returns the number of the player's perimeter cells
+func (b *Board) PerimeterLength(playerID int) int {
+	return len(b.PerimeterCells(playerID))
+}
+
+// foreignNeighbors counts the neighbors of pos the player does not own
+func (b *Board) foreignNeighbors(pos Position, playerID int) int {
+	count := 0
+	for _, neighbor := range b.GetNeighbors(pos) {
+		if !b.IsOwnedBy(neighbor, playerID) {
+			count++
+		}
+	}
+	return count
+}
+
+// perimeterDelta is the change in PerimeterLength if the player captured pos
+func (b *Board) perimeterDelta(pos Position, playerID int) int {
+	delta := 0
+	for _, neighbor := range b.GetNeighbors(pos) {
+		if !b.IsOwnedBy(neighbor, playerID) {
+			delta = 1
+			break
+		}
+	}
+	// Own neighbors whose only foreign neighbor is pos become interior
+	for _, neighbor := range b.GetNeighbors(pos) {
+		if b.IsOwnedBy(neighbor, playerID) && b.foreignNeighbors(neighbor, playerID) == 1 {
+			delta--
+		}
+	}
+	return delta
+}
+
+// touchesOpponent reports whether any of the player's cells borders a live
+// cell of another player, checking only the perimeter
+func (b *Board) touchesOpponent(playerID int) bool {
+	for _, pos := range b.PerimeterCells(playerID) {
+		for _, neighbor := range b.GetNeighbors(pos) {
+			if b.isLiveOpponent(neighbor, playerID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isLiveOpponent reports whether pos holds a cell of another player that
+// has not been killed
+func (b *Board) isLiveOpponent(pos Position, playerID int) bool {
+	cell := b.GetCell(pos)
+	p := cell.Player()
+	return p >= int(protocol.CellPlayer1) && p <= int(protocol.CellPlayer4) && p != playerID && !cell.IsKilled()
+}
+
+// orthogonal are the four side-sharing directions used to measure perimeter
+var orthogonal = []Position{{Row: -1, Col: 0}, {Row: 1, Col: 0}, {Row: 0, Col: -1}, {Row: 0, Col: 1}}
+
+// areaAndPerimeter counts the player's cells and the cell sides they expose
+// to the board edge or to cells they do not own
+func (b *Board) areaAndPerimeter(playerID int) (int, int) {
+	area, perimeter := 0, 0
+	for _, pos := range b.GetPlayerCells(playerID) {
+		area++
+		perimeter += 4 - b.ownSides(pos, playerID)
+	}
+	return area, perimeter
+}
+
+// ownSides counts the orthogonal neighbors of pos owned by the player
+func (b *Board) ownSides(pos Position, playerID int) int {
+	count := 0
+	for _, d := range orthogonal {
+		if b.IsOwnedBy(Position{Row: pos.Row + d.Row, Col: pos.Col + d.Col}, playerID) {
+			count++
+		}
+	}
+	return count
+}
+
+// cellChar returns the character drawn for a cell: '.' empty, '*' neutral,
+// '#' killed, '$' special, the player digit for normal cells, an uppercase
+// letter for a base (A = player 1) and a lowercase one for fortified cells
+func cellChar(cell protocol.CellType) byte {
+	switch {
+	case cell == protocol.CellEmpty:
+		return '.'
+	case cell == protocol.CellNeutral:
+		return '*'
+	case cell.IsSpecial():
+		return '$'
+	case cell.IsKilled():
+		return '#'
+	}
+
+	player := cell.Player()
+	if player < 1 || player > 9 {
+		return '?'
+	}
+	switch cell.Flag() {
+	case protocol.CellFlagBase:
+		return byte('A' + player - 1)
+	case protocol.CellFlagFortified:
+		return byte('a' + player - 1)
+	}
+	return byte('0' + player)
+}
+
+// RenderWithMoves draws the board with candidate moves overlaid: '+' for a
+// grow and 'x' for an attack target. The first move is the chosen one and is
+// bracketed. Rows may differ in length.
+func (b *Board) RenderWithMoves(moves []Move, playerID int) string {
+	markers := make(map[Position]byte)
+	grows, attacks := 0, 0
+	for _, move := range moves {
+		switch move.Type {
+		case MoveAttack:
+			// An attack marker wins over a grow on the same cell
+			markers[move.Position] = 'x'
+			attacks++
+		default:
+			if markers[move.Position] == 0 {
+				markers[move.Position] = '+'
+			}
+			grows++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Player %d: %d grow, %d attack", playerID, grows, attacks)
+	chosen := Position{Row: -1, Col: -1}
+	if len(moves) > 0 {
+		chosen = moves[0].Position
+		fmt.Fprintf(&sb, ", chosen (%d, %d)", chosen.Row, chosen.Col)
+	}
+	sb.WriteString("\n")
+	b.renderGrid(&sb, markers, chosen)
+
+	return sb.String()
+}
+
+// Render draws the board as a grid with row and column numbers, one
+// character per cell as described at cellChar
+func (b *Board) Render() string {
+	var sb strings.Builder
+	b.renderGrid(&sb, nil, Position{Row: -1, Col: -1})
+	return sb.String()
+}
+
+// renderGrid writes the numbered grid, drawing markers over their cells
+// and bracketing the chosen cell
+func (b *Board) renderGrid(sb *strings.Builder, markers map[Position]byte, chosen Position) {
+	width := 0
+	for _, row := range b.Cells {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+
+	header := "   "
+	for col := 0; col < width; col++ {
+		header += fmt.Sprintf("%2d ", col)
+	}
+	sb.WriteString(strings.TrimRight(header, " ") + "\n")
+
+	for row, cells := range b.Cells {
+		line := fmt.Sprintf("%2d ", row)
+		for col, cell := range cells {
+			pos := Position{Row: row, Col: col}
+			ch := cellChar(cell)
+			if marker, ok := markers[pos]; ok {
+				ch = marker
+			}
+			if pos == chosen {
+				line += fmt.Sprintf("[%c]", ch)
+			} else {
+				line += fmt.Sprintf(" %c ", ch)
+			}
+		}
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
 }
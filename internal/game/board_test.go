@@ -1,6 +1,9 @@
 package game
 
 import (
+	"errors"
+	"math"
+	"strings"
 	"testing"
 
 	"virusbot/internal/protocol"
@@ -9,8 +12,8 @@ import (
 func TestNewBoard(t *testing.T) {
 	board := NewBoard(10)
 
-	if board.Size != 10 {
-		t.Errorf("Expected size 10, got %d", board.Size)
+	if board.Rows != 10 || board.Cols != 10 {
+		t.Errorf("Expected a 10x10 board, got %dx%d", board.Rows, board.Cols)
 	}
 
 	if len(board.Cells) != 10 {
@@ -55,6 +58,106 @@ func TestBoardCellOperations(t *testing.T) {
 	}
 }
 
+func TestPlayerIDsMatchCellValues(t *testing.T) {
+	board := NewBoard(4)
+	for id := 1; id <= 4; id++ {
+		base := Position{Row: id - 1, Col: 0}
+		cell := Position{Row: id - 1, Col: 1}
+		board.BasePos[id] = base
+		board.SetCell(base, protocol.CellType(id|int(protocol.CellFlagBase)))
+		board.SetCell(cell, protocol.CellType(id))
+
+		if got := board.GetCell(cell).Player(); got != id {
+			t.Errorf("CellPlayer%d.Player() = %d", id, got)
+		}
+		if got := board.GetCell(base).Player(); got != id {
+			t.Errorf("Player %d base cell Player() = %d", id, got)
+		}
+	}
+
+	for id := 1; id <= 4; id++ {
+		cell := Position{Row: id - 1, Col: 1}
+		if !board.IsOwnedBy(cell, id) {
+			t.Errorf("Expected player %d to own their cell", id)
+		}
+		if board.IsOwnedBy(cell, id-1) || board.IsOwnedBy(cell, id+1) {
+			t.Errorf("Expected only player %d to own their cell", id)
+		}
+		if got := board.CountCells(id); got != 2 {
+			t.Errorf("CountCells(%d) = %d, want 2", id, got)
+		}
+		if got := len(board.GetReachableCells(id)); got != 2 {
+			t.Errorf("GetReachableCells(%d) has %d cells, want 2", id, got)
+		}
+		if !board.IsConnectedToBase(id, cell) {
+			t.Errorf("Expected player %d's cell to be connected to BasePos[%d]", id, id)
+		}
+	}
+
+	if board.CountCells(0) != 0 || len(board.GetReachableCells(0)) != 0 {
+		t.Error("Expected 0 to be no player")
+	}
+}
+
+func TestOwnershipRespectsFlagBits(t *testing.T) {
+	flagged := func(bits protocol.CellType, flag byte) protocol.CellType {
+		return bits | protocol.CellType(flag)
+	}
+	cases := []struct {
+		name     string
+		cell     protocol.CellType
+		playerID int
+		owned    bool
+	}{
+		{"normal", protocol.CellPlayer1, 1, true},
+		{"base", flagged(protocol.CellPlayer1, protocol.CellFlagBase), 1, true},
+		{"fortified", flagged(protocol.CellPlayer1, protocol.CellFlagFortified), 1, true},
+		{"fortified, other player", flagged(protocol.CellPlayer1, protocol.CellFlagFortified), 2, false},
+		{"killed", flagged(protocol.CellPlayer1, protocol.CellFlagKilled), 1, false},
+		{"killed player 4", flagged(protocol.CellPlayer4, protocol.CellFlagKilled), 4, false},
+		{"fortified neutral", flagged(protocol.CellNeutral, protocol.CellFlagFortified), int(protocol.CellNeutral), false},
+		{"special base", flagged(protocol.CellSpecial, protocol.CellFlagBase), int(protocol.CellSpecial), false},
+		{"fortified without player", flagged(protocol.CellEmpty, protocol.CellFlagFortified), 0, false},
+	}
+	for _, c := range cases {
+		board := NewBoard(2)
+		pos := Position{Row: 1, Col: 1}
+		board.SetCell(pos, c.cell)
+		if got := board.IsOwnedBy(pos, c.playerID); got != c.owned {
+			t.Errorf("%s (%#x): IsOwnedBy(%d) = %v, want %v", c.name, int(c.cell), c.playerID, got, c.owned)
+		}
+		for id := 1; id <= 4; id++ {
+			if board.IsOpponent(pos, id) && c.cell.Flag() != protocol.CellFlagNormal {
+				t.Errorf("%s (%#x): flagged cell is attackable by player %d", c.name, int(c.cell), id)
+			}
+		}
+	}
+}
+
+func TestConnectivityThroughFortifiedNotKilledCells(t *testing.T) {
+	// Row 0: base, fortified, normal, killed, normal; row 1 stays empty
+	board := NewRectBoard(2, 5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagFortified)))
+	board.SetCell(Position{Row: 0, Col: 2}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 0, Col: 3}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagKilled)))
+	board.SetCell(Position{Row: 0, Col: 4}, protocol.CellPlayer1)
+
+	if !board.IsConnectedToBase(1, Position{Row: 0, Col: 2}) {
+		t.Error("Expected the path through the fortified cell to connect (0,2)")
+	}
+	if board.IsConnectedToBase(1, Position{Row: 0, Col: 4}) {
+		t.Error("Expected the killed cell to cut (0,4) off from the base")
+	}
+	if got := len(board.GetReachableCells(1)); got != 3 {
+		t.Errorf("Expected 3 reachable cells, got %d", got)
+	}
+	if got := board.CountCells(1); got != 4 {
+		t.Errorf("Expected 4 owned cells without the killed one, got %d", got)
+	}
+}
+
 func TestBoardIsValid(t *testing.T) {
 	board := NewBoard(5)
 
@@ -79,38 +182,118 @@ func TestBoardIsValid(t *testing.T) {
 }
 
 func TestBoardNeighbors(t *testing.T) {
-	board := NewBoard(5)
-	pos := Position{Row: 2, Col: 2}
+	tests := []struct {
+		name      string
+		adjacency Adjacency
+		expected  []Position
+		corner    int
+	}{
+		{
+			name:      "8-directional by default",
+			adjacency: Adjacency8,
+			expected:  []Position{{1, 2}, {3, 2}, {2, 1}, {2, 3}, {1, 1}, {1, 3}, {3, 1}, {3, 3}},
+			corner:    3,
+		},
+		{
+			name:      "4-directional",
+			adjacency: Adjacency4,
+			expected:  []Position{{1, 2}, {3, 2}, {2, 1}, {2, 3}},
+			corner:    2,
+		},
+	}
 
-	neighbors := board.GetNeighbors(pos)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := NewBoard(5)
+			board.Rules.Adjacency = tt.adjacency
 
-	if len(neighbors) != 4 {
-		t.Errorf("Expected 4 neighbors, got %d", len(neighbors))
-	}
+			neighbors := board.GetNeighbors(Position{Row: 2, Col: 2})
+			if len(neighbors) != len(tt.expected) {
+				t.Errorf("Expected %d neighbors, got %d", len(tt.expected), len(neighbors))
+			}
+
+			// Check all directions
+			for _, exp := range tt.expected {
+				found := false
+				for _, n := range neighbors {
+					if n.Row == exp.Row && n.Col == exp.Col {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("Expected neighbor %v not found", exp)
+				}
+			}
 
-	// Check all directions
-	expected := []Position{
-		{1, 2}, {3, 2}, {2, 1}, {2, 3},
+			// Test corner
+			if n := len(board.GetNeighbors(Position{0, 0})); n != tt.corner {
+				t.Errorf("Expected %d neighbors for corner, got %d", tt.corner, n)
+			}
+		})
 	}
+}
+
+func TestAppendNeighborsMatchesGetNeighbors(t *testing.T) {
+	board := NewBoard(4)
+	buf := make([]Position, 0, 8)
 
-	for _, exp := range expected {
-		found := false
-		for _, n := range neighbors {
-			if n.Row == exp.Row && n.Col == exp.Col {
-				found = true
-				break
+	for row := 0; row < board.Rows; row++ {
+		for col := 0; col < board.Cols; col++ {
+			pos := Position{Row: row, Col: col}
+			want := board.GetNeighbors(pos)
+			buf = board.AppendNeighbors(buf[:0], pos)
+
+			if len(buf) != len(want) {
+				t.Fatalf("%v: expected %d neighbors, got %d", pos, len(want), len(buf))
+			}
+			for i := range want {
+				if buf[i] != want[i] {
+					t.Errorf("%v: neighbor %d is %v, want %v", pos, i, buf[i], want[i])
+				}
 			}
 		}
-		if !found {
-			t.Errorf("Expected neighbor %v not found", exp)
-		}
 	}
 
-	// Test corner
-	corner := Position{0, 0}
-	cornerNeighbors := board.GetNeighbors(corner)
-	if len(cornerNeighbors) != 2 {
-		t.Errorf("Expected 2 neighbors for corner, got %d", len(cornerNeighbors))
+	// Existing entries in dst are kept
+	prefix := []Position{{Row: 9, Col: 9}}
+	if got := board.AppendNeighbors(prefix, Position{Row: 0, Col: 0}); got[0] != prefix[0] || len(got) != 1+len(board.GetNeighbors(Position{Row: 0, Col: 0})) {
+		t.Errorf("Expected neighbors appended after existing entries, got %v", got)
+	}
+}
+
+// neighborSink keeps benchmark results alive so they escape like they do
+// when passed through non-inlined callers
+var neighborSink []Position
+
+func BenchmarkGetNeighbors(b *testing.B) {
+	board := NewBoard(10)
+	pos := Position{Row: 5, Col: 5}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		neighborSink = board.GetNeighbors(pos)
+	}
+}
+
+func BenchmarkAppendNeighbors(b *testing.B) {
+	board := NewBoard(10)
+	pos := Position{Row: 5, Col: 5}
+	buf := make([]Position, 0, 8)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = board.AppendNeighbors(buf[:0], pos)
+	}
+	neighborSink = buf
+}
+
+func BenchmarkGetReachableCells(b *testing.B) {
+	board := GenerateBoard(20, 0.4, 1)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		board.GetReachableCells(1)
 	}
 }
 
@@ -174,3 +357,335 @@ func TestBoardIsCornerPosition(t *testing.T) {
 		}
 	}
 }
+
+func TestRectBoardRespectsBothDimensions(t *testing.T) {
+	board := NewRectBoard(10, 15)
+
+	cases := []struct {
+		pos                 Position
+		valid, edge, corner bool
+	}{
+		{Position{Row: 9, Col: 14}, true, true, true},
+		{Position{Row: 0, Col: 14}, true, true, true},
+		{Position{Row: 9, Col: 9}, true, true, false},
+		{Position{Row: 5, Col: 12}, true, false, false},
+		{Position{Row: 10, Col: 5}, false, false, false},
+	}
+	for _, c := range cases {
+		if got := board.IsValid(c.pos); got != c.valid {
+			t.Errorf("IsValid(%v) = %v, want %v", c.pos, got, c.valid)
+		}
+		if got := board.IsEdgePosition(c.pos); c.valid && got != c.edge {
+			t.Errorf("IsEdgePosition(%v) = %v, want %v", c.pos, got, c.edge)
+		}
+		if got := board.IsCornerPosition(c.pos); c.valid && got != c.corner {
+			t.Errorf("IsCornerPosition(%v) = %v, want %v", c.pos, got, c.corner)
+		}
+	}
+
+	if empty := board.GetEmptyCells(); len(empty) != 150 {
+		t.Errorf("Expected 150 empty cells, got %d", len(empty))
+	}
+	if moves := board.GetValidMoves(1); len(moves) != 150 || moves[len(moves)-1].Position != (Position{Row: 9, Col: 14}) {
+		t.Errorf("Expected a first move on each of the 150 cells, got %d", len(moves))
+	}
+
+	// Only the flips and the half turn map a 10x15 grid onto itself
+	if syms := board.Symmetries(); len(syms) != 3 {
+		t.Errorf("Expected 3 symmetries of the empty board, got %v", syms)
+	}
+
+	board.BasePos[1] = Position{Row: 9, Col: 14}
+	board.SetCell(board.BasePos[1], protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	if moves := board.GetValidMoves(1); len(moves) != 3 {
+		t.Errorf("Expected 3 moves around the far corner base, got %v", moves)
+	}
+}
+
+func TestEnclosedEmptyCells(t *testing.T) {
+	board := NewBoard(5)
+
+	// Player 1 rings the pocket at (1,1)
+	for r := 0; r <= 2; r++ {
+		for c := 0; c <= 2; c++ {
+			if r == 1 && c == 1 {
+				continue
+			}
+			board.SetCell(Position{Row: r, Col: c}, protocol.CellPlayer1)
+		}
+	}
+
+	// The rest of the board is open and touches player 2
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellPlayer2)
+
+	enclosed := board.EnclosedEmptyCells(1)
+	if len(enclosed) != 1 {
+		t.Fatalf("Expected 1 enclosed cell, got %d: %v", len(enclosed), enclosed)
+	}
+	if enclosed[0] != (Position{Row: 1, Col: 1}) {
+		t.Errorf("Expected enclosed cell at (1,1), got %v", enclosed[0])
+	}
+
+	// From player 2's point of view nothing is enclosed
+	if cells := board.EnclosedEmptyCells(2); len(cells) != 0 {
+		t.Errorf("Expected no enclosed cells for player 2, got %v", cells)
+	}
+}
+
+func TestSpecialCells(t *testing.T) {
+	board := NewBoard(3)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellPlayer1)
+	special := Position{Row: 0, Col: 1}
+	board.SetCell(special, protocol.CellSpecial)
+
+	if !board.IsSpecial(special) {
+		t.Error("Expected (0,1) to be special")
+	}
+	if board.IsSpecial(Position{Row: 1, Col: 1}) {
+		t.Error("Expected empty cell not to be special")
+	}
+	if board.IsOpponent(special, 1) || board.IsEmpty(special) {
+		t.Error("Special cell must be neither an opponent cell nor empty")
+	}
+
+	found := false
+	for _, move := range board.GetValidMoves(1) {
+		if move.Position == special {
+			found = true
+			if move.Type != MoveGrow {
+				t.Errorf("Expected special cell to be captured with a grow, got %v", move.Type)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected a move into the special cell")
+	}
+}
+
+func TestInternalHoles(t *testing.T) {
+	board := NewBoard(5)
+
+	// Ring around (2,2); the edge pocket at (0,0) is enclosed but not internal
+	for r := 1; r <= 3; r++ {
+		for c := 1; c <= 3; c++ {
+			if r == 2 && c == 2 {
+				continue
+			}
+			board.SetCell(Position{Row: r, Col: c}, protocol.CellPlayer1)
+		}
+	}
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 1, Col: 0}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellPlayer2)
+
+	if holes := board.InternalHoles(1); holes != 1 {
+		t.Errorf("Expected 1 internal hole, got %d", holes)
+	}
+	if holes := board.InternalHoles(2); holes != 0 {
+		t.Errorf("Expected no internal holes for player 2, got %d", holes)
+	}
+}
+
+func TestCompactness(t *testing.T) {
+	blob := NewBoard(6)
+	for _, pos := range []Position{{Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 2, Col: 1}, {Row: 2, Col: 2}} {
+		blob.SetCell(pos, protocol.CellPlayer1)
+	}
+
+	chain := NewBoard(6)
+	for c := 1; c <= 4; c++ {
+		chain.SetCell(Position{Row: 1, Col: c}, protocol.CellPlayer1)
+	}
+
+	if blob.CountCells(1) != chain.CountCells(1) {
+		t.Fatalf("Expected equal cell counts, got %d and %d", blob.CountCells(1), chain.CountCells(1))
+	}
+	if blob.Compactness(1) <= chain.Compactness(1) {
+		t.Errorf("Expected blob (%.3f) to be more compact than chain (%.3f)", blob.Compactness(1), chain.Compactness(1))
+	}
+	if c := NewBoard(3).Compactness(1); c != 0 {
+		t.Errorf("Expected 0 compactness without cells, got %.3f", c)
+	}
+}
+
+func TestDuplicateBasesAreReportedAndRepaired(t *testing.T) {
+	cells := NewBoard(5).Cells
+	cells[0][0] = protocol.CellType(int(protocol.CellPlayer1) | int(protocol.CellFlagBase))
+	cells[4][4] = protocol.CellType(int(protocol.CellPlayer2) | int(protocol.CellFlagBase))
+	cells[4][3] = protocol.CellPlayer2
+	bases := map[int]Position{1: {Row: 0, Col: 0}, 2: {Row: 0, Col: 0}, 3: {Row: 9, Col: 9}}
+
+	raw := &Board{Rows: 5, Cols: 5, Cells: cells, BasePos: bases}
+	err := raw.ValidateBases()
+	if !errors.Is(err, ErrInvalidBase) {
+		t.Fatalf("Expected ErrInvalidBase, got %v", err)
+	}
+	for _, want := range []string{"player 2", "player 3"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %q", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "player 1 base") {
+		t.Errorf("Player 1 owns the shared cell and should keep it: %q", err)
+	}
+
+	board := NewBoardFromData(cells, bases)
+	if got := board.BasePos[1]; got != (Position{Row: 0, Col: 0}) {
+		t.Errorf("Expected player 1 to keep (0,0), got %v", got)
+	}
+	if got := board.BasePos[2]; got != (Position{Row: 4, Col: 4}) {
+		t.Errorf("Expected player 2's base moved to its flagged cell, got %v", got)
+	}
+	if _, ok := board.BasePos[3]; ok {
+		t.Error("Expected the off-board base of a player without cells to be dropped")
+	}
+	if err := board.ValidateBases(); err != nil {
+		t.Errorf("Expected repaired bases to validate, got %v", err)
+	}
+	if !board.IsConnectedToBase(2, Position{Row: 4, Col: 3}) {
+		t.Error("Expected player 2's territory connected after the repair")
+	}
+}
+
+func TestPerimeterLength(t *testing.T) {
+	square := NewBoard(7)
+	for r := 2; r <= 4; r++ {
+		for c := 2; c <= 4; c++ {
+			square.SetCell(Position{Row: r, Col: c}, protocol.CellPlayer1)
+		}
+	}
+
+	cross := NewBoard(7)
+	for i := 1; i <= 5; i++ {
+		cross.SetCell(Position{Row: 3, Col: i}, protocol.CellPlayer1)
+		cross.SetCell(Position{Row: i, Col: 3}, protocol.CellPlayer1)
+	}
+
+	if square.CountCells(1) != cross.CountCells(1) {
+		t.Fatalf("Expected equal areas, got %d and %d", square.CountCells(1), cross.CountCells(1))
+	}
+	if got := square.PerimeterLength(1); got != 8 {
+		t.Errorf("Expected the 3x3 square to have 8 perimeter cells, got %d", got)
+	}
+	if square.PerimeterLength(1) >= cross.PerimeterLength(1) {
+		t.Errorf("Expected the cross (%d) to have a longer perimeter than the square (%d)",
+			cross.PerimeterLength(1), square.PerimeterLength(1))
+	}
+
+	// Filling the square's neighbor grows the perimeter by the new cell only
+	// when it does not enclose any existing perimeter cell
+	if got := square.perimeterDelta(Position{Row: 3, Col: 5}, 1); got != 1 {
+		t.Errorf("Expected perimeter delta 1 for a cell beside the square, got %d", got)
+	}
+}
+
+func TestSymmetryGroups(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 4, Col: 4}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+
+	symmetries := board.Symmetries()
+	if len(symmetries) != 1 || symmetries[0] != SymTranspose {
+		t.Fatalf("Expected only the diagonal symmetry, got %v", symmetries)
+	}
+
+	moves := board.GetValidMoves(1)
+	groups := board.SymmetryGroups(moves)
+	if len(groups) >= len(moves) {
+		t.Errorf("Expected mirror moves to be grouped, got %d groups for %d moves", len(groups), len(moves))
+	}
+	for _, group := range groups {
+		for _, move := range group[1:] {
+			if board.Transform(move.Position, SymTranspose) != group[0].Position && move.Position != group[0].Position {
+				t.Errorf("Move %v grouped with non-mirror %v", move.Position, group[0].Position)
+			}
+		}
+	}
+
+	// Breaking the symmetry disables grouping
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+	if len(board.Symmetries()) != 0 {
+		t.Error("Expected no symmetries after an asymmetric move")
+	}
+	moves = board.GetValidMoves(1)
+	targets := make(map[Position]bool)
+	for _, move := range moves {
+		targets[move.Position] = true
+	}
+	if groups := board.SymmetryGroups(moves); len(groups) != len(targets) {
+		t.Errorf("Expected one group per target on an asymmetric board, got %d groups for %d targets", len(groups), len(targets))
+	}
+}
+
+func TestRenderWithMoves(t *testing.T) {
+	board := NewBoard(3)
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(1|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 1, Col: 1}, protocol.CellPlayer2)
+	board.SetCell(Position{Row: 2, Col: 2}, protocol.CellType(2|int(protocol.CellFlagFortified)))
+	board.SetCell(Position{Row: 2, Col: 0}, protocol.CellNeutral)
+	// Make the board rectangular: the last row is one cell longer
+	board.Cells[2] = append(board.Cells[2], protocol.CellEmpty)
+
+	moves := []Move{
+		{Position: Position{Row: 0, Col: 2}, Type: MoveGrow, FromCell: Position{Row: 0, Col: 1}},
+		{Position: Position{Row: 1, Col: 1}, Type: MoveAttack, FromCell: Position{Row: 0, Col: 1}},
+		{Position: Position{Row: 1, Col: 0}, Type: MoveGrow, FromCell: Position{Row: 0, Col: 0}},
+		{Position: Position{Row: 1, Col: 0}, Type: MoveGrow, FromCell: Position{Row: 0, Col: 1}},
+	}
+
+	expected := "Player 1: 3 grow, 1 attack, chosen (0, 2)\n" +
+		"    0  1  2  3\n" +
+		" 0  A  1 [+]\n" +
+		" 1  +  x  .\n" +
+		" 2  *  .  b  .\n"
+	if got := board.RenderWithMoves(moves, 1); got != expected {
+		t.Errorf("Unexpected render:\n%s\nwant:\n%s", got, expected)
+	}
+}
+
+func TestRender(t *testing.T) {
+	board := NewBoard(4)
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(1|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 1, Col: 1}, protocol.CellType(1|int(protocol.CellFlagFortified)))
+	board.SetCell(Position{Row: 1, Col: 2}, protocol.CellNeutral)
+	board.SetCell(Position{Row: 2, Col: 2}, protocol.CellPlayer3)
+	board.SetCell(Position{Row: 3, Col: 0}, protocol.CellType(4|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 3, Col: 3}, protocol.CellType(2|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 2, Col: 3}, protocol.CellPlayer2)
+
+	expected := "    0  1  2  3\n" +
+		" 0  A  1  .  .\n" +
+		" 1  .  a  *  .\n" +
+		" 2  .  .  3  2\n" +
+		" 3  D  .  .  B\n"
+	if got := board.Render(); got != expected {
+		t.Errorf("Unexpected render:\n%s\nwant:\n%s", got, expected)
+	}
+}
+
+func TestGenerateBoardIsConnectedAndFilled(t *testing.T) {
+	for _, fill := range []float64{0.2, 0.5} {
+		board := GenerateBoard(12, fill, 7)
+
+		owned := 0
+		for id := 1; id <= 2; id++ {
+			cells := board.GetPlayerCells(id)
+			owned += len(cells)
+			if reachable := board.GetReachableCells(id); len(reachable) != len(cells) {
+				t.Errorf("fill %.1f: player %d has %d cells but only %d reach the base", fill, id, len(cells), len(reachable))
+			}
+		}
+		if got := float64(owned) / float64(12*12); math.Abs(got-fill) > 0.02 {
+			t.Errorf("Expected a fill ratio near %.2f, got %.2f", fill, got)
+		}
+	}
+
+	if a, b := GenerateBoard(12, 0.5, 7), GenerateBoard(12, 0.5, 7); a.Hash() != b.Hash() {
+		t.Error("Expected the same seed to generate the same board")
+	}
+}
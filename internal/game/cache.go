@@ -0,0 +1,164 @@
+package game
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// cacheKey identifies a cached query. The player ID is part of the key:
+// the same board yields different moves for every player.
+type cacheKey struct {
+	hash     uint64
+	playerID int
+}
+
+// MoveCache memoizes per-player board queries during search, where the same
+// positions are reached many times. Returned slices are shared between
+// callers and must not be modified. A nil cache computes every query.
+type MoveCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	reachable  map[cacheKey][]Position
+	moves      map[cacheKey][]Move
+	distances  map[cacheKey][][]int
+}
+
+// NewMoveCache creates a cache holding up to maxEntries results per query
+// type; a full table is cleared rather than evicted entry by entry
+func NewMoveCache(maxEntries int) *MoveCache {
+	return &MoveCache{
+		maxEntries: maxEntries,
+		reachable:  make(map[cacheKey][]Position),
+		moves:      make(map[cacheKey][]Move),
+		distances:  make(map[cacheKey][][]int),
+	}
+}
+
+// ReachableCells returns Board.GetReachableCells, cached per board and player
+func (c *MoveCache) ReachableCells(b *Board, playerID int) []Position {
+	if c == nil {
+		return b.GetReachableCells(playerID)
+	}
+	key := cacheKey{hash: b.Hash(), playerID: playerID}
+
+	c.mu.Lock()
+	cells, ok := c.reachable[key]
+	c.mu.Unlock()
+	if ok {
+		return cells
+	}
+
+	cells = b.GetReachableCells(playerID)
+
+	c.mu.Lock()
+	if len(c.reachable) >= c.maxEntries {
+		c.reachable = make(map[cacheKey][]Position)
+	}
+	c.reachable[key] = cells
+	c.mu.Unlock()
+	return cells
+}
+
+// ValidMoves returns Board.GetValidMoves, cached per board and player
+func (c *MoveCache) ValidMoves(b *Board, playerID int) []Move {
+	if c == nil {
+		return b.GetValidMoves(playerID)
+	}
+	key := cacheKey{hash: b.Hash(), playerID: playerID}
+
+	c.mu.Lock()
+	moves, ok := c.moves[key]
+	c.mu.Unlock()
+	if ok {
+		return moves
+	}
+
+	moves = b.GetValidMoves(playerID)
+
+	c.mu.Lock()
+	if len(c.moves) >= c.maxEntries {
+		c.moves = make(map[cacheKey][]Move)
+	}
+	c.moves[key] = moves
+	c.mu.Unlock()
+	return moves
+}
+
+// Len returns the number of cached results across all query types
+func (c *MoveCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.reachable) + len(c.moves) + len(c.distances)
+}
+
+// OpponentBaseDistances returns the distance map from every base except the
+// player's own, cached per board and player
+func (c *MoveCache) OpponentBaseDistances(b *Board, playerID int) [][]int {
+	if c == nil {
+		return b.opponentBaseDistances(playerID)
+	}
+	key := cacheKey{hash: b.Hash(), playerID: playerID}
+
+	c.mu.Lock()
+	dist, ok := c.distances[key]
+	c.mu.Unlock()
+	if ok {
+		return dist
+	}
+
+	dist = b.opponentBaseDistances(playerID)
+
+	c.mu.Lock()
+	if len(c.distances) >= c.maxEntries {
+		c.distances = make(map[cacheKey][][]int)
+	}
+	c.distances[key] = dist
+	c.mu.Unlock()
+	return dist
+}
+
+// opponentBaseDistances maps the distance from every base but the player's
+func (b *Board) opponentBaseDistances(playerID int) [][]int {
+	bases := make([]Position, 0, len(b.BasePos))
+	for id, pos := range b.BasePos {
+		if id != playerID {
+			bases = append(bases, pos)
+		}
+	}
+	return b.DistanceMap(bases)
+}
+
+// Hash returns a hash of the board's cells, base positions and rules. The
+// rules decide the moves, so boards differing only in rules hash apart.
+func (b *Board) Hash() uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 0, b.Rows*b.Cols+3*len(b.BasePos)+5)
+	attackable := byte(0)
+	if b.Rules.BasesAttackable {
+		attackable = 1
+	}
+	buf = append(buf, byte(b.Rows), byte(b.Cols), byte(b.Rules.Adjacency), byte(b.Rules.MovesPerTurn), attackable)
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
+			buf = append(buf, byte(b.Cells[row][col]))
+		}
+	}
+
+	// Map iteration order is random, so hash bases in player order
+	ids := make([]int, 0, len(b.BasePos))
+	for id := range b.BasePos {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		pos := b.BasePos[id]
+		buf = append(buf, byte(id), byte(pos.Row), byte(pos.Col))
+	}
+
+	h.Write(buf)
+	return h.Sum64()
+}
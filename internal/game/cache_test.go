@@ -0,0 +1,71 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	"virusbot/internal/protocol"
+)
+
+func TestMoveCacheKeyedByPlayer(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 4, Col: 4}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellPlayer2)
+	board.SetCell(Position{Row: 3, Col: 4}, protocol.CellPlayer2)
+
+	want1 := board.GetValidMoves(1)
+	want2 := board.GetValidMoves(2)
+	if reflect.DeepEqual(want1, want2) {
+		t.Fatal("Test board must give the players different moves")
+	}
+
+	cache := NewMoveCache(16)
+	for i := 0; i < 3; i++ {
+		if got := cache.ValidMoves(board, 1); !reflect.DeepEqual(got, want1) {
+			t.Errorf("Round %d: player 1 moves = %v, want %v", i, got, want1)
+		}
+		if got := cache.ValidMoves(board, 2); !reflect.DeepEqual(got, want2) {
+			t.Errorf("Round %d: player 2 moves = %v, want %v", i, got, want2)
+		}
+		if got, want := cache.ReachableCells(board, 2), board.GetReachableCells(2); !reflect.DeepEqual(got, want) {
+			t.Errorf("Round %d: player 2 reachable = %v, want %v", i, got, want)
+		}
+		if got, want := cache.OpponentBaseDistances(board, 1), board.opponentBaseDistances(1); !reflect.DeepEqual(got, want) {
+			t.Errorf("Round %d: player 1 distances differ from uncached", i)
+		}
+	}
+
+	// A changed board must not hit the old entry
+	board.SetCell(Position{Row: 1, Col: 1}, protocol.CellPlayer1)
+	if got, want := cache.ValidMoves(board, 1), board.GetValidMoves(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("After change: player 1 moves = %v, want %v", got, want)
+	}
+
+	var nilCache *MoveCache
+	if got := nilCache.ValidMoves(board, 2); !reflect.DeepEqual(got, board.GetValidMoves(2)) {
+		t.Errorf("Nil cache returned %v", got)
+	}
+}
+
+func TestMoveCacheKeyedByRules(t *testing.T) {
+	eight := NewBoard(5)
+	eight.BasePos[1] = Position{Row: 2, Col: 2}
+	eight.SetCell(Position{Row: 2, Col: 2}, protocol.CellPlayer1)
+	four := eight.Clone()
+	four.Rules.Adjacency = Adjacency4
+
+	if eight.Hash() == four.Hash() {
+		t.Fatal("Expected boards with different rules to hash apart")
+	}
+
+	cache := NewMoveCache(16)
+	if got := len(cache.ValidMoves(eight, 1)); got != 8 {
+		t.Fatalf("Expected 8 moves with 8-adjacency, got %d", got)
+	}
+	if got := len(cache.ValidMoves(four, 1)); got != 4 {
+		t.Errorf("Expected 4 moves with 4-adjacency, got %d", got)
+	}
+}
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"math/rand"
+
+	"virusbot/internal/protocol"
+)
+
+// baseCorners are the standard starting corners in player order: top-left,
+// bottom-right, top-right, bottom-left
+func baseCorners(size int) []Position {
+	last := size - 1
+	return []Position{{Row: 0, Col: 0}, {Row: last, Col: last}, {Row: 0, Col: last}, {Row: last, Col: 0}}
+}
+
+// GenerateBoard builds a two-player position for tests and benchmarks; see
+// GenerateBoardN
+func GenerateBoard(size int, fillRatio float64, seed int64) *Board {
+	return GenerateBoardN(size, 2, fillRatio, seed)
+}
+
+// GenerateBoardN builds a deterministic position with players (1-4) bases
+// in the standard corners, growing each territory by one random empty
+// neighbor per player in turn until fillRatio of the board is owned or no
+// one can grow. Territories only grow, so every cell stays base-connected.
+func GenerateBoardN(size, players int, fillRatio float64, seed int64) *Board {
+	board := NewBoard(size)
+	rng := rand.New(rand.NewSource(seed))
+	players = min(max(players, 1), len(baseCorners(size)))
+
+	owned := make([][]Position, players+1)
+	for id := 1; id <= players; id++ {
+		base := baseCorners(size)[id-1]
+		board.BasePos[id] = base
+		board.SetCell(base, protocol.CellType(id|int(protocol.CellFlagBase)))
+		owned[id] = []Position{base}
+	}
+
+	target := int(fillRatio * float64(size*size))
+	filled := players
+	var neighbors, empty [8]Position
+	for filled < target {
+		grew := false
+		for id := 1; id <= players && filled < target; id++ {
+			// Try the player's cells in random order for an empty neighbor
+			for _, i := range rng.Perm(len(owned[id])) {
+				candidates := empty[:0]
+				for _, n := range board.AppendNeighbors(neighbors[:0], owned[id][i]) {
+					if board.IsEmpty(n) {
+						candidates = append(candidates, n)
+					}
+				}
+				if len(candidates) == 0 {
+					continue
+				}
+				pos := candidates[rng.Intn(len(candidates))]
+				board.SetCell(pos, protocol.CellType(id))
+				owned[id] = append(owned[id], pos)
+				filled++
+				grew = true
+				break
+			}
+		}
+		if !grew {
+			break
+		}
+	}
+
+	return board
+}
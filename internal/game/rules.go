@@ -10,13 +10,62 @@ type MoveType int
 const (
 	MoveGrow MoveType = iota
 	MoveAttack
+	MoveNeutral // places neutrals on Position and Pair, ending the turn
+	MoveFortify // fortifies one of our own normal cells
 )
 
+// Adjacency is the neighborhood rule deciding which cells touch
+type Adjacency int
+
+const (
+	Adjacency8 Adjacency = iota // orthogonal and diagonal neighbors
+	Adjacency4                  // orthogonal neighbors only
+)
+
+// defaultMovesPerTurn is the number of moves per turn in the standard rules
+const defaultMovesPerTurn = 3
+
+// GameConfig holds the rule variant a game is played with. The zero value
+// is the standard game.
+type GameConfig struct {
+	MovesPerTurn    int
+	Adjacency       Adjacency
+	BasesAttackable bool
+}
+
+// GameConfigFromRules converts the rules announced in game_start
+func GameConfigFromRules(rules protocol.GameRules) GameConfig {
+	cfg := GameConfig{
+		MovesPerTurn:    rules.MovesPerTurn,
+		BasesAttackable: rules.BasesAttackable,
+	}
+	if rules.Adjacency == 4 {
+		cfg.Adjacency = Adjacency4
+	}
+	return cfg
+}
+
+// TurnLength returns the number of moves per turn, defaulting to the
+// standard rules when the game did not announce it
+func (c GameConfig) TurnLength() int {
+	if c.MovesPerTurn > 0 {
+		return c.MovesPerTurn
+	}
+	return defaultMovesPerTurn
+}
+
 // Move represents a potential move
 type Move struct {
 	Position Position
 	Type     MoveType
 	FromCell Position // The cell we're expanding from
+	Pair     Position // The second cell of a neutral placement
+}
+
+// Fortifies reports whether the move leaves a fortified cell: attacks and
+// fortify moves do
+func (m Move) Fortifies() bool {
+	return m.Type == MoveAttack || m.Type == MoveFortify
 }
 
 // ValidMove checks if a move is legal for a player
@@ -26,6 +75,24 @@ func ValidMove(board *Board, playerID int, move Move) bool {
 		return false
 	}
 
+	// Neutrals go on two distinct cells of our own, connected or not
+	if move.Type == MoveNeutral {
+		return board.CanPlaceNeutrals(playerID) && move.Position != move.Pair &&
+			board.IsOwnedBy(move.Position, playerID) && board.IsOwnedBy(move.Pair, playerID)
+	}
+
+	// Only a normal cell of our own that is connected to base can be fortified
+	if move.Type == MoveFortify {
+		cell := board.GetCell(move.Position)
+		return cell.Player() == playerID && cell.Flag() == protocol.CellFlagNormal &&
+			board.IsConnectedToBase(playerID, move.Position)
+	}
+
+	// Neutral and killed cells can never be targeted
+	if board.IsObstacle(move.Position) {
+		return false
+	}
+
 	// Check if the move originates from a cell connected to base
 	if !board.IsConnectedToBase(playerID, move.FromCell) {
 		return false
@@ -34,8 +101,8 @@ func ValidMove(board *Board, playerID int, move Move) bool {
 	// Check the move type
 	switch move.Type {
 	case MoveGrow:
-		// Must be growing into an empty cell
-		return board.IsEmpty(move.Position) && board.IsAdjacent(move.FromCell, move.Position)
+		// Must be growing into an empty (or special) cell
+		return board.IsGrowable(move.Position) && board.IsAdjacent(move.FromCell, move.Position)
 	case MoveAttack:
 		// Must be attacking an opponent's cell
 		return board.IsOpponent(move.Position, playerID) && board.IsAdjacent(move.FromCell, move.Position)
@@ -44,10 +111,14 @@ func ValidMove(board *Board, playerID int, move Move) bool {
 	return false
 }
 
-// IsAdjacent checks if two positions are adjacent (8-directional: includes diagonals)
+// IsAdjacent checks if two positions are adjacent (8-directional: includes
+// diagonals, unless the game uses 4-directional adjacency)
 func (b *Board) IsAdjacent(pos1, pos2 Position) bool {
 	dr := abs(pos1.Row - pos2.Row)
 	dc := abs(pos1.Col - pos2.Col)
+	if b.Rules.Adjacency == Adjacency4 {
+		return dr+dc == 1
+	}
 	// Adjacent if distance is at most 1 in both directions (allows diagonals)
 	return dr <= 1 && dc <= 1 && (dr != 0 || dc != 0)
 }
@@ -63,6 +134,7 @@ func (b *Board) IsConnectedToBase(playerID int, pos Position) bool {
 	// Use BFS to check if pos is connected to base through player's cells
 	visited := make(map[Position]bool)
 	queue := []Position{basePos}
+	neighbors := make([]Position, 0, 8)
 
 	for len(queue) > 0 {
 		current := queue[0]
@@ -75,7 +147,8 @@ func (b *Board) IsConnectedToBase(playerID int, pos Position) bool {
 		visited[current] = true
 
 		// Check all player's cells adjacent to current
-		for _, neighbor := range b.GetNeighbors(current) {
+		neighbors = b.AppendNeighbors(neighbors[:0], current)
+		for _, neighbor := range neighbors {
 			if visited[neighbor] {
 				continue
 			}
@@ -110,6 +183,7 @@ func (b *Board) GetReachableCells(playerID int) []Position {
 	reachable := make([]Position, 0)
 	visited := make(map[Position]bool)
 	queue := []Position{basePos}
+	neighbors := make([]Position, 0, 8)
 
 	for len(queue) > 0 {
 		current := queue[0]
@@ -122,7 +196,8 @@ func (b *Board) GetReachableCells(playerID int) []Position {
 		reachable = append(reachable, current)
 
 		// Check all player's cells adjacent to current
-		for _, neighbor := range b.GetNeighbors(current) {
+		neighbors = b.AppendNeighbors(neighbors[:0], current)
+		for _, neighbor := range neighbors {
 			if !visited[neighbor] && b.IsOwnedBy(neighbor, playerID) {
 				queue = append(queue, neighbor)
 			}
@@ -132,6 +207,22 @@ func (b *Board) GetReachableCells(playerID int) []Position {
 	return reachable
 }
 
+// NearestConnectedCell returns an owned, base-connected cell adjacent to the
+// target to use as a move's FromCell, or false if there is none
+func (b *Board) NearestConnectedCell(target Position, playerID int) (Position, bool) {
+	reachable := make(map[Position]bool)
+	for _, pos := range b.GetReachableCells(playerID) {
+		reachable[pos] = true
+	}
+
+	for _, neighbor := range b.GetNeighbors(target) {
+		if reachable[neighbor] {
+			return neighbor, true
+		}
+	}
+	return Position{}, false
+}
+
 // GetValidMoves returns all valid moves for a player
 func (b *Board) GetValidMoves(playerID int) []Move {
 	moves := make([]Move, 0)
@@ -142,8 +233,8 @@ func (b *Board) GetValidMoves(playerID int) []Move {
 	// Special case: if player has no cells yet (first move), they can place anywhere
 	if len(reachableCells) == 0 {
 		// First move: can place on any empty cell
-		for row := 0; row < b.Size; row++ {
-			for col := 0; col < b.Size; col++ {
+		for row := 0; row < b.Rows; row++ {
+			for col := 0; col < b.Cols; col++ {
 				pos := Position{Row: row, Col: col}
 				if b.IsEmpty(pos) {
 					moves = append(moves, Move{
@@ -160,13 +251,19 @@ func (b *Board) GetValidMoves(playerID int) []Move {
 	for _, fromCell := range reachableCells {
 		// Check all neighbors for potential moves
 		for _, neighbor := range b.GetNeighbors(fromCell) {
+			// Neutral and killed cells are never targets, whatever
+			// player bits a killed cell still carries
+			if b.IsObstacle(neighbor) {
+				continue
+			}
+
 			// Skip if this is one of our own cells
 			if b.IsOwnedBy(neighbor, playerID) {
 				continue
 			}
 
-			// Check for grow move (into empty cell)
-			if b.IsEmpty(neighbor) {
+			// Check for grow move (into empty or special cell)
+			if b.IsGrowable(neighbor) {
 				moves = append(moves, Move{
 					Position: neighbor,
 					Type:     MoveGrow,
@@ -188,6 +285,68 @@ func (b *Board) GetValidMoves(playerID int) []Move {
 	return moves
 }
 
+// MobilityByPlayer returns the number of distinct cells each player could
+// target, walking each player's territory once without building moves
+func (b *Board) MobilityByPlayer(playerIDs []int) map[int]int {
+	mobility := make(map[int]int, len(playerIDs))
+	for _, id := range playerIDs {
+		seen := make(map[Position]bool)
+		b.visitTargets(id, func(pos Position) bool {
+			seen[pos] = true
+			return true
+		})
+		mobility[id] = len(seen)
+	}
+	return mobility
+}
+
+// AnyPlayerCanMove reports whether at least one of the players has a legal
+// move, stopping at the first target found
+func (b *Board) AnyPlayerCanMove(playerIDs []int) bool {
+	for _, id := range playerIDs {
+		found := false
+		b.visitTargets(id, func(Position) bool {
+			found = true
+			return false
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// visitTargets calls visit for every cell GetValidMoves would target, a cell
+// possibly more than once, until visit returns false
+func (b *Board) visitTargets(playerID int, visit func(Position) bool) {
+	reachableCells := b.GetReachableCells(playerID)
+
+	// Without cells the first placement may go on any empty cell
+	if len(reachableCells) == 0 {
+		for row := 0; row < b.Rows; row++ {
+			for col := 0; col < b.Cols; col++ {
+				pos := Position{Row: row, Col: col}
+				if b.IsEmpty(pos) && !visit(pos) {
+					return
+				}
+			}
+		}
+		return
+	}
+
+	var buf [8]Position
+	for _, fromCell := range reachableCells {
+		for _, neighbor := range b.AppendNeighbors(buf[:0], fromCell) {
+			if b.IsObstacle(neighbor) || b.IsOwnedBy(neighbor, playerID) {
+				continue
+			}
+			if (b.IsGrowable(neighbor) || b.IsOpponent(neighbor, playerID)) && !visit(neighbor) {
+				return
+			}
+		}
+	}
+}
+
 // GetAttackMoves returns only attack moves
 func (b *Board) GetAttackMoves(playerID int) []Move {
 	moves := b.GetValidMoves(playerID)
@@ -200,6 +359,19 @@ func (b *Board) GetAttackMoves(playerID int) []Move {
 	return attacks
 }
 
+// GetAttackableCells returns the distinct cells the player could attack now
+func (b *Board) GetAttackableCells(playerID int) []Position {
+	seen := make(map[Position]bool)
+	cells := make([]Position, 0)
+	for _, move := range b.GetAttackMoves(playerID) {
+		if !seen[move.Position] {
+			seen[move.Position] = true
+			cells = append(cells, move.Position)
+		}
+	}
+	return cells
+}
+
 // GetGrowMoves returns only grow moves
 func (b *Board) GetGrowMoves(playerID int) []Move {
 	moves := b.GetValidMoves(playerID)
@@ -214,23 +386,44 @@ func (b *Board) GetGrowMoves(playerID int) []Move {
 
 // CanPlaceNeutrals checks if the player can place neutral cells
 func (b *Board) CanPlaceNeutrals(playerID int) bool {
-	// Count non-fortified cells (cells that are owned but not the base)
-	// In this game, we can place neutrals on any of our cells
-	// The rule says "at least two non-fortified cells"
+	// The rule says "at least two non-fortified cells", not counting the base
+	return len(b.GetNeutralPositions(playerID)) >= 2
+}
 
-	// For now, we check if player has at least 2 cells total
-	cells := b.GetPlayerCells(playerID)
-	return len(cells) >= 2
+// GetNeutralMoves returns the neutral placements open to the player, one
+// per pair of adjacent placeable cells. Pairing only neighbors keeps the
+// branching factor linear in the territory size. Whether the player has
+// already used their neutrals is tracked by GameState, not the board.
+func (b *Board) GetNeutralMoves(playerID int) []Move {
+	if !b.CanPlaceNeutrals(playerID) {
+		return nil
+	}
+
+	placeable := make(map[Position]bool)
+	positions := b.GetNeutralPositions(playerID)
+	for _, pos := range positions {
+		placeable[pos] = true
+	}
+
+	moves := make([]Move, 0)
+	for _, pos := range positions {
+		for _, n := range b.GetNeighbors(pos) {
+			// Each unordered pair once, from its first cell in row-major order
+			if placeable[n] && (n.Row > pos.Row || (n.Row == pos.Row && n.Col > pos.Col)) {
+				moves = append(moves, Move{Position: pos, Type: MoveNeutral, FromCell: pos, Pair: n})
+			}
+		}
+	}
+	return moves
 }
 
 // GetNeutralPositions returns valid positions for neutral placement
 func (b *Board) GetNeutralPositions(playerID int) []Position {
-	// Can place on any of our own non-fortified cells
-	// In this implementation, all player cells are valid for neutrals
+	// Can place on any of our own cells except the base and fortified cells
 	cells := b.GetPlayerCells(playerID)
 	neutrals := make([]Position, 0)
 	for _, cell := range cells {
-		if b.GetCell(cell) != protocol.CellNeutral {
+		if b.GetCell(cell).Flag() == protocol.CellFlagNormal {
 			neutrals = append(neutrals, cell)
 		}
 	}
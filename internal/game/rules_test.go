@@ -8,24 +8,31 @@ import (
 
 func TestIsAdjacent(t *testing.T) {
 	board := NewBoard(5)
+	orthogonal := NewBoard(5)
+	orthogonal.Rules.Adjacency = Adjacency4
 
 	tests := []struct {
-		pos1     Position
-		pos2     Position
-		adjacent bool
+		pos1       Position
+		pos2       Position
+		adjacent   bool // with diagonals, the default
+		orthogonal bool // without diagonals
 	}{
-		{pos1: Position{Row: 0, Col: 0}, pos2: Position{Row: 0, Col: 1}, adjacent: true},
-		{pos1: Position{Row: 0, Col: 0}, pos2: Position{Row: 1, Col: 0}, adjacent: true},
-		{pos1: Position{Row: 0, Col: 0}, pos2: Position{Row: 1, Col: 1}, adjacent: false},
-		{pos1: Position{Row: 0, Col: 0}, pos2: Position{Row: 0, Col: 2}, adjacent: false},
-		{pos1: Position{Row: 2, Col: 2}, pos2: Position{Row: 2, Col: 3}, adjacent: true},
-		{pos1: Position{Row: 2, Col: 2}, pos2: Position{Row: 3, Col: 2}, adjacent: true},
-		{pos1: Position{Row: 2, Col: 2}, pos2: Position{Row: 2, Col: 2}, adjacent: false},
+		{pos1: Position{Row: 0, Col: 0}, pos2: Position{Row: 0, Col: 1}, adjacent: true, orthogonal: true},
+		{pos1: Position{Row: 0, Col: 0}, pos2: Position{Row: 1, Col: 0}, adjacent: true, orthogonal: true},
+		{pos1: Position{Row: 0, Col: 0}, pos2: Position{Row: 1, Col: 1}, adjacent: true, orthogonal: false},
+		{pos1: Position{Row: 0, Col: 0}, pos2: Position{Row: 0, Col: 2}, adjacent: false, orthogonal: false},
+		{pos1: Position{Row: 2, Col: 2}, pos2: Position{Row: 2, Col: 3}, adjacent: true, orthogonal: true},
+		{pos1: Position{Row: 2, Col: 2}, pos2: Position{Row: 3, Col: 2}, adjacent: true, orthogonal: true},
+		{pos1: Position{Row: 2, Col: 2}, pos2: Position{Row: 3, Col: 1}, adjacent: true, orthogonal: false},
+		{pos1: Position{Row: 2, Col: 2}, pos2: Position{Row: 2, Col: 2}, adjacent: false, orthogonal: false},
 	}
 
 	for _, tt := range tests {
-		if board.IsAdjacent(tt.pos1, tt.pos2) != tt.adjacent {
-			t.Errorf("IsAdjacent(%v, %v) = %v, want %v", tt.pos1, tt.pos2, board.IsAdjacent(tt.pos1, tt.pos2), tt.adjacent)
+		if got := board.IsAdjacent(tt.pos1, tt.pos2); got != tt.adjacent {
+			t.Errorf("IsAdjacent(%v, %v) = %v, want %v", tt.pos1, tt.pos2, got, tt.adjacent)
+		}
+		if got := orthogonal.IsAdjacent(tt.pos1, tt.pos2); got != tt.orthogonal {
+			t.Errorf("4-directional IsAdjacent(%v, %v) = %v, want %v", tt.pos1, tt.pos2, got, tt.orthogonal)
 		}
 	}
 }
@@ -70,7 +77,7 @@ func TestGetReachableCells(t *testing.T) {
 	// Create a disconnected group
 	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellPlayer1)
 
-	reachable := board.GetReachableCells(0)
+	reachable := board.GetReachableCells(1)
 
 	// Should find 3 connected cells
 	if len(reachable) != 3 {
@@ -100,7 +107,7 @@ func TestGetReachableCells(t *testing.T) {
 func TestGetValidMoves(t *testing.T) {
 	board := NewBoard(5)
 	board.BasePos[1] = Position{Row: 0, Col: 0}
-	board.BasePos[1] = Position{Row: 4, Col: 4}
+	board.BasePos[2] = Position{Row: 4, Col: 4}
 
 	// Set up player's territory
 	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellPlayer1)
@@ -111,7 +118,7 @@ func TestGetValidMoves(t *testing.T) {
 	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellPlayer2)
 	board.SetCell(Position{Row: 4, Col: 3}, protocol.CellPlayer2)
 
-	moves := board.GetValidMoves(0)
+	moves := board.GetValidMoves(1)
 
 	// Should find moves around the player's territory
 	if len(moves) == 0 {
@@ -129,20 +136,20 @@ func TestGetValidMoves(t *testing.T) {
 func TestGetAttackMoves(t *testing.T) {
 	board := NewBoard(5)
 	board.BasePos[1] = Position{Row: 0, Col: 0}
-	board.BasePos[1] = Position{Row: 0, Col: 4}
+	board.BasePos[2] = Position{Row: 0, Col: 4}
 
-	// Player 0 at (0,0)
+	// Player 1 at (0,0)
 	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellPlayer1)
 
-	// Player 1 at (0,4) with neighbor at (0,3)
+	// Player 2 at (0,4) with neighbor at (0,3)
 	board.SetCell(Position{Row: 0, Col: 4}, protocol.CellPlayer2)
 	board.SetCell(Position{Row: 0, Col: 3}, protocol.CellPlayer2)
 
-	// Player 0 has an attack available at (0,1)
+	// Player 1 has an attack available at (0,2), next to (0,1)
 	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
-	board.SetCell(Position{Row: 0, Col: 2}, protocol.CellPlayer2) // This is adjacent to (0,1)
+	board.SetCell(Position{Row: 0, Col: 2}, protocol.CellPlayer2)
 
-	attacks := board.GetAttackMoves(0)
+	attacks := board.GetAttackMoves(1)
 
 	// Should find the attack at (0,2)
 	found := false
@@ -161,7 +168,7 @@ func TestGetAttackMoves(t *testing.T) {
 func TestIsAlive(t *testing.T) {
 	board := NewBoard(5)
 	board.BasePos[1] = Position{Row: 0, Col: 0}
-	board.BasePos[1] = Position{Row: 4, Col: 4}
+	board.BasePos[2] = Position{Row: 4, Col: 4}
 
 	// Player 0 has cells
 	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellPlayer1)
@@ -205,7 +212,7 @@ func TestGetNeutralPositions(t *testing.T) {
 func TestValidMove(t *testing.T) {
 	board := NewBoard(5)
 	board.BasePos[1] = Position{Row: 0, Col: 0}
-	board.BasePos[1] = Position{Row: 4, Col: 4}
+	board.BasePos[2] = Position{Row: 4, Col: 4}
 
 	// Set up player 1's territory
 	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellPlayer1)
@@ -248,3 +255,164 @@ func TestValidMove(t *testing.T) {
 		})
 	}
 }
+
+func TestGetValidMovesSkipsKilledCells(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+
+	ourKilled := Position{Row: 1, Col: 1}
+	theirKilled := Position{Row: 0, Col: 2}
+	board.SetCell(ourKilled, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagKilled)))
+	board.SetCell(theirKilled, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagKilled)))
+
+	if board.IsOwnedBy(ourKilled, 1) {
+		t.Error("A killed cell with our player bits must not count as ours")
+	}
+	if board.IsOpponent(ourKilled, 2) || board.IsOpponent(theirKilled, 1) {
+		t.Error("Killed cells must never be attackable")
+	}
+
+	moves := board.GetValidMoves(1)
+	if len(moves) == 0 {
+		t.Fatal("Expected some valid moves")
+	}
+	for _, move := range moves {
+		if move.Position == ourKilled || move.Position == theirKilled {
+			t.Errorf("Move %v targets a killed cell", move)
+		}
+	}
+
+	for _, moveType := range []MoveType{MoveGrow, MoveAttack} {
+		move := Move{Position: ourKilled, Type: moveType, FromCell: Position{Row: 0, Col: 1}}
+		if ValidMove(board, 1, move) {
+			t.Errorf("ValidMove accepted a move of type %d onto our killed cell", moveType)
+		}
+	}
+}
+
+func TestNearestConnectedCell(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 0, Col: 2}, protocol.CellPlayer1)
+	// Owned but cut off from the base
+	board.SetCell(Position{Row: 2, Col: 2}, protocol.CellPlayer1)
+
+	target := Position{Row: 1, Col: 2}
+	from, ok := board.NearestConnectedCell(target, 1)
+	if !ok {
+		t.Fatalf("Expected a source cell for %v", target)
+	}
+	if from != (Position{Row: 0, Col: 2}) {
+		t.Errorf("Expected source (0,2), got %v", from)
+	}
+	if !ValidMove(board, 1, Move{Position: target, Type: MoveGrow, FromCell: from}) {
+		t.Errorf("Expected grow from %v to %v to be legal", from, target)
+	}
+
+	// Only the disconnected cell touches this target
+	if from, ok := board.NearestConnectedCell(Position{Row: 3, Col: 2}, 1); ok {
+		t.Errorf("Expected no connected source for (3,2), got %v", from)
+	}
+}
+
+func TestMobilityWhenOnlyOnePlayerCanMove(t *testing.T) {
+	// Player 1 is walled in by neutrals; player 2 has one empty cell left
+	board := NewBoard(3)
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			board.SetCell(Position{Row: row, Col: col}, protocol.CellNeutral)
+		}
+	}
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 2, Col: 2}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 2, Col: 2}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 2, Col: 1}, protocol.CellEmpty)
+
+	mobility := board.MobilityByPlayer([]int{1, 2})
+	if mobility[1] != 0 || mobility[2] != 1 {
+		t.Errorf("Expected mobility {1:0 2:1}, got %v", mobility)
+	}
+	if board.AnyPlayerCanMove([]int{1}) {
+		t.Error("Expected player 1 to have no moves")
+	}
+	if !board.AnyPlayerCanMove([]int{1, 2}) {
+		t.Error("Expected player 2 to be able to move")
+	}
+
+	state := &GameState{
+		Board: board,
+		Players: []*Player{
+			NewPlayer(1, "P1", protocol.CellPlayer1, board.BasePos[1]),
+			NewPlayer(2, "P2", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+	if done, _ := state.IsTerminal(); done {
+		t.Error("Expected the game to go on while player 2 can move")
+	}
+	board.SetCell(Position{Row: 2, Col: 1}, protocol.CellNeutral)
+	if done, winner := state.IsTerminal(); !done || winner != Draw {
+		t.Errorf("Expected a drawn stalemate once nobody can move, got done %v winner %d", done, winner)
+	}
+	state.Players[1].IsAlive = false
+	if done, winner := state.IsTerminal(); !done || winner != 1 {
+		t.Errorf("Expected player 1 to win as the last one alive, got done %v winner %d", done, winner)
+	}
+}
+
+func TestFortifyMove(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 4, Col: 4}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 1, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 3, Col: 3}, protocol.CellPlayer2)
+	board.SetCell(Position{Row: 2, Col: 2}, protocol.CellPlayer2)
+	board.SetCell(Position{Row: 3, Col: 0}, protocol.CellPlayer1) // cut off from base
+
+	tests := []struct {
+		name     string
+		pos      Position
+		expected bool
+	}{
+		{"own connected cell", Position{Row: 1, Col: 1}, true},
+		{"own base", Position{Row: 0, Col: 0}, false},
+		{"own cell cut off from base", Position{Row: 3, Col: 0}, false},
+		{"opponent cell", Position{Row: 2, Col: 2}, false},
+		{"empty cell", Position{Row: 0, Col: 1}, false},
+	}
+	for _, tt := range tests {
+		move := Move{Position: tt.pos, Type: MoveFortify, FromCell: tt.pos}
+		if got := ValidMove(board, 1, move); got != tt.expected {
+			t.Errorf("%s: ValidMove = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+
+	// Player 2 can attack (1,1) from (2,2) until it is fortified
+	if !board.IsOpponent(Position{Row: 1, Col: 1}, 2) {
+		t.Fatal("Expected a normal cell to be attackable")
+	}
+	fortify := Move{Position: Position{Row: 1, Col: 1}, Type: MoveFortify, FromCell: Position{Row: 1, Col: 1}}
+	next := board.ApplyMove(fortify.Position, 1, fortify.Fortifies())
+	if cell := next.GetCell(fortify.Position); cell.Player() != 1 || !cell.IsFortified() {
+		t.Errorf("Expected a fortified player 1 cell, got %v", cell)
+	}
+	if next.IsOpponent(fortify.Position, 2) {
+		t.Error("Expected a fortified cell not to be an attack target")
+	}
+	for _, m := range next.GetValidMoves(2) {
+		if m.Type == MoveAttack && m.Position == fortify.Position {
+			t.Error("Expected no attack move on the fortified cell")
+		}
+	}
+	if ValidMove(next, 1, fortify) {
+		t.Error("Expected an already fortified cell not to be fortified again")
+	}
+}
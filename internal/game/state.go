@@ -1,8 +1,6 @@
 package game
 
-import (
-	"virusbot/internal/protocol"
-)
+import "virusbot/internal/protocol"
 
 // GameState represents the complete state of a game
 type GameState struct {
@@ -10,10 +8,41 @@ type GameState struct {
 	Players       []*Player
 	CurrentPlayer int
 	YourPlayerID  int
+	MovesLeft     int // moves the current player has left this turn; 0 means a full turn
+}
+
+// Phase is the stage of the game, judged by how much of the board is taken
+type Phase int
+
+const (
+	PhaseOpening Phase = iota
+	PhaseMidgame
+	PhaseEndgame
+)
+
+// Fill ratios at which the game moves into the next phase
+const (
+	midgameFill = 0.25
+	endgameFill = 0.6
+)
+
+// String returns the phase name
+func (p Phase) String() string {
+	switch p {
+	case PhaseOpening:
+		return "opening"
+	case PhaseMidgame:
+		return "midgame"
+	case PhaseEndgame:
+		return "endgame"
+	}
+	return "unknown"
 }
 
-// NewGameState creates a new game state from protocol data
-func NewGameState(boardData [][]protocol.CellType, players []protocol.PlayerInfo, currentPlayer, yourPlayerID int) *GameState {
+// NewGameState creates a new game state from protocol data. Bases that are
+// off the board or shared are relocated as NewBoardFromData does, and the
+// error reports them; the returned state is usable either way.
+func NewGameState(boardData [][]protocol.CellType, players []protocol.PlayerInfo, currentPlayer, yourPlayerID int) (*GameState, error) {
 	// Build base positions from players
 	basePos := make(map[int]Position)
 	for _, p := range players {
@@ -23,6 +52,10 @@ func NewGameState(boardData [][]protocol.CellType, players []protocol.PlayerInfo
 		}
 	}
 
+	rows, cols := Dimensions(boardData)
+	raw := &Board{Rows: rows, Cols: cols, Cells: boardData, BasePos: basePos}
+	invalid := raw.ValidateBases()
+
 	board := NewBoardFromData(boardData, basePos)
 	gamePlayers := PlayersFromInfo(players)
 
@@ -31,7 +64,15 @@ func NewGameState(boardData [][]protocol.CellType, players []protocol.PlayerInfo
 		Players:       gamePlayers,
 		CurrentPlayer: currentPlayer,
 		YourPlayerID:  yourPlayerID,
+	}, invalid
+}
+
+// Config returns the rule variant the game is played with
+func (s *GameState) Config() GameConfig {
+	if s.Board == nil {
+		return GameConfig{}
 	}
+	return s.Board.Rules
 }
 
 // GetCurrentPlayer returns the current player
@@ -69,6 +110,28 @@ func (s *GameState) IsMyTurn() bool {
 	return s.CurrentPlayer == s.YourPlayerID
 }
 
+// AmIAlive reports whether the bot is still in the game: not marked dead in
+// the player list and owning at least one live cell. Before anyone owns a
+// cell (initial placement) everyone counts as alive.
+func (s *GameState) AmIAlive() bool {
+	if player := s.GetYourPlayer(); player != nil && !player.IsAlive {
+		return false
+	}
+	if s.Board == nil || s.Board.IsAlive(s.YourPlayerID) {
+		return true
+	}
+
+	for row := 0; row < s.Board.Rows; row++ {
+		for col := 0; col < s.Board.Cols; col++ {
+			cell := s.Board.Cells[row][col]
+			if player := cell.Player(); player >= int(protocol.CellPlayer1) && player <= int(protocol.CellPlayer4) && !cell.IsKilled() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // GetOpponents returns all opponent players
 func (s *GameState) GetOpponents() []*Player {
 	opponents := make([]*Player, 0)
@@ -91,6 +154,131 @@ func (s *GameState) GetAlivePlayers() []*Player {
 	return alive
 }
 
+// Draw is the winner IsTerminal reports for a game nobody won
+const Draw = -1
+
+// IsTerminal reports whether the game is over and who won it. The last
+// player alive wins; with nobody alive, or when none of the alive players
+// has a legal move left, the game is a Draw. The winner is 0 while the game
+// goes on.
+func (s *GameState) IsTerminal() (done bool, winner int) {
+	alive := s.GetAlivePlayers()
+	switch len(alive) {
+	case 0:
+		return true, Draw
+	case 1:
+		return true, alive[0].ID
+	}
+	ids := make([]int, len(alive))
+	for i, p := range alive {
+		ids[i] = p.ID
+	}
+	if s.Board.AnyPlayerCanMove(ids) {
+		return false, 0
+	}
+	return true, Draw
+}
+
+// Phase returns the game phase based on the share of non-empty cells
+func (s *GameState) Phase() Phase {
+	total := s.Board.Rows * s.Board.Cols
+	if total == 0 {
+		return PhaseOpening
+	}
+
+	filled := 0
+	for row := 0; row < s.Board.Rows; row++ {
+		for col := 0; col < s.Board.Cols; col++ {
+			if s.Board.Cells[row][col] != protocol.CellEmpty {
+				filled++
+			}
+		}
+	}
+
+	ratio := float64(filled) / float64(total)
+	switch {
+	case ratio >= endgameFill:
+		return PhaseEndgame
+	case ratio >= midgameFill:
+		return PhaseMidgame
+	}
+	return PhaseOpening
+}
+
+// DangerMap marks the player's cells that at least one opponent can attack
+// on their next turn from territory connected to their base
+func (s *GameState) DangerMap(playerID int) [][]bool {
+	danger := make([][]bool, s.Board.Rows)
+	for i := range danger {
+		danger[i] = make([]bool, s.Board.Cols)
+	}
+
+	for _, oppID := range s.opponentIDs(playerID) {
+		for _, pos := range s.Board.GetAttackableCells(oppID) {
+			if s.Board.IsOwnedBy(pos, playerID) {
+				danger[pos.Row][pos.Col] = true
+			}
+		}
+	}
+
+	return danger
+}
+
+// MostCriticalCell returns the player's cell whose loss would cost the most
+// territory, by LossCosts, and that cost. Only cells an opponent could take
+// are candidates, so a base counts only where bases can be attacked. It
+// returns a cost of 0 when the player has no such cell.
+func (s *GameState) MostCriticalCell(playerID int) (Position, float64) {
+	best, bestCost := Position{}, 0
+	for pos, cost := range s.Board.LossCosts(playerID) {
+		if !s.Board.GetCell(pos).CanBeAttacked() {
+			continue
+		}
+		// Break ties by position so the answer does not depend on map order
+		if cost > bestCost || (cost == bestCost && (pos.Row < best.Row || (pos.Row == best.Row && pos.Col < best.Col))) {
+			best, bestCost = pos, cost
+		}
+	}
+	return best, float64(bestCost)
+}
+
+// opponentIDs returns the alive opponents of a player, falling back to the
+// board's bases when the player list is unknown
+func (s *GameState) opponentIDs(playerID int) []int {
+	ids := make([]int, 0)
+	if len(s.Players) > 0 {
+		for _, p := range s.Players {
+			if p.ID != playerID && p.IsAlive {
+				ids = append(ids, p.ID)
+			}
+		}
+		return ids
+	}
+
+	for id := range s.Board.BasePos {
+		if id != playerID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ExportGrid returns the raw cell values (player bits plus flags) as plain
+// ints, for external tools that render boards
+func (s *GameState) ExportGrid() [][]int {
+	if s.Board == nil {
+		return nil
+	}
+	grid := make([][]int, len(s.Board.Cells))
+	for row, cells := range s.Board.Cells {
+		grid[row] = make([]int, len(cells))
+		for col, cell := range cells {
+			grid[row][col] = int(cell)
+		}
+	}
+	return grid
+}
+
 // Clone creates a deep copy of the game state
 func (s *GameState) Clone() *GameState {
 	newPlayers := make([]*Player, len(s.Players))
@@ -103,10 +291,30 @@ func (s *GameState) Clone() *GameState {
 		Players:       newPlayers,
 		CurrentPlayer: s.CurrentPlayer,
 		YourPlayerID:  s.YourPlayerID,
+		MovesLeft:     s.MovesLeft,
 	}
 }
 
-// ApplyMove applies a move and returns a new game state
+// TurnMovesLeft returns the moves the current player has left this turn
+func (s *GameState) TurnMovesLeft() int {
+	if s.MovesLeft > 0 {
+		return s.MovesLeft
+	}
+	return s.Config().TurnLength()
+}
+
+// GetValidMoves returns the player's grows and attacks, plus their neutral
+// placements while they have not used them yet
+func (s *GameState) GetValidMoves(playerID int) []Move {
+	moves := s.Board.GetValidMoves(playerID)
+	if player := s.GetPlayer(playerID); player != nil && !player.HasUsedNeutrals {
+		moves = append(moves, s.Board.GetNeutralMoves(playerID)...)
+	}
+	return moves
+}
+
+// ApplyMove applies a move and returns a new game state. The current player
+// keeps the turn until their moves for it are used up.
 func (s *GameState) ApplyMove(move Move) *GameState {
 	newState := s.Clone()
 	player := newState.GetCurrentPlayer()
@@ -114,8 +322,13 @@ func (s *GameState) ApplyMove(move Move) *GameState {
 		return newState
 	}
 
+	if move.Type == MoveNeutral {
+		newState.placeNeutrals(player, []Position{move.Position, move.Pair})
+		return newState
+	}
+
 	// Apply the move to the board
-	newState.Board.ApplyMove(move.Position, player.ID, move.Type == MoveAttack)
+	newState.Board = newState.Board.ApplyMove(move.Position, player.ID, move.Fortifies())
 
 	// Update player's cell list
 	if move.Type == MoveGrow {
@@ -128,14 +341,38 @@ func (s *GameState) ApplyMove(move Move) *GameState {
 		player.AddCell(move.Position)
 	}
 
-	// Advance to next player
-	newState.AdvancePlayer()
+	// Advance to next player once the turn is used up
+	newState.MovesLeft = newState.TurnMovesLeft() - 1
+	if newState.MovesLeft <= 0 {
+		newState.AdvancePlayer()
+	}
 
 	return newState
 }
 
-// AdvancePlayer moves to the next alive player
+// ApplyMoveSequence plays moves as the current player's whole turn and
+// returns the state with the next player to move. Moves beyond the moves
+// left are ignored; a shorter sequence passes the rest of the turn.
+func (s *GameState) ApplyMoveSequence(moves []Move) *GameState {
+	left := s.TurnMovesLeft()
+	next := s
+	for _, move := range moves[:min(len(moves), left)] {
+		next = next.ApplyMove(move)
+		if move.Type == MoveNeutral {
+			// Placing neutrals ends the turn
+			return next
+		}
+	}
+	if len(moves) < left {
+		next = next.Clone()
+		next.AdvancePlayer()
+	}
+	return next
+}
+
+// AdvancePlayer moves to the next alive player and gives them a full turn
 func (s *GameState) AdvancePlayer() {
+	s.MovesLeft = s.Config().TurnLength()
 	alive := s.GetAlivePlayers()
 	if len(alive) == 0 {
 		return
@@ -163,16 +400,21 @@ func (s *GameState) ApplyNeutrals(positions []Position) *GameState {
 		return newState
 	}
 
+	newState.placeNeutrals(player, positions)
+	return newState
+}
+
+// placeNeutrals turns the player's cells at positions into neutrals, marks
+// their neutrals as used and ends their turn
+func (s *GameState) placeNeutrals(player *Player, positions []Position) {
 	player.HasUsedNeutrals = true
 
 	for _, pos := range positions {
-		newState.Board.SetCell(pos, protocol.CellNeutral)
+		s.Board.SetCell(pos, protocol.CellNeutral)
 		// Remove from player's cells
 		player.RemoveCell(pos)
 	}
 
 	// Advance player (using neutrals ends your turn)
-	newState.AdvancePlayer()
-
-	return newState
+	s.AdvancePlayer()
 }
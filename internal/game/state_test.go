@@ -0,0 +1,344 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	"virusbot/internal/protocol"
+)
+
+func TestDangerMap(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 4, Col: 4}
+
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1) // safe, far from player 2
+	board.SetCell(Position{Row: 1, Col: 0}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 2, Col: 0}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 3, Col: 0}, protocol.CellPlayer1) // exposed to player 2
+
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	for c := 3; c >= 0; c-- {
+		board.SetCell(Position{Row: 4, Col: c}, protocol.CellPlayer2)
+	}
+
+	state := &GameState{
+		Board: board,
+		Players: []*Player{
+			NewPlayer(1, "Bot", protocol.CellPlayer1, Position{Row: 0, Col: 0}),
+			NewPlayer(2, "Opponent", protocol.CellPlayer2, Position{Row: 4, Col: 4}),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	danger := state.DangerMap(1)
+
+	if !danger[3][0] {
+		t.Error("Expected (3,0) to be in danger from player 2")
+	}
+	if danger[0][1] {
+		t.Error("Expected (0,1) to be safe")
+	}
+	if danger[0][0] {
+		t.Error("Base cells cannot be attacked and must not be marked")
+	}
+	if danger[4][0] {
+		t.Error("Opponent cells must not be marked")
+	}
+}
+
+func TestNewGameStateReportsRelocatedBases(t *testing.T) {
+	cells := [][]protocol.CellType{
+		{protocol.CellType(int(protocol.CellPlayer1) | int(protocol.CellFlagBase)), protocol.CellEmpty},
+		{protocol.CellEmpty, protocol.CellPlayer2},
+	}
+	players := []protocol.PlayerInfo{
+		{ID: 1, Position: protocol.Position{Row: 0, Col: 0}},
+		{ID: 2, Position: protocol.Position{Row: 5, Col: 5}},
+	}
+
+	state, err := NewGameState(cells, players, 1, 1)
+	if !errors.Is(err, ErrInvalidBase) {
+		t.Errorf("Expected the off-board base to be reported, got %v", err)
+	}
+	if state == nil || state.Board.BasePos[2] != (Position{Row: 1, Col: 1}) {
+		t.Fatalf("Expected player 2's base to be relocated onto its cell, got %+v", state)
+	}
+
+	players[1].Position = protocol.Position{Row: 1, Col: 1}
+	if _, err := NewGameState(cells, players, 1, 1); err != nil {
+		t.Errorf("Expected valid bases to pass, got %v", err)
+	}
+}
+
+func TestPhase(t *testing.T) {
+	board := NewBoard(4)
+	state := &GameState{Board: board}
+
+	if phase := state.Phase(); phase != PhaseOpening {
+		t.Errorf("Expected empty board to be %v, got %v", PhaseOpening, phase)
+	}
+
+	// 5 of 16 cells taken
+	for col := 0; col < 4; col++ {
+		board.SetCell(Position{Row: 0, Col: col}, protocol.CellPlayer1)
+	}
+	board.SetCell(Position{Row: 3, Col: 3}, protocol.CellNeutral)
+	if phase := state.Phase(); phase != PhaseMidgame {
+		t.Errorf("Expected %v, got %v", PhaseMidgame, phase)
+	}
+
+	// 10 of 16 cells taken
+	for col := 0; col < 4; col++ {
+		board.SetCell(Position{Row: 1, Col: col}, protocol.CellPlayer2)
+	}
+	board.SetCell(Position{Row: 2, Col: 0}, protocol.CellPlayer2)
+	if phase := state.Phase(); phase != PhaseEndgame {
+		t.Errorf("Expected %v, got %v", PhaseEndgame, phase)
+	}
+}
+
+func TestAmIAlive(t *testing.T) {
+	board := NewBoard(3)
+	state := &GameState{
+		Board: board,
+		Players: []*Player{
+			NewPlayer(1, "Bot", protocol.CellPlayer1, Position{Row: 0, Col: 0}),
+			NewPlayer(2, "Opponent", protocol.CellPlayer2, Position{Row: 2, Col: 2}),
+		},
+		YourPlayerID: 1,
+	}
+
+	if !state.AmIAlive() {
+		t.Error("Expected everyone to be alive before any cell is placed")
+	}
+
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(1|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 2, Col: 2}, protocol.CellType(2|int(protocol.CellFlagBase)))
+	if !state.AmIAlive() {
+		t.Error("Expected to be alive while owning cells")
+	}
+
+	// Base captured and fortified by player 2, nothing else left
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(2|int(protocol.CellFlagFortified)))
+	if state.AmIAlive() {
+		t.Error("Expected to be eliminated with no cells left")
+	}
+
+	board.SetCell(Position{Row: 1, Col: 1}, protocol.CellPlayer1)
+	state.Players[0].IsAlive = false
+	if state.AmIAlive() {
+		t.Error("Expected a player marked dead to be eliminated")
+	}
+}
+
+func TestExportGrid(t *testing.T) {
+	board := NewBoard(3)
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(1|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 1, Col: 1}, protocol.CellType(2|int(protocol.CellFlagFortified)))
+	board.SetCell(Position{Row: 2, Col: 0}, protocol.CellType(2|int(protocol.CellFlagKilled)))
+	board.SetCell(Position{Row: 2, Col: 2}, protocol.CellNeutral)
+	state := &GameState{Board: board}
+
+	grid := state.ExportGrid()
+
+	want := [][]int{
+		{0x11, 0x01, 0x00},
+		{0x00, 0x22, 0x00},
+		{0x32, 0x00, 0x05},
+	}
+	if len(grid) != len(want) {
+		t.Fatalf("Expected %d rows, got %d", len(want), len(grid))
+	}
+	for row := range want {
+		for col := range want[row] {
+			if grid[row][col] != want[row][col] {
+				t.Errorf("Cell (%d,%d): expected %#x, got %#x", row, col, want[row][col], grid[row][col])
+			}
+		}
+	}
+
+	// The export is a copy, not a view of the board
+	grid[0][1] = 0
+	if board.GetCell(Position{Row: 0, Col: 1}) != protocol.CellPlayer1 {
+		t.Error("Modifying the exported grid changed the board")
+	}
+}
+
+// countNeutralMoves counts the neutral placements among moves
+func countNeutralMoves(moves []Move) int {
+	n := 0
+	for _, m := range moves {
+		if m.Type == MoveNeutral {
+			n++
+		}
+	}
+	return n
+}
+
+func TestNeutralMovesOnlyWhenLegal(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 4, Col: 4}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+
+	state := &GameState{
+		Board: board,
+		Players: []*Player{
+			NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+			NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	// A single cell is not enough for two neutrals
+	if n := countNeutralMoves(state.GetValidMoves(1)); n != 0 {
+		t.Errorf("Expected no neutral placement with one cell, got %d", n)
+	}
+
+	// The base cannot become a neutral
+	board.SetCell(Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+	state.Players[0].AddCell(Position{Row: 0, Col: 1})
+	if n := countNeutralMoves(state.GetValidMoves(1)); n != 0 {
+		t.Errorf("Expected no neutral placement with one cell besides the base, got %d", n)
+	}
+
+	board.SetCell(Position{Row: 1, Col: 1}, protocol.CellPlayer1)
+	state.Players[0].AddCell(Position{Row: 1, Col: 1})
+	if n := countNeutralMoves(state.GetValidMoves(1)); n != 1 {
+		t.Fatalf("Expected one neutral placement with two cells, got %d", n)
+	}
+
+	state.Players[0].HasUsedNeutrals = true
+	if n := countNeutralMoves(state.GetValidMoves(1)); n != 0 {
+		t.Errorf("Expected no neutral placement once neutrals are used, got %d", n)
+	}
+}
+
+func TestApplyNeutralMove(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 4, Col: 4}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	for _, pos := range []Position{{Row: 0, Col: 1}, {Row: 1, Col: 1}, {Row: 1, Col: 2}} {
+		board.SetCell(pos, protocol.CellPlayer1)
+	}
+
+	player := NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1])
+	player.Cells = append(player.Cells, Position{Row: 0, Col: 1}, Position{Row: 1, Col: 1}, Position{Row: 1, Col: 2})
+	state := &GameState{
+		Board:         board,
+		Players:       []*Player{player, NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2])},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	move := Move{Position: Position{Row: 1, Col: 1}, Type: MoveNeutral, FromCell: Position{Row: 1, Col: 1}, Pair: Position{Row: 1, Col: 2}}
+	if !ValidMove(board, 1, move) {
+		t.Fatalf("Expected %+v to be legal", move)
+	}
+
+	next := state.ApplyMove(move)
+	for _, pos := range []Position{move.Position, move.Pair} {
+		if !next.Board.IsNeutral(pos) {
+			t.Errorf("Expected a neutral at %v", pos)
+		}
+	}
+	if !next.GetPlayer(1).HasUsedNeutrals {
+		t.Error("Expected the neutrals to be marked as used")
+	}
+	if next.CurrentPlayer != 2 {
+		t.Errorf("Expected placing neutrals to end the turn, current player is %d", next.CurrentPlayer)
+	}
+	if state.GetPlayer(1).HasUsedNeutrals || state.Board.IsNeutral(move.Position) {
+		t.Error("Expected the original state to be unchanged")
+	}
+}
+
+func TestApplyMoveUpdatesBoard(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 4, Col: 4}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 1, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(Position{Row: 2, Col: 2}, protocol.CellPlayer2)
+
+	state := &GameState{
+		Board:         board,
+		Players:       []*Player{NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]), NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2])},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+	state.Players[1].Cells = append(state.Players[1].Cells, Position{Row: 2, Col: 2})
+
+	grow := Move{Position: Position{Row: 0, Col: 1}, Type: MoveGrow}
+	next := state.ApplyMove(grow)
+	if cell := next.Board.GetCell(grow.Position); cell != protocol.CellPlayer1 {
+		t.Errorf("Expected a grow move to claim %v for player 1, got %v", grow.Position, cell)
+	}
+	if !state.Board.IsEmpty(grow.Position) {
+		t.Error("Expected the original board to be unchanged")
+	}
+
+	next.CurrentPlayer = 1
+	attack := Move{Position: Position{Row: 2, Col: 2}, Type: MoveAttack}
+	next = next.ApplyMove(attack)
+	if cell := next.Board.GetCell(attack.Position); cell.Player() != 1 || !cell.IsFortified() {
+		t.Errorf("Expected an attack to leave a fortified player 1 cell at %v, got %v", attack.Position, cell)
+	}
+	for _, pos := range next.GetPlayer(2).Cells {
+		if pos == attack.Position {
+			t.Error("Expected the attacked cell to be removed from the opponent")
+		}
+	}
+}
+
+func TestApplyMoveKeepsTurnUntilMovesAreUsed(t *testing.T) {
+	board := NewBoard(5)
+	board.BasePos[1] = Position{Row: 0, Col: 0}
+	board.BasePos[2] = Position{Row: 4, Col: 4}
+	board.SetCell(Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(Position{Row: 4, Col: 4}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	state := &GameState{
+		Board:         board,
+		Players:       []*Player{NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]), NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2])},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	turn := []Move{
+		{Position: Position{Row: 0, Col: 1}, Type: MoveGrow},
+		{Position: Position{Row: 1, Col: 1}, Type: MoveGrow},
+		{Position: Position{Row: 1, Col: 0}, Type: MoveGrow},
+	}
+	next := state
+	for i, move := range turn[:2] {
+		next = next.ApplyMove(move)
+		if next.CurrentPlayer != 1 || next.MovesLeft != 2-i {
+			t.Fatalf("After move %d expected player 1 with %d moves left, got player %d with %d", i+1, 2-i, next.CurrentPlayer, next.MovesLeft)
+		}
+	}
+	next = next.ApplyMove(turn[2])
+	if next.CurrentPlayer != 2 || next.MovesLeft != 3 {
+		t.Errorf("Expected the third move to hand player 2 a full turn, got player %d with %d moves", next.CurrentPlayer, next.MovesLeft)
+	}
+
+	sequence := state.ApplyMoveSequence(turn)
+	if sequence.CurrentPlayer != 2 || sequence.Board.CountCells(1) != 4 {
+		t.Errorf("Expected the sequence to play all three moves and pass the turn, got player %d with %d cells", sequence.CurrentPlayer, sequence.Board.CountCells(1))
+	}
+	short := state.ApplyMoveSequence(turn[:1])
+	if short.CurrentPlayer != 2 || short.Board.CountCells(1) != 2 {
+		t.Errorf("Expected a one-move sequence to pass the rest of the turn, got player %d with %d cells", short.CurrentPlayer, short.Board.CountCells(1))
+	}
+	if state.CurrentPlayer != 1 || state.MovesLeft != 0 {
+		t.Error("Expected the original state to be unchanged")
+	}
+}
@@ -0,0 +1,122 @@
+package game
+
+// Symmetry is one of the non-identity transformations of a square board.
+// Only the flips and the half turn apply to a board that is not square.
+type Symmetry int
+
+const (
+	SymRotate90 Symmetry = iota
+	SymRotate180
+	SymRotate270
+	SymFlipHorizontal
+	SymFlipVertical
+	SymTranspose
+	SymAntiTranspose
+)
+
+// allSymmetries lists every non-identity symmetry of the square
+var allSymmetries = []Symmetry{
+	SymRotate90, SymRotate180, SymRotate270,
+	SymFlipHorizontal, SymFlipVertical,
+	SymTranspose, SymAntiTranspose,
+}
+
+// Transform returns the image of a position under a symmetry
+func (b *Board) Transform(pos Position, sym Symmetry) Position {
+	lastRow, lastCol := b.Rows-1, b.Cols-1
+	switch sym {
+	case SymRotate90:
+		return Position{Row: pos.Col, Col: lastRow - pos.Row}
+	case SymRotate180:
+		return Position{Row: lastRow - pos.Row, Col: lastCol - pos.Col}
+	case SymRotate270:
+		return Position{Row: lastCol - pos.Col, Col: pos.Row}
+	case SymFlipHorizontal:
+		return Position{Row: pos.Row, Col: lastCol - pos.Col}
+	case SymFlipVertical:
+		return Position{Row: lastRow - pos.Row, Col: pos.Col}
+	case SymTranspose:
+		return Position{Row: pos.Col, Col: pos.Row}
+	case SymAntiTranspose:
+		return Position{Row: lastCol - pos.Col, Col: lastRow - pos.Row}
+	}
+	return pos
+}
+
+// swapsAxes reports whether a symmetry turns rows into columns, which only
+// a square board survives
+func (sym Symmetry) swapsAxes() bool {
+	switch sym {
+	case SymRotate90, SymRotate270, SymTranspose, SymAntiTranspose:
+		return true
+	}
+	return false
+}
+
+// Symmetries returns the symmetries that leave every cell and every base
+// in place. It is empty as soon as the position loses its symmetry.
+func (b *Board) Symmetries() []Symmetry {
+	result := make([]Symmetry, 0)
+	for _, sym := range allSymmetries {
+		if sym.swapsAxes() && b.Rows != b.Cols {
+			continue
+		}
+		if b.isInvariant(sym) {
+			result = append(result, sym)
+		}
+	}
+	return result
+}
+
+// isInvariant checks if the board looks identical after applying sym
+func (b *Board) isInvariant(sym Symmetry) bool {
+	for _, basePos := range b.BasePos {
+		if b.Transform(basePos, sym) != basePos {
+			return false
+		}
+	}
+
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
+			pos := Position{Row: row, Col: col}
+			if b.GetCell(pos) != b.GetCell(b.Transform(pos, sym)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SymmetryGroups partitions moves into classes whose targets are mirror
+// images of each other under the board's symmetries. Moves in one group lead
+// to equivalent positions, so a search only needs to explore one of them.
+// Moves onto the same target from different cells always share a group.
+func (b *Board) SymmetryGroups(moves []Move) [][]Move {
+	type target struct {
+		pos      Position
+		moveType MoveType
+	}
+
+	symmetries := b.Symmetries()
+
+	groups := make([][]Move, 0, len(moves))
+	groupOf := make(map[target]int)
+	for _, move := range moves {
+		key := target{pos: move.Position, moveType: move.Type}
+		idx, seen := groupOf[key]
+		if !seen {
+			idx = len(groups)
+			groups = append(groups, nil)
+			groupOf[key] = idx
+			for _, sym := range symmetries {
+				image := target{pos: b.Transform(move.Position, sym), moveType: move.Type}
+				if _, exists := groupOf[image]; !exists {
+					groupOf[image] = idx
+				}
+			}
+		}
+		groups[idx] = append(groups[idx], move)
+	}
+
+	return groups
+}
@@ -0,0 +1,127 @@
+// Package logging provides the leveled logger shared by the client, the
+// strategies and the bot.
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Level is the severity of a log line
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = [...]string{"debug", "info", "warn", "error"}
+
+// String returns the level name
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses a level name such as "debug" or "WARN"
+func ParseLevel(name string) (Level, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warning" {
+		return LevelWarn, nil
+	}
+	for i, n := range levelNames {
+		if n == name {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", name)
+}
+
+// Logger writes leveled, printf-style log lines
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// stdLogger writes lines at or above its level through the log package
+type stdLogger struct {
+	level  Level
+	prefix string
+	printf func(format string, args ...interface{})
+}
+
+// New returns a Logger that writes lines at or above level through the
+// standard log package, each starting with prefix
+func New(level Level, prefix string) Logger {
+	return &stdLogger{level: level, prefix: prefix, printf: log.Printf}
+}
+
+// WithPrefix returns a logger that adds prefix to the lines of l. Loggers
+// not created by New are returned unchanged.
+func WithPrefix(l Logger, prefix string) Logger {
+	std, ok := l.(*stdLogger)
+	if !ok {
+		return l
+	}
+	clone := *std
+	clone.prefix += prefix
+	return &clone
+}
+
+// Enabled reports whether l writes lines at level, so that callers can skip
+// building costly arguments. Loggers not created by New are assumed to
+// write everything, except Nop.
+func Enabled(l Logger, level Level) bool {
+	switch l := l.(type) {
+	case *stdLogger:
+		return level >= l.level
+	case nop:
+		return false
+	}
+	return true
+}
+
+func (l *stdLogger) logf(level Level, tag, format string, args ...interface{}) {
+	if level < l.level {
+		return
+	}
+	l.printf(l.prefix+tag+format, args...)
+}
+
+// Debugf logs detail only useful when investigating the bot
+func (l *stdLogger) Debugf(format string, args ...interface{}) {
+	l.logf(LevelDebug, "", format, args...)
+}
+
+// Infof logs normal progress
+func (l *stdLogger) Infof(format string, args ...interface{}) {
+	l.logf(LevelInfo, "", format, args...)
+}
+
+// Warnf logs something unexpected the bot recovered from
+func (l *stdLogger) Warnf(format string, args ...interface{}) {
+	l.logf(LevelWarn, "WARNING: ", format, args...)
+}
+
+// Errorf logs a failed operation
+func (l *stdLogger) Errorf(format string, args ...interface{}) {
+	l.logf(LevelError, "ERROR: ", format, args...)
+}
+
+// nop discards every line
+type nop struct{}
+
+// Nop returns a Logger that discards everything, for tests
+func Nop() Logger { return nop{} }
+
+func (nop) Debugf(string, ...interface{}) {}
+func (nop) Infof(string, ...interface{})  {}
+func (nop) Warnf(string, ...interface{})  {}
+func (nop) Errorf(string, ...interface{}) {}
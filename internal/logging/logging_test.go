@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLoggerFiltersBelowLevel(t *testing.T) {
+	var lines []string
+	l := &stdLogger{level: LevelWarn, prefix: "[bot] ", printf: func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}}
+
+	l.Debugf("debug %d", 1)
+	l.Infof("info %d", 2)
+	l.Warnf("warn %d", 3)
+	l.Errorf("error %d", 4)
+
+	want := []string{"[bot] WARNING: warn 3", "[bot] ERROR: error 4"}
+	if fmt.Sprint(lines) != fmt.Sprint(want) {
+		t.Errorf("Expected %q, got %q", want, lines)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	l := New(LevelInfo, "")
+	if Enabled(l, LevelDebug) || !Enabled(l, LevelInfo) || !Enabled(WithPrefix(l, "[bot] "), LevelError) {
+		t.Error("Expected an info logger to write info and above only")
+	}
+	if Enabled(Nop(), LevelError) {
+		t.Error("Expected Nop to write nothing")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Level
+		wantErr bool
+	}{
+		{"debug", LevelDebug, false},
+		{"INFO", LevelInfo, false},
+		{"warning", LevelWarn, false},
+		{" error ", LevelError, false},
+		{"verbose", LevelInfo, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
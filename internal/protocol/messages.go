@@ -1,6 +1,10 @@
 package protocol
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
 
 // MessageType represents the type of WebSocket message
 type MessageType string
@@ -19,6 +23,7 @@ const (
 	MsgBotWanted        MessageType = "bot_wanted"
 	MsgRemoveBot        MessageType = "remove_bot"
 	MsgStartMultiplayer MessageType = "start_multiplayer_game"
+	MsgLobbyUpdate      MessageType = "lobby_update"
 
 	// Game messages
 	MsgGameStart  MessageType = "game_start"
@@ -27,10 +32,32 @@ const (
 	MsgTurnChange MessageType = "turn_change"
 	MsgGameEnd    MessageType = "game_end"
 
+	// MsgPlaceNeutrals turns two of our cells into neutrals, once per game
+	MsgPlaceNeutrals MessageType = "place_neutrals"
+
+	// MsgPlayersUpdate carries the authoritative player list mid-game
+	MsgPlayersUpdate MessageType = "players_update"
+
+	// MsgRequestState asks the server to resend the full game state
+	MsgRequestState MessageType = "request_state"
+
+	// MsgGameState is the full board, sent in reply to request_state
+	MsgGameState MessageType = "game_state"
+
+	// MsgError reports a request the server refused, such as an illegal move
+	MsgError MessageType = "error"
+
 	// Challenge messages
 	MsgChallenge        MessageType = "challenge_received"
 	MsgAcceptChallenge  MessageType = "accept_challenge"
 	MsgDeclineChallenge MessageType = "decline_challenge"
+	MsgSendChallenge    MessageType = "send_challenge"
+	MsgChallengeSent    MessageType = "challenge_sent"
+	MsgChallengeCancel  MessageType = "challenge_cancelled"
+
+	// Room messages
+	MsgJoinRoom  MessageType = "join_room"
+	MsgLeaveRoom MessageType = "leave_room"
 )
 
 // Cell flags (encoded in high 2 bits)
@@ -46,7 +73,7 @@ const (
 
 // CellType represents the type of cell on the board
 // It encodes both the player ID and cell flags using bit fields:
-// - Low 4 bits (0x0F): Player ID (0=empty, 1-4=players, 5=neutral)
+// - Low 4 bits (0x0F): Player ID (0=empty, 1-4=players, 5=neutral, 6=special)
 // - High 2 bits (0x30): Flags (0x00=normal, 0x10=base, 0x20=fortified, 0x30=killed/neutral)
 type CellType int
 
@@ -57,6 +84,7 @@ const (
 	CellPlayer3 CellType = 3
 	CellPlayer4 CellType = 4
 	CellNeutral CellType = 5
+	CellSpecial CellType = 6 // Unowned power-up cell, captured by growing into it
 )
 
 // Player extracts the player ID from a CellType
@@ -84,6 +112,11 @@ func (c CellType) IsKilled() bool {
 	return c.Flag() == CellFlagKilled
 }
 
+// IsSpecial returns true if the cell is an uncaptured special (power-up) cell
+func (c CellType) IsSpecial() bool {
+	return c == CellSpecial
+}
+
 // CanBeAttacked returns true if the cell can be attacked (only normal cells)
 func (c CellType) CanBeAttacked() bool {
 	return c.Flag() == CellFlagNormal
@@ -97,17 +130,19 @@ type Position struct {
 
 // PlayerInfo contains information about a player
 type PlayerInfo struct {
-	ID       int      `json:"id"`
-	Name     string   `json:"name"`
-	Symbol   CellType `json:"symbol"`
-	Position Position `json:"position"`
-	IsAI     bool     `json:"isAI,omitempty"`
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	Symbol     CellType `json:"symbol"`
+	Position   Position `json:"position"`
+	IsAI       bool     `json:"isAI,omitempty"`
+	Eliminated bool     `json:"eliminated,omitempty"`
 }
 
 // Message is the base WebSocket message structure
 type Message struct {
-	Type MessageType `json:"type"`
-	Data interface{} `json:"data,omitempty"`
+	Type   MessageType `json:"type"`
+	Data   interface{} `json:"data,omitempty"`
+	RoomID string      `json:"roomId,omitempty"` // Set on game messages routed from a room
 }
 
 // WelcomeMessage is sent when a client connects
@@ -121,6 +156,15 @@ type UsersUpdateMessage struct {
 	Users []UserInfo `json:"users"`
 }
 
+// ParseUsersUpdate parses a users update message
+func ParseUsersUpdate(data []byte) (*UsersUpdateMessage, error) {
+	var msg UsersUpdateMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
 // UserInfo contains user details for the user list
 type UserInfo struct {
 	ID      string `json:"id"`
@@ -139,6 +183,17 @@ type JoinLobbyMessage struct {
 	LobbyID string `json:"lobbyId"`
 }
 
+// LobbyActionMessage is sent to leave, start or add a bot to a lobby
+type LobbyActionMessage struct {
+	LobbyID string `json:"lobbyId"`
+}
+
+// RemoveBotMessage is sent to remove a bot from a lobby
+type RemoveBotMessage struct {
+	LobbyID string `json:"lobbyId"`
+	BotID   string `json:"botId"`
+}
+
 // LobbyMessage is the response when joining/creating a lobby
 type LobbyMessage struct {
 	LobbyID   string       `json:"lobbyId"`
@@ -147,12 +202,30 @@ type LobbyMessage struct {
 	BoardSize int          `json:"boardSize"`
 }
 
+// ParseLobby parses a lobby update
+func ParseLobby(data []byte) (*LobbyMessage, error) {
+	var msg LobbyMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// GameRules is the optional game-mode metadata a game_start may carry.
+// Zero values mean the standard rules.
+type GameRules struct {
+	MovesPerTurn    int  `json:"movesPerTurn,omitempty"`
+	Adjacency       int  `json:"adjacency,omitempty"` // 4 or 8 neighbors
+	BasesAttackable bool `json:"basesAttackable,omitempty"`
+}
+
 // GameStartMessage is sent when a game begins
 type GameStartMessage struct {
 	Board         [][]CellType `json:"board"`
 	Players       []PlayerInfo `json:"players"`
 	CurrentPlayer int          `json:"currentPlayer"`
 	YourPlayerID  int          `json:"yourPlayerId"`
+	GameRules
 }
 
 // GameStartV2Message is sent when a game begins (new format without board data)
@@ -163,6 +236,7 @@ type GameStartV2Message struct {
 	YourPlayer       int    `json:"yourPlayer"`
 	Rows             int    `json:"rows"`
 	Cols             int    `json:"cols"`
+	GameRules
 }
 
 // MoveMessage is sent to make a move
@@ -180,6 +254,28 @@ type MoveMadeMessage struct {
 	MovesLeft int    `json:"movesLeft"`
 }
 
+// PlaceNeutralsMessage is sent to place our neutrals. Like moves, it is
+// sent flat rather than wrapped in a data field.
+type PlaceNeutralsMessage struct {
+	Type      MessageType `json:"type"`
+	GameID    string      `json:"gameId"`
+	Positions []Position  `json:"positions"`
+}
+
+// NewPlaceNeutralsMessage creates a neutral placement message
+func NewPlaceNeutralsMessage(gameID string, positions []Position) *PlaceNeutralsMessage {
+	return &PlaceNeutralsMessage{Type: MsgPlaceNeutrals, GameID: gameID, Positions: positions}
+}
+
+// ParsePlaceNeutrals parses a neutral placement message
+func ParsePlaceNeutrals(data []byte) (*PlaceNeutralsMessage, error) {
+	var msg PlaceNeutralsMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
 // GameEndMessage is sent when the game ends
 type GameEndMessage struct {
 	Winner     int    `json:"winner"`
@@ -192,6 +288,15 @@ type TurnChangeMessage struct {
 	GameID    string `json:"gameId"`
 	Player    int    `json:"player"`
 	MovesLeft int    `json:"movesLeft"`
+
+	// Skipped is set when the message explicitly grants no moves: the
+	// player's turn is skipped. A missing movesLeft leaves it unset.
+	Skipped bool `json:"-"`
+}
+
+// RoomMessage is sent to join or leave a room
+type RoomMessage struct {
+	RoomID string `json:"roomId"`
 }
 
 // ParseTurnChange parses a turn change message
@@ -200,6 +305,14 @@ func ParseTurnChange(data []byte) (*TurnChangeMessage, error) {
 	if err := json.Unmarshal(data, &msg); err != nil {
 		return nil, err
 	}
+
+	var budget struct {
+		MovesLeft *int `json:"movesLeft"`
+	}
+	if err := json.Unmarshal(data, &budget); err != nil {
+		return nil, err
+	}
+	msg.Skipped = budget.MovesLeft != nil && *budget.MovesLeft == 0
 	return &msg, nil
 }
 
@@ -257,6 +370,66 @@ func ParseGameEnd(data []byte) (*GameEndMessage, error) {
 	return &msg, nil
 }
 
+// GameStateMessage is a snapshot of a game in progress: the whole board
+// and, when the server sends them, the players and whose turn it is
+type GameStateMessage struct {
+	GameID        string       `json:"gameId"`
+	Board         [][]CellType `json:"board"`
+	Players       []PlayerInfo `json:"players,omitempty"`
+	CurrentPlayer *int         `json:"currentPlayer,omitempty"`
+	MovesLeft     int          `json:"movesLeft,omitempty"`
+}
+
+// ParseGameState parses a game state snapshot. A snapshot without a board
+// is an error, as there would be nothing to apply.
+func ParseGameState(data []byte) (*GameStateMessage, error) {
+	var msg GameStateMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	if len(msg.Board) == 0 {
+		return nil, errors.New("game_state has no board")
+	}
+	return &msg, nil
+}
+
+// ErrorMessage is the server's refusal of one of our requests
+type ErrorMessage struct {
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// ParseError parses an error message. Servers that send the text as
+// "error" instead of "message" are understood too.
+func ParseError(data []byte) (*ErrorMessage, error) {
+	var msg struct {
+		ErrorMessage
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	if msg.Message == "" {
+		msg.Message = msg.Error
+	}
+	return &msg.ErrorMessage, nil
+}
+
+// moveErrorWords mark the errors a server sends when it refuses a move
+var moveErrorWords = []string{"move", "cell", "turn"}
+
+// IsMoveError reports whether the error refuses a move, as told by its code
+// or, for servers without codes, its text
+func (e *ErrorMessage) IsMoveError() bool {
+	text := strings.ToLower(e.Code + " " + e.Message)
+	for _, word := range moveErrorWords {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
 // ChallengeMessage contains challenge information
 type ChallengeMessage struct {
 	ChallengeID  string `json:"challengeId"`
@@ -273,6 +446,49 @@ func ParseChallenge(data []byte) (*ChallengeMessage, error) {
 	return &msg, nil
 }
 
+// ChallengeSentMessage confirms a challenge we issued
+type ChallengeSentMessage struct {
+	ChallengeID  string `json:"challengeId"`
+	TargetUserID string `json:"targetUserId"`
+}
+
+// PlayersUpdateMessage replaces the player list, e.g. after an elimination
+type PlayersUpdateMessage struct {
+	Players []PlayerInfo `json:"players"`
+}
+
+// ParsePlayersUpdate parses a players update message
+func ParsePlayersUpdate(data []byte) (*PlayersUpdateMessage, error) {
+	var msg PlayersUpdateMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// ChallengeCancelledMessage withdraws a challenge we received
+type ChallengeCancelledMessage struct {
+	ChallengeID string `json:"challengeId"`
+}
+
+// ParseChallengeCancelled parses a challenge cancellation
+func ParseChallengeCancelled(data []byte) (*ChallengeCancelledMessage, error) {
+	var msg ChallengeCancelledMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// ParseChallengeSent parses a challenge sent confirmation
+func ParseChallengeSent(data []byte) (*ChallengeSentMessage, error) {
+	var msg ChallengeSentMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
 // NewAcceptChallengeMessage creates an accept challenge message
 func NewAcceptChallengeMessage(challengeID string) *Message {
 	return &Message{
@@ -303,3 +519,42 @@ func NewJoinLobbyMessage(lobbyID string) *Message {
 func NewCreateLobbyMessage(boardSize int) *Message {
 	return NewMessage(MsgCreateLobby, CreateLobbyMessage{BoardSize: boardSize})
 }
+
+// NewLeaveLobbyMessage creates a leave lobby message
+func NewLeaveLobbyMessage(lobbyID string) *Message {
+	return NewMessage(MsgLeaveLobby, LobbyActionMessage{LobbyID: lobbyID})
+}
+
+// NewStartMultiplayerMessage creates a message starting the lobby's game
+func NewStartMultiplayerMessage(lobbyID string) *Message {
+	return NewMessage(MsgStartMultiplayer, LobbyActionMessage{LobbyID: lobbyID})
+}
+
+// NewAddBotMessage creates a message adding a server bot to a lobby
+func NewAddBotMessage(lobbyID string) *Message {
+	return NewMessage(MsgAddBot, LobbyActionMessage{LobbyID: lobbyID})
+}
+
+// NewRemoveBotMessage creates a message removing a bot from a lobby
+func NewRemoveBotMessage(lobbyID, botID string) *Message {
+	return NewMessage(MsgRemoveBot, RemoveBotMessage{LobbyID: lobbyID, BotID: botID})
+}
+
+// ParseRoom parses a room join/leave message
+func ParseRoom(data []byte) (*RoomMessage, error) {
+	var msg RoomMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// NewJoinRoomMessage creates a join room message
+func NewJoinRoomMessage(roomID string) *Message {
+	return NewMessage(MsgJoinRoom, RoomMessage{RoomID: roomID})
+}
+
+// NewLeaveRoomMessage creates a leave room message
+func NewLeaveRoomMessage(roomID string) *Message {
+	return NewMessage(MsgLeaveRoom, RoomMessage{RoomID: roomID})
+}
@@ -0,0 +1,215 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewJoinRoomMessage(t *testing.T) {
+	data, err := json.Marshal(NewJoinRoomMessage("room-1"))
+	if err != nil {
+		t.Fatalf("Failed to marshal join room message: %v", err)
+	}
+
+	expected := `{"type":"join_room","data":{"roomId":"room-1"}}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, string(data))
+	}
+}
+
+func TestNewLeaveRoomMessage(t *testing.T) {
+	data, err := json.Marshal(NewLeaveRoomMessage("room-1"))
+	if err != nil {
+		t.Fatalf("Failed to marshal leave room message: %v", err)
+	}
+
+	expected := `{"type":"leave_room","data":{"roomId":"room-1"}}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, string(data))
+	}
+}
+
+func TestParseRoom(t *testing.T) {
+	msg, err := ParseRoom([]byte(`{"type":"join_room","roomId":"room-42"}`))
+	if err != nil {
+		t.Fatalf("Failed to parse room message: %v", err)
+	}
+	if msg.RoomID != "room-42" {
+		t.Errorf("Expected roomId to be 'room-42', got %s", msg.RoomID)
+	}
+}
+
+func TestParseMessageRoomContext(t *testing.T) {
+	msg, err := ParseMessage([]byte(`{"type":"move_made","roomId":"room-42","row":1,"col":2,"player":1}`))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	if msg.Type != MsgMoveMade {
+		t.Errorf("Expected type move_made, got %s", msg.Type)
+	}
+	if msg.RoomID != "room-42" {
+		t.Errorf("Expected roomId to be 'room-42', got %s", msg.RoomID)
+	}
+
+	msg, err = ParseMessage([]byte(`{"type":"move_made","row":1,"col":2,"player":1}`))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	if msg.RoomID != "" {
+		t.Errorf("Expected empty roomId for own game message, got %s", msg.RoomID)
+	}
+}
+
+func TestParseUsersUpdate(t *testing.T) {
+	data := []byte(`{"type":"users_update","users":[` +
+		`{"id":"u1","name":"alice","status":"idle"},` +
+		`{"id":"u2","name":"bob","status":"in_lobby","lobbyId":"l1"}]}`)
+
+	msg, err := ParseUsersUpdate(data)
+	if err != nil {
+		t.Fatalf("Failed to parse users update: %v", err)
+	}
+	if len(msg.Users) != 2 {
+		t.Fatalf("Expected 2 users, got %d", len(msg.Users))
+	}
+	if msg.Users[0].ID != "u1" || msg.Users[0].Name != "alice" || msg.Users[0].Status != "idle" {
+		t.Errorf("Unexpected first user: %+v", msg.Users[0])
+	}
+	if msg.Users[1].LobbyID != "l1" {
+		t.Errorf("Expected lobbyId l1, got %q", msg.Users[1].LobbyID)
+	}
+}
+
+func TestParsePlayersUpdate(t *testing.T) {
+	data := []byte(`{"type":"players_update","players":[` +
+		`{"id":1,"name":"alice","symbol":1,"position":{"row":0,"col":0}},` +
+		`{"id":2,"name":"bob","symbol":2,"position":{"row":9,"col":9},"eliminated":true}]}`)
+
+	msg, err := ParsePlayersUpdate(data)
+	if err != nil {
+		t.Fatalf("Failed to parse players update: %v", err)
+	}
+	if len(msg.Players) != 2 {
+		t.Fatalf("Expected 2 players, got %d", len(msg.Players))
+	}
+	if msg.Players[0].Eliminated || msg.Players[0].Name != "alice" {
+		t.Errorf("Unexpected first player: %+v", msg.Players[0])
+	}
+	if !msg.Players[1].Eliminated || msg.Players[1].Position != (Position{Row: 9, Col: 9}) {
+		t.Errorf("Unexpected second player: %+v", msg.Players[1])
+	}
+}
+
+func TestParseChallengeSent(t *testing.T) {
+	msg, err := ParseChallengeSent([]byte(`{"type":"challenge_sent","challengeId":"c1","targetUserId":"u1"}`))
+	if err != nil {
+		t.Fatalf("Failed to parse challenge sent: %v", err)
+	}
+	if msg.ChallengeID != "c1" || msg.TargetUserID != "u1" {
+		t.Errorf("Unexpected challenge sent: %+v", msg)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		data, message, code string
+	}{
+		{`{"type":"error","message":"Invalid move","code":"invalid_move"}`, "Invalid move", "invalid_move"},
+		{`{"type":"error","error":"Not your turn"}`, "Not your turn", ""},
+	}
+	for _, tt := range tests {
+		msg, err := ParseError([]byte(tt.data))
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", tt.data, err)
+		}
+		if msg.Message != tt.message || msg.Code != tt.code {
+			t.Errorf("ParseError(%s) = %+v, want message %q and code %q", tt.data, msg, tt.message, tt.code)
+		}
+	}
+}
+
+func TestIsMoveError(t *testing.T) {
+	tests := []struct {
+		msg  ErrorMessage
+		want bool
+	}{
+		{ErrorMessage{Message: "Invalid move", Code: "invalid_move"}, true},
+		{ErrorMessage{Message: "Cell is already fortified"}, true},
+		{ErrorMessage{Message: "Not your turn"}, true},
+		{ErrorMessage{Message: "Lobby is full", Code: "lobby_full"}, false},
+		{ErrorMessage{Message: "Rate limited"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.msg.IsMoveError(); got != tt.want {
+			t.Errorf("IsMoveError(%+v) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestParseGameState(t *testing.T) {
+	data := []byte(`{"type":"game_state","gameId":"g1","currentPlayer":2,"movesLeft":3,` +
+		`"players":[{"id":1,"name":"VirusBot","symbol":1,"position":{"row":0,"col":0}},` +
+		`{"id":2,"name":"alice","symbol":2,"position":{"row":4,"col":4}}],` +
+		`"board":[[17,1,0,0,0],[1,33,0,0,0],[0,0,6,0,0],[0,0,0,34,2],[0,0,0,2,18]]}`)
+
+	msg, err := ParseGameState(data)
+	if err != nil {
+		t.Fatalf("Failed to parse game state: %v", err)
+	}
+	if msg.GameID != "g1" || msg.CurrentPlayer == nil || *msg.CurrentPlayer != 2 || msg.MovesLeft != 3 {
+		t.Errorf("Unexpected game state header: %+v", msg)
+	}
+	if len(msg.Board) != 5 || len(msg.Board[4]) != 5 || len(msg.Players) != 2 {
+		t.Fatalf("Expected a 5x5 board and two players, got %+v", msg)
+	}
+	if cell := msg.Board[0][0]; !cell.IsBase() || cell.Player() != 1 {
+		t.Errorf("Expected player 1's base at (0,0), got %d", cell)
+	}
+	if cell := msg.Board[3][3]; !cell.IsFortified() || cell.Player() != 2 {
+		t.Errorf("Expected a fortified player 2 cell at (3,3), got %d", cell)
+	}
+
+	if _, err := ParseGameState([]byte(`{"type":"game_state","gameId":"g1"}`)); err == nil {
+		t.Error("Expected a snapshot without a board to be rejected")
+	}
+}
+
+func TestParseLobby(t *testing.T) {
+	data := []byte(`{"type":"lobby_update","lobbyId":"l1","hostId":1,"boardSize":12,"players":[` +
+		`{"id":1,"name":"VirusBot","symbol":1,"position":{"row":0,"col":0},"isAI":true},` +
+		`{"id":2,"name":"alice","symbol":2,"position":{"row":11,"col":11}}]}`)
+
+	msg, err := ParseLobby(data)
+	if err != nil {
+		t.Fatalf("Failed to parse lobby update: %v", err)
+	}
+	if msg.LobbyID != "l1" || msg.HostID != 1 || msg.BoardSize != 12 {
+		t.Errorf("Unexpected lobby: %+v", msg)
+	}
+	if len(msg.Players) != 2 {
+		t.Fatalf("Expected 2 players, got %d", len(msg.Players))
+	}
+	if !msg.Players[0].IsAI || msg.Players[1].Name != "alice" || msg.Players[1].Position != (Position{Row: 11, Col: 11}) {
+		t.Errorf("Unexpected players: %+v", msg.Players)
+	}
+}
+
+func TestPlaceNeutralsMessageRoundTrip(t *testing.T) {
+	data, err := json.Marshal(NewPlaceNeutralsMessage("g1", []Position{{Row: 1, Col: 2}, {Row: 1, Col: 3}}))
+	if err != nil {
+		t.Fatalf("Failed to marshal place neutrals message: %v", err)
+	}
+
+	expected := `{"type":"place_neutrals","gameId":"g1","positions":[{"row":1,"col":2},{"row":1,"col":3}]}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, string(data))
+	}
+
+	msg, err := ParsePlaceNeutrals(data)
+	if err != nil {
+		t.Fatalf("Failed to parse place neutrals message: %v", err)
+	}
+	if msg.GameID != "g1" || len(msg.Positions) != 2 || msg.Positions[1] != (Position{Row: 1, Col: 3}) {
+		t.Errorf("Unexpected place neutrals message: %+v", msg)
+	}
+}
@@ -0,0 +1,151 @@
+// Package replay records the server messages of a game to a newline-delimited
+// JSON file and rebuilds the game from such a file.
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"virusbot/config"
+	"virusbot/internal/client"
+	"virusbot/internal/protocol"
+)
+
+// Header is the first line of a replay file
+type Header struct {
+	GameID     string    `json:"gameId"`
+	YourPlayer int       `json:"yourPlayer"`
+	Rows       int       `json:"rows"`
+	Cols       int       `json:"cols"`
+	Started    time.Time `json:"started"`
+}
+
+// Event is one recorded server message, one per line after the header
+type Event struct {
+	Time    time.Time            `json:"time"`
+	Type    protocol.MessageType `json:"type"`
+	Message json.RawMessage      `json:"message"`
+}
+
+// Recorder writes one replay file per game into a directory. It implements
+// client.Recorder and is safe for concurrent use.
+type Recorder struct {
+	dir string
+
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+}
+
+// NewRecorder records games into dir, which is created when needed
+func NewRecorder(dir string) *Recorder {
+	return &Recorder{dir: dir}
+}
+
+// StartGame closes the previous game's file, if any, and starts a new one
+// named after the game and our player
+func (r *Recorder) StartGame(gameID string, yourPlayer, rows, cols int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create replay directory: %w", err)
+	}
+
+	now := time.Now()
+	name := gameID
+	if name == "" {
+		name = "game-" + now.Format("20060102-150405.000")
+	}
+	path := filepath.Join(r.dir, fmt.Sprintf("%s-p%d.jsonl", name, yourPlayer))
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create replay file: %w", err)
+	}
+
+	r.file = file
+	r.w = bufio.NewWriter(file)
+	r.enc = json.NewEncoder(r.w)
+	return r.enc.Encode(Header{GameID: gameID, YourPlayer: yourPlayer, Rows: rows, Cols: cols, Started: now})
+}
+
+// Record appends a server message to the current game's file. Messages
+// outside a game are dropped.
+func (r *Recorder) Record(msgType protocol.MessageType, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.enc == nil {
+		return nil
+	}
+	event := Event{Time: time.Now(), Type: msgType, Message: json.RawMessage(data)}
+	if err := r.enc.Encode(event); err != nil {
+		return fmt.Errorf("failed to record %s: %w", msgType, err)
+	}
+	return nil
+}
+
+// Close flushes and closes the current game's file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.close()
+}
+
+// close is Close for callers holding r.mu
+func (r *Recorder) close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.w.Flush()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.file, r.w, r.enc = nil, nil, nil
+	if err != nil {
+		return fmt.Errorf("failed to close replay file: %w", err)
+	}
+	return nil
+}
+
+// Load replays a recorded game through a fresh client and returns the state
+// it ends in
+func Load(path string) (*client.GameState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay: %w", err)
+	}
+	defer file.Close()
+
+	dec := json.NewDecoder(file)
+	var header Header
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("failed to read replay header: %w", err)
+	}
+
+	c := client.NewClient(&config.Config{DefaultBoardSize: header.Rows}, nil)
+	for dec.More() {
+		var event Event
+		if err := dec.Decode(&event); err != nil {
+			return nil, fmt.Errorf("failed to read replay event: %w", err)
+		}
+		if err := c.HandleMessage(event.Message); err != nil {
+			return nil, fmt.Errorf("failed to replay %s: %w", event.Type, err)
+		}
+	}
+
+	state := c.GetGameState()
+	if state == nil {
+		return nil, fmt.Errorf("replay %s has no game_start", path)
+	}
+	return state, nil
+}
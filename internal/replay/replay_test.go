@@ -0,0 +1,64 @@
+package replay
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"virusbot/config"
+	"virusbot/internal/client"
+)
+
+func TestRecordAndLoadRebuildsFinalState(t *testing.T) {
+	dir := t.TempDir()
+	c := client.NewClient(&config.Config{}, nil)
+	c.SetRecorder(NewRecorder(dir))
+
+	messages := []string{
+		`{"type":"game_start","gameId":"g1","yourPlayer":1,"rows":4,"cols":4}`,
+		`{"type":"move_made","gameId":"g1","row":0,"col":1,"player":1,"movesLeft":2}`,
+		`{"type":"move_made","gameId":"g1","row":1,"col":1,"player":1,"movesLeft":1}`,
+		`{"type":"move_made","gameId":"g1","row":2,"col":2,"player":1,"movesLeft":0}`,
+		`{"type":"turn_change","gameId":"g1","player":2,"movesLeft":3}`,
+		`{"type":"move_made","gameId":"g1","row":2,"col":2,"player":2,"movesLeft":2}`,
+		`{"type":"users_update","users":[]}`,
+		`{"type":"game_end","winner":2}`,
+	}
+	for _, m := range messages {
+		if err := c.HandleMessage([]byte(m)); err != nil {
+			t.Fatalf("HandleMessage failed: %v", err)
+		}
+	}
+
+	path := filepath.Join(dir, "g1-p1.jsonl")
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected a replay file: %v", err)
+	}
+	lines := 0
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		lines++
+	}
+	file.Close()
+	// The header plus every game message, without the users_update
+	if lines != 1+len(messages)-1 {
+		t.Errorf("Expected %d lines, got %d", len(messages), lines)
+	}
+
+	state, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := c.GetGameState()
+	if !reflect.DeepEqual(state.Board, want.Board) {
+		t.Errorf("Replayed board differs:\n%v\nwant:\n%v", state.Board, want.Board)
+	}
+	if state.CurrentPlayer != want.CurrentPlayer || state.YourPlayerID != 1 {
+		t.Errorf("Expected player 1 with player %d to move, got %+v", want.CurrentPlayer, state)
+	}
+	if cell := state.Board[2][2]; cell.Player() != 2 || !cell.IsFortified() {
+		t.Errorf("Expected the attacked cell to be a fortified player 2 cell, got %v", cell)
+	}
+}
@@ -0,0 +1,220 @@
+// Package selfplay plays two strategies against each other on an in-memory
+// board, without a server, so they can be compared and tuned offline.
+package selfplay
+
+import (
+	"fmt"
+
+	"virusbot/internal/game"
+	"virusbot/internal/protocol"
+	"virusbot/internal/strategy"
+)
+
+// Result summarizes a series of games between two strategies
+type Result struct {
+	Games      int
+	FirstWins  int
+	SecondWins int
+	Draws      int
+	Turns      int // turns played over every game
+}
+
+// FirstWinRate returns the share of games the first strategy won
+func (r Result) FirstWinRate() float64 {
+	return r.rate(r.FirstWins)
+}
+
+// SecondWinRate returns the share of games the second strategy won
+func (r Result) SecondWinRate() float64 {
+	return r.rate(r.SecondWins)
+}
+
+// AverageLength returns the average number of turns per game
+func (r Result) AverageLength() float64 {
+	return r.rate(r.Turns)
+}
+
+func (r Result) rate(n int) float64 {
+	if r.Games == 0 {
+		return 0
+	}
+	return float64(n) / float64(r.Games)
+}
+
+// String formats the result for a report
+func (r Result) String() string {
+	return fmt.Sprintf("%d games: first won %.1f%%, second won %.1f%%, %d drawn, %.1f turns per game",
+		r.Games, 100*r.FirstWinRate(), 100*r.SecondWinRate(), r.Draws, r.AverageLength())
+}
+
+// Play runs games between first and second on a size x size board. The
+// strategies swap seats every game so neither always moves first. Passing
+// the same strategy twice plays it against a clone of itself.
+func Play(first, second strategy.Strategy, size, games int) Result {
+	if first == second {
+		second = second.Clone()
+	}
+
+	var result Result
+	for i := 0; i < games; i++ {
+		// Credit wins by seat: a strategy's clone may be the strategy itself
+		firstSeat := i % 2
+		seats := [2]strategy.Strategy{first, second}
+		if firstSeat == 1 {
+			seats = [2]strategy.Strategy{second, first}
+		}
+		winner, turns := PlayGame(seats, size)
+		result.Games++
+		result.Turns += turns
+		switch winner {
+		case -1:
+			result.Draws++
+		case firstSeat:
+			result.FirstWins++
+		default:
+			result.SecondWins++
+		}
+	}
+	return result
+}
+
+// PlayGame plays one game with seats[0] as player 1 and seats[1] as player
+// 2, from their bases in opposite corners. It returns the index of the
+// winning seat, or -1 for a draw, and the number of turns played. A game
+// ends when a player is wiped out, when nobody moves for a full round or
+// after maxTurns. Bases cannot be attacked, so a wipe-out is rare; the
+// other endings go to the player owning more cells, or are a draw.
+func PlayGame(seats [2]strategy.Strategy, size int) (winner, turns int) {
+	return playGame(seats, size, maxTurns(size))
+}
+
+// playGame is PlayGame ending the game after turnCap turns
+func playGame(seats [2]strategy.Strategy, size, turnCap int) (winner, turns int) {
+	board := game.GenerateBoard(size, 0, 0)
+	players := make([]*game.Player, len(seats))
+	for i, s := range seats {
+		id := i + 1
+		players[i] = game.NewPlayer(id, s.Name(), protocol.CellType(id), board.BasePos[id])
+		s.Reset()
+	}
+	state := &game.GameState{Board: board, Players: players, CurrentPlayer: 1}
+
+	passes := 0
+	for turns < turnCap {
+		var moved bool
+		state, moved = playTurn(state, seats)
+		turns++
+
+		alive := updateLiveness(state)
+		if done, winner := state.IsTerminal(); done {
+			if winner == game.Draw {
+				return territoryWinner(state), turns
+			}
+			return winner - 1, turns
+		}
+		if moved {
+			passes = 0
+		} else if passes++; passes >= alive {
+			break
+		}
+		if current := state.GetCurrentPlayer(); current == nil || !current.IsAlive {
+			state.AdvancePlayer()
+		}
+	}
+	return territoryWinner(state), turns
+}
+
+// territoryWinner returns the seat of the player owning more cells, or -1
+// when both own as many
+func territoryWinner(state *game.GameState) int {
+	first, second := state.Board.CountCells(1), state.Board.CountCells(2)
+	switch {
+	case first > second:
+		return 0
+	case second > first:
+		return 1
+	}
+	return -1
+}
+
+// maxTurns bounds a game on a size x size board. Every move takes an empty
+// cell or fortifies one, so a real game ends well within it.
+func maxTurns(size int) int {
+	return 2 * size * size
+}
+
+// playTurn lets the current player's strategy play its turn and reports
+// whether it changed the board. Moves that are not legal when their turn
+// comes are dropped, as the server would reject them; with no move at all
+// the strategy may place its neutrals, or passes.
+func playTurn(state *game.GameState, seats [2]strategy.Strategy) (*game.GameState, bool) {
+	id := state.CurrentPlayer
+	s := seats[id-1]
+	view := state.Clone()
+	view.YourPlayerID = id
+	view.MovesLeft = state.TurnMovesLeft()
+
+	moved := false
+	for _, planned := range s.DecideMoves(view, view.MovesLeft) {
+		if state.CurrentPlayer != id {
+			break
+		}
+		if planned.Type == game.MoveNeutral {
+			// A neutral placement is a turn of its own
+			if player := state.GetPlayer(id); !moved && !player.HasUsedNeutrals && game.ValidMove(state.Board, id, planned) {
+				return state.ApplyMove(planned), true
+			}
+			continue
+		}
+		move, ok := legalMove(state, id, planned.Position)
+		if !ok {
+			continue
+		}
+		state = state.ApplyMove(move)
+		moved = true
+		for seat, other := range seats {
+			other.OnMoveMade(&game.GameState{CurrentPlayer: id, YourPlayerID: seat + 1}, move)
+		}
+	}
+	if state.CurrentPlayer != id {
+		return state, moved
+	}
+
+	if !moved {
+		if neutrals := s.DecideNeutrals(view); len(neutrals) >= 2 {
+			move := game.Move{Position: neutrals[0], Pair: neutrals[1], Type: game.MoveNeutral}
+			if player := state.GetPlayer(id); !player.HasUsedNeutrals && game.ValidMove(state.Board, id, move) {
+				return state.ApplyMove(move), true
+			}
+		}
+	}
+
+	// Out of moves early: pass the rest of the turn
+	state = state.Clone()
+	state.AdvancePlayer()
+	return state, moved
+}
+
+// legalMove returns the grow or attack the player can make on pos, typed
+// from the board rather than trusting the strategy
+func legalMove(state *game.GameState, playerID int, pos game.Position) (game.Move, bool) {
+	for _, move := range state.Board.GetValidMoves(playerID) {
+		if move.Position == pos {
+			return move, true
+		}
+	}
+	return game.Move{}, false
+}
+
+// updateLiveness marks players without cells dead and returns how many are
+// left
+func updateLiveness(state *game.GameState) int {
+	alive := 0
+	for _, p := range state.Players {
+		p.IsAlive = state.Board.IsAlive(p.ID)
+		if p.IsAlive {
+			alive++
+		}
+	}
+	return alive
+}
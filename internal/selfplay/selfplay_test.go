@@ -0,0 +1,51 @@
+package selfplay
+
+import (
+	"testing"
+
+	"virusbot/config"
+	"virusbot/internal/strategy"
+)
+
+func TestHeuristicSelfPlayTerminates(t *testing.T) {
+	cfg := &config.Config{}
+	heuristic := strategy.NewHeuristicStrategy(cfg)
+
+	const size, games = 6, 2
+	result := Play(heuristic, heuristic, size, games)
+
+	if result.Games != games {
+		t.Fatalf("Expected %d games, got %d", games, result.Games)
+	}
+	if result.FirstWins+result.SecondWins+result.Draws != games {
+		t.Errorf("Expected every game to be won or drawn, got %+v", result)
+	}
+	if result.Turns == 0 || result.AverageLength() >= float64(maxTurns(size)) {
+		t.Errorf("Expected games to end before the turn cap, got %.1f turns per game", result.AverageLength())
+	}
+}
+
+func TestTurnCapDecidesByTerritory(t *testing.T) {
+	heuristic := strategy.NewHeuristicStrategy(&config.Config{})
+	seats := [2]strategy.Strategy{heuristic, heuristic.Clone()}
+
+	// After one turn only the first player has grown
+	winner, turns := playGame(seats, 6, 1)
+	if winner != 0 || turns != 1 {
+		t.Errorf("Expected the first seat to win on territory after 1 turn, got winner %d after %d turns", winner, turns)
+	}
+}
+
+func TestPlayCreditsWinsBySeat(t *testing.T) {
+	// Greedy is deterministic and its clone is itself, so the same seat
+	// wins every game and the strategies, swapping seats, split the wins
+	greedy := strategy.NewGreedyStrategy(&config.Config{})
+
+	result := Play(greedy, greedy, 6, 2)
+	if result.Draws != 0 {
+		t.Fatalf("Expected decisive games, got %+v", result)
+	}
+	if result.FirstWins != 1 || result.SecondWins != 1 {
+		t.Errorf("Expected the wins to be split between the seats, got %+v", result)
+	}
+}
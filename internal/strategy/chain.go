@@ -0,0 +1,144 @@
+package strategy
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"virusbot/internal/game"
+	"virusbot/internal/logging"
+)
+
+// ChainStrategy tries an ordered list of strategies until one returns moves
+// within its time budget, so the bot always has something to play
+type ChainStrategy struct {
+	strategies []Strategy
+	stepLimit  time.Duration
+	logger     logging.Logger
+	// busy marks members whose abandoned call is still running
+	busy []atomic.Bool
+}
+
+// NewChainStrategy creates a fallback chain. Each strategy gets stepLimit to
+// decide before the next one is tried; zero means no limit.
+func NewChainStrategy(strategies []Strategy, stepLimit time.Duration, logger logging.Logger) *ChainStrategy {
+	return &ChainStrategy{
+		strategies: strategies,
+		stepLimit:  stepLimit,
+		logger:     logger,
+		busy:       make([]atomic.Bool, len(strategies)),
+	}
+}
+
+// Name returns the strategy name, listing the chain members in order
+func (s *ChainStrategy) Name() string {
+	names := make([]string, len(s.strategies))
+	for i, strategy := range s.strategies {
+		names[i] = strategy.Name()
+	}
+	return "chain(" + strings.Join(names, ",") + ")"
+}
+
+// DecideMoves returns the moves of the first strategy that produces any in time
+func (s *ChainStrategy) DecideMoves(state *game.GameState, count int) []game.Move {
+	for i, strategy := range s.strategies {
+		if s.busy[i].Load() {
+			s.logger.Warnf("Strategy %s is still running a timed-out call, skipping", strategy.Name())
+			continue
+		}
+		moves, ok := s.decideWithLimit(i, state, count)
+		if ok && len(moves) > 0 {
+			return moves
+		}
+		if ok {
+			s.logger.Debugf("Strategy %s returned no moves, falling back", strategy.Name())
+		} else {
+			s.logger.Warnf("Strategy %s exceeded %v, falling back", strategy.Name(), s.stepLimit)
+		}
+	}
+	return nil
+}
+
+// decideWithLimit runs the i-th strategy, reporting false if it missed its
+// budget. A budgeted strategy is handed the limit and trusted to keep it.
+// Any other strategy runs on its own copy of the state and is marked busy
+// until it returns, so an abandoned call is never overlapped by the next one.
+func (s *ChainStrategy) decideWithLimit(i int, state *game.GameState, count int) ([]game.Move, bool) {
+	strategy := s.strategies[i]
+	if s.stepLimit <= 0 {
+		return strategy.DecideMoves(state, count), true
+	}
+	if b, ok := strategy.(Budgeted); ok {
+		return b.DecideMovesWithin(state, count, s.stepLimit), true
+	}
+
+	result := make(chan []game.Move, 1)
+	snapshot := state.Clone()
+	s.busy[i].Store(true)
+	go func() {
+		defer s.busy[i].Store(false)
+		result <- strategy.DecideMoves(snapshot, count)
+	}()
+
+	timer := time.NewTimer(s.stepLimit)
+	defer timer.Stop()
+
+	select {
+	case moves := <-result:
+		return moves, true
+	case <-timer.C:
+		return nil, false
+	}
+}
+
+// DecideNeutrals returns the placement of the first strategy that chooses any
+func (s *ChainStrategy) DecideNeutrals(state *game.GameState) []game.Position {
+	for _, strategy := range s.strategies {
+		if positions := strategy.DecideNeutrals(state); len(positions) > 0 {
+			return positions
+		}
+	}
+	return nil
+}
+
+// OnMoveMade forwards the move to every strategy in the chain
+func (s *ChainStrategy) OnMoveMade(state *game.GameState, move game.Move) {
+	for _, strategy := range s.strategies {
+		strategy.OnMoveMade(state, move)
+	}
+}
+
+// SetFactors replaces the weights of every tunable strategy in the chain
+func (s *ChainStrategy) SetFactors(factors EvaluationFactors) {
+	for _, strategy := range s.strategies {
+		if tunable, ok := strategy.(Tunable); ok {
+			tunable.SetFactors(factors)
+		}
+	}
+}
+
+// Reset resets every strategy in the chain
+func (s *ChainStrategy) Reset() {
+	for _, strategy := range s.strategies {
+		strategy.Reset()
+	}
+}
+
+// Clone returns a chain of clones
+func (s *ChainStrategy) Clone() Strategy {
+	strategies := make([]Strategy, len(s.strategies))
+	for i, strategy := range s.strategies {
+		strategies[i] = strategy.Clone()
+	}
+	return NewChainStrategy(strategies, s.stepLimit, s.logger)
+}
+
+// WarmUp warms up every strategy in the chain that supports it
+func (s *ChainStrategy) WarmUp(ctx context.Context, state *game.GameState) {
+	for _, strategy := range s.strategies {
+		if w, ok := strategy.(WarmUpper); ok {
+			w.WarmUp(ctx, state)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package strategy
+
+import (
+	"virusbot/config"
+	"virusbot/internal/game"
+	"virusbot/internal/logging"
+)
+
+// maxPlanBranch bounds the attacks tried at each step of the turn plan
+const maxPlanBranch = 12
+
+// DisconnectStrategy plans the whole turn to cut as much of the strongest
+// opponent's territory off from their base as possible. Combinations of
+// attacks can sever an arm that no single attack would. When no sequence
+// disconnects anything it plays like the heuristic.
+type DisconnectStrategy struct {
+	movesPerTurn int
+	fallback     *HeuristicStrategy
+	logger       logging.Logger
+}
+
+// NewDisconnectStrategy creates a new disconnection planner
+func NewDisconnectStrategy(cfg *config.Config) *DisconnectStrategy {
+	turnLength := cfg.MovesPerTurn
+	if turnLength <= 0 {
+		turnLength = movesPerTurn
+	}
+	return &DisconnectStrategy{
+		movesPerTurn: turnLength,
+		fallback:     NewHeuristicStrategy(cfg),
+		logger:       cfg.Logger(),
+	}
+}
+
+// Name returns the strategy name
+func (s *DisconnectStrategy) Name() string {
+	return "disconnect"
+}
+
+// DecideMoves returns the first count moves of the best disconnecting plan,
+// topped up with heuristic moves if the plan is shorter
+func (s *DisconnectStrategy) DecideMoves(state *game.GameState, count int) []game.Move {
+	if !state.IsMyTurn() {
+		return nil
+	}
+
+	player := state.GetYourPlayer()
+	target := s.strongestOpponent(state)
+	if player == nil || target == 0 {
+		return s.fallback.DecideMoves(state, count)
+	}
+
+	plan, cut := s.PlanTurn(state.Board, player.ID, target)
+	if cut == 0 {
+		return s.fallback.DecideMoves(state, count)
+	}
+	s.logger.Debugf("Disconnect plan %v cuts %d cells from player %d", plan, cut, target)
+
+	if len(plan) >= count {
+		return plan[:count]
+	}
+
+	// Top up from the position the plan leaves behind
+	after := state.Clone()
+	for _, move := range plan {
+		after.Board = after.Board.ApplyMove(move.Position, player.ID, move.Fortifies())
+	}
+	return append(plan, s.fallback.DecideMoves(after, count-len(plan))...)
+}
+
+// PlanTurn searches attack sequences of up to a turn's moves and returns
+// the one disconnecting the most of the victim's cells, preferring shorter
+// sequences on ties, together with the number of cells it cuts off. The
+// turn length announced by the game takes precedence over the configured one.
+func (s *DisconnectStrategy) PlanTurn(board *game.Board, playerID, victimID int) ([]game.Move, int) {
+	before := len(board.GetReachableCells(victimID))
+	depth := s.movesPerTurn
+	if board.Rules.MovesPerTurn > 0 {
+		depth = board.Rules.MovesPerTurn
+	}
+
+	var best []game.Move
+	bestCut := 0
+	var search func(b *game.Board, plan []game.Move, captured int)
+	search = func(b *game.Board, plan []game.Move, captured int) {
+		if len(plan) > 0 {
+			cut := before - len(b.GetReachableCells(victimID)) - captured
+			if cut > bestCut || (cut == bestCut && cut > 0 && len(plan) < len(best)) {
+				bestCut = cut
+				best = append([]game.Move(nil), plan...)
+			}
+		}
+		if len(plan) == depth {
+			return
+		}
+
+		for _, move := range s.candidateAttacks(b, playerID, victimID) {
+			next := b.ApplyMove(move.Position, playerID, true)
+			search(next, append(plan, move), captured+1)
+		}
+	}
+	search(board, nil, 0)
+
+	return best, bestCut
+}
+
+// candidateAttacks returns one attack per victim cell we can reach, capped
+// at maxPlanBranch to keep the search small
+func (s *DisconnectStrategy) candidateAttacks(b *game.Board, playerID, victimID int) []game.Move {
+	seen := make(map[game.Position]bool)
+	attacks := make([]game.Move, 0)
+	for _, move := range b.GetAttackMoves(playerID) {
+		if seen[move.Position] || !b.IsOwnedBy(move.Position, victimID) {
+			continue
+		}
+		seen[move.Position] = true
+		attacks = append(attacks, move)
+		if len(attacks) == maxPlanBranch {
+			break
+		}
+	}
+	return attacks
+}
+
+// strongestOpponent returns the alive opponent owning the most cells
+func (s *DisconnectStrategy) strongestOpponent(state *game.GameState) int {
+	best, bestCells := 0, 0
+	for _, opp := range state.GetOpponents() {
+		if cells := state.Board.CountCells(opp.ID); cells > bestCells {
+			best, bestCells = opp.ID, cells
+		}
+	}
+	return best
+}
+
+// DecideNeutrals uses the heuristic placement
+func (s *DisconnectStrategy) DecideNeutrals(state *game.GameState) []game.Position {
+	return s.fallback.DecideNeutrals(state)
+}
+
+// OnMoveMade feeds the heuristic fallback's opponent model
+func (s *DisconnectStrategy) OnMoveMade(state *game.GameState, move game.Move) {
+	s.fallback.OnMoveMade(state, move)
+}
+
+// SetFactors replaces the heuristic fallback's weights
+func (s *DisconnectStrategy) SetFactors(factors EvaluationFactors) {
+	s.fallback.SetFactors(factors)
+}
+
+// Reset resets the heuristic fallback
+func (s *DisconnectStrategy) Reset() {
+	s.fallback.Reset()
+}
+
+// Clone returns a copy with its own heuristic fallback
+func (s *DisconnectStrategy) Clone() Strategy {
+	clone := *s
+	clone.fallback = s.fallback.clone()
+	return &clone
+}
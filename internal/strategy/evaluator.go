@@ -1,18 +1,39 @@
 package strategy
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
 	"virusbot/config"
 	"virusbot/internal/game"
+	"virusbot/internal/logging"
+	"virusbot/internal/protocol"
 )
 
-// EvaluationFactors contains weights for different scoring factors
+// EvaluationFactors contains weights for different scoring factors. The
+// JSON names follow the VIRUSBOT_WGT_* variables.
 type EvaluationFactors struct {
-	TerritoryGain      float64 // +10 per cell captured
-	StrategicPosition  float64 // +5 for edge, +8 for corner
-	ThreatRemoval      float64 // +15 for attacking
-	Connectivity       float64 // +3 for reconnecting cut-off groups
-	ExpansionPotential float64 // +4 for cells with multiple empty neighbors
-	DefensiveValue     float64 // +2 for cells adjacent to own territory
+	TerritoryGain      float64 `json:"territory"`          // +10 per cell captured
+	StrategicPosition  float64 `json:"strategic"`          // +5 for edge, +8 for corner
+	ThreatRemoval      float64 `json:"threat"`             // +15 for attacking
+	Connectivity       float64 `json:"connectivity"`       // +3 for reconnecting cut-off groups
+	ExpansionPotential float64 `json:"expansion"`          // +4 for cells with multiple empty neighbors, +1 per perimeter cell added while uncontested
+	DefensiveValue     float64 `json:"defensive"`          // +2 for cells adjacent to own territory, +3 next to recent opponent moves
+	Mobility           float64 `json:"mobility"`           // +1 per future move target, -10 per target below a full turn
+	SpecialCapture     float64 `json:"special"`            // +25 for capturing a special cell
+	OpeningCenter      float64 `json:"opening_center"`     // opening: shifts the edge/corner bonus toward up to +8 at the center
+	EndgameEdge        float64 `json:"endgame_edge"`       // endgame: extra share of the edge/corner bonus
+	Compactness        float64 `json:"compactness"`        // +50 per unit of compactness gained, +6 for filling a hole, -4 per perimeter cell added under pressure
+	FFAAttackDamping   float64 `json:"ffa_attack_damping"` // free-for-all: share of the attack bonus dropped (0-1)
+	FFAExpansion       float64 `json:"ffa_expansion"`      // free-for-all: extra share of the expansion bonus
+	ThreatDecay        float64 `json:"threat_decay"`       // -1/d² per opponent cell d steps from our base after the move
 }
 
 // DefaultFactors returns the default evaluation factors
@@ -24,27 +45,76 @@ func DefaultFactors() EvaluationFactors {
 		Connectivity:       0.3,
 		ExpansionPotential: 0.4,
 		DefensiveValue:     0.2,
+		Mobility:           0.3,
+		SpecialCapture:     1.0,
+		OpeningCenter:      0.5,
+		EndgameEdge:        0.5,
+		Compactness:        0.5,
+		FFAAttackDamping:   0.5,
+		FFAExpansion:       0.5,
+		ThreatDecay:        5.0,
+	}
+}
+
+// FactorsFromConfig returns the weights set by the VIRUSBOT_WGT_* variables
+func FactorsFromConfig(cfg *config.Config) EvaluationFactors {
+	return EvaluationFactors{
+		TerritoryGain:      cfg.WeightTerritory,
+		StrategicPosition:  cfg.WeightStrategic,
+		ThreatRemoval:      cfg.WeightThreat,
+		Connectivity:       cfg.WeightConnectivity,
+		ExpansionPotential: cfg.WeightExpansion,
+		DefensiveValue:     cfg.WeightDefensive,
+		Mobility:           cfg.WeightMobility,
+		SpecialCapture:     cfg.WeightSpecial,
+		OpeningCenter:      cfg.WeightOpeningCenter,
+		EndgameEdge:        cfg.WeightEndgameEdge,
+		Compactness:        cfg.WeightCompactness,
+		FFAAttackDamping:   cfg.WeightFFAAttack,
+		FFAExpansion:       cfg.WeightFFAExpansion,
+		ThreatDecay:        cfg.WeightThreatDecay,
 	}
 }
 
+// LoadFactors reads weights from a JSON file such as {"threat": 2.5}.
+// Weights the file leaves out keep their value in base; unknown names are
+// an error, so a typo is not silently ignored.
+func LoadFactors(path string, base EvaluationFactors) (EvaluationFactors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return base, fmt.Errorf("failed to read weights: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	factors := base
+	if err := dec.Decode(&factors); err != nil {
+		return base, fmt.Errorf("failed to parse weights %s: %w", path, err)
+	}
+	return factors, nil
+}
+
 // HeuristicStrategy uses a multi-factor scoring system
 type HeuristicStrategy struct {
-	factors EvaluationFactors
-	debug   bool
+	mu            sync.RWMutex // guards factors, which SetFactors may change mid-game
+	factors       EvaluationFactors
+	maxCandidates int     // moves considered for scoring; 0 means all
+	normalize     bool    // scale size-dependent terms to the reference board
+	epsilon       float64 // chance of replacing each chosen move with a random one
+	rand          *rand.Rand
+	opponents     *OpponentModel
+	logger        logging.Logger
 }
 
 // NewHeuristicStrategy creates a new heuristic strategy
 func NewHeuristicStrategy(cfg *config.Config) *HeuristicStrategy {
 	return &HeuristicStrategy{
-		factors: EvaluationFactors{
-			TerritoryGain:      cfg.WeightTerritory,
-			StrategicPosition:  cfg.WeightStrategic,
-			ThreatRemoval:      cfg.WeightThreat,
-			Connectivity:       cfg.WeightConnectivity,
-			ExpansionPotential: cfg.WeightExpansion,
-			DefensiveValue:     cfg.WeightDefensive,
-		},
-		debug: cfg.Debug,
+		factors:       FactorsFromConfig(cfg),
+		maxCandidates: cfg.MaxCandidates,
+		normalize:     cfg.NormalizeEval,
+		epsilon:       cfg.HeuristicEpsilon,
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		opponents:     NewOpponentModel(),
+		logger:        cfg.Logger(),
 	}
 }
 
@@ -53,8 +123,26 @@ func (s *HeuristicStrategy) Name() string {
 	return "heuristic"
 }
 
+// Factors returns the weights in use
+func (s *HeuristicStrategy) Factors() EvaluationFactors {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.factors
+}
+
+// SetFactors replaces the weights from the next decision on. It is safe to
+// call while the strategy is deciding.
+func (s *HeuristicStrategy) SetFactors(factors EvaluationFactors) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.factors = factors
+}
+
 // DecideMoves selects the best moves for the current turn
 func (s *HeuristicStrategy) DecideMoves(state *game.GameState, count int) []game.Move {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	if !state.IsMyTurn() {
 		return nil
 	}
@@ -73,7 +161,7 @@ func (s *HeuristicStrategy) DecideMoves(state *game.GameState, count int) []game
 	// Filter out moves to already occupied cells (defensive check)
 	filteredMoves := make([]game.Move, 0, len(validMoves))
 	for _, move := range validMoves {
-		if state.Board.IsEmpty(move.Position) || state.Board.IsOpponent(move.Position, player.ID) {
+		if state.Board.IsGrowable(move.Position) || state.Board.IsOpponent(move.Position, player.ID) {
 			filteredMoves = append(filteredMoves, move)
 		}
 	}
@@ -83,11 +171,126 @@ func (s *HeuristicStrategy) DecideMoves(state *game.GameState, count int) []game
 	}
 
 	// Score each move
-	scoredMoves := s.scoreMoves(filteredMoves, state)
+	candidates := s.capCandidates(filteredMoves, state, player.ID, s.maxCandidates)
+	scoredMoves := s.scoreMoves(candidates, state)
+	s.respondToOpponents(scoredMoves)
+	s.guardCriticalCell(scoredMoves, state, player.ID)
 
 	// Select top moves with diversity
 	selected := s.selectDiverseMoves(scoredMoves, count)
 
+	return s.explore(selected, filteredMoves)
+}
+
+// capCandidates keeps at most max moves in the opening, so that scoring
+// stays fast on large open boards, where the first move alone may have
+// hundreds of targets. Once the board fills up every move is kept: there
+// are fewer of them, and defensive and edge moves matter. A max of zero or
+// less keeps every move. The caller holds s.mu.
+func (s *HeuristicStrategy) capCandidates(moves []game.Move, state *game.GameState, playerID, max int) []game.Move {
+	if !capApplies(moves, state, max) {
+		return moves
+	}
+
+	s.logger.Debugf("Candidate cap: considering %d of %d moves", max, len(moves))
+	return s.topCandidates(moves, state, playerID, max)
+}
+
+// topCandidates returns the max moves the evaluator scores best on their
+// local features alone, see Board.LocalFeatures. Neutral placements give
+// up the turn, so they come last. The caller holds s.mu.
+func (s *HeuristicStrategy) topCandidates(moves []game.Move, state *game.GameState, playerID, max int) []game.Move {
+	if len(moves) <= max {
+		return moves
+	}
+
+	phase := state.Phase()
+	scored := make([]scoredMove, len(moves))
+	for i, move := range moves {
+		scored[i] = scoredMove{move: move, score: s.scoreFeatures(state.Board.LocalFeatures(move), state, playerID, phase)}
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		ni, nj := scored[i].move.Type == game.MoveNeutral, scored[j].move.Type == game.MoveNeutral
+		if ni != nj {
+			return nj
+		}
+		return scored[i].score > scored[j].score
+	})
+
+	capped := make([]game.Move, max)
+	for i := range capped {
+		capped[i] = scored[i].move
+	}
+	return capped
+}
+
+// capApplies reports whether capCandidates cuts moves down to max
+func capApplies(moves []game.Move, state *game.GameState, max int) bool {
+	return max > 0 && len(moves) > max && state.Phase() == game.PhaseOpening
+}
+
+// searchCandidates is capCandidates without the log, for the searches that
+// cap every node. They rank with the heuristic's weights from outside its
+// DecideMoves.
+func (s *HeuristicStrategy) searchCandidates(moves []game.Move, state *game.GameState, playerID, max int) []game.Move {
+	if !capApplies(moves, state, max) {
+		return moves
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.topCandidates(moves, state, playerID, max)
+}
+
+// branchCandidates is topCandidates for the searches, which rank with the
+// heuristic's weights from outside its DecideMoves
+func (s *HeuristicStrategy) branchCandidates(moves []game.Move, state *game.GameState, playerID, max int) []game.Move {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.topCandidates(moves, state, playerID, max)
+}
+
+// turnOf returns up to count of moves, best first, as one turn. Placing
+// neutrals ends the turn, so a neutral placement is played alone when it
+// comes first and skipped otherwise.
+func turnOf(moves []game.Move, count int) []game.Move {
+	if len(moves) > 0 && moves[0].Type == game.MoveNeutral {
+		return moves[:1]
+	}
+	turn := make([]game.Move, 0, count)
+	for _, move := range moves {
+		if len(turn) == count {
+			break
+		}
+		if move.Type != game.MoveNeutral {
+			turn = append(turn, move)
+		}
+	}
+	return turn
+}
+
+// explore replaces each selected move with a random legal one with
+// probability epsilon, so self-play visits more varied positions
+func (s *HeuristicStrategy) explore(selected, legal []game.Move) []game.Move {
+	if s.epsilon <= 0 || len(legal) <= len(selected) {
+		return selected
+	}
+
+	chosen := make(map[game.Position]bool, len(selected))
+	for _, move := range selected {
+		chosen[move.Position] = true
+	}
+	for i := range selected {
+		if s.rand.Float64() >= s.epsilon {
+			continue
+		}
+		move := legal[s.rand.Intn(len(legal))]
+		if chosen[move.Position] {
+			continue
+		}
+		delete(chosen, selected[i].Position)
+		chosen[move.Position] = true
+		selected[i] = move
+	}
 	return selected
 }
 
@@ -98,77 +301,222 @@ func (s *HeuristicStrategy) scoreMoves(moves []game.Move, state *game.GameState)
 		return nil
 	}
 
+	features := state.Board.AnnotateMoves(moves, player.ID)
+	phase := state.Phase()
 	scored := make([]scoredMove, 0, len(moves))
-	for _, move := range moves {
-		score := s.evaluateMove(move, state, player.ID)
+	for _, f := range features {
 		scored = append(scored, scoredMove{
-			move:  move,
-			score: score,
+			move:  f.Move,
+			score: s.scoreFeatures(f, state, player.ID, phase),
 		})
 	}
 
 	return scored
 }
 
+// responseBonus rewards contesting the area where the opponents just moved
+const responseBonus = 3.0
+
+// respondToOpponents adds the response bonus to moves next to the latest
+// opponent moves recorded in the cached opponent model
+func (s *HeuristicStrategy) respondToOpponents(scored []scoredMove) {
+	recent := s.opponents.Recent()
+	if len(recent) == 0 {
+		return
+	}
+	for i := range scored {
+		if nearRecent(scored[i].move.Position, recent) {
+			scored[i].score += responseBonus * s.factors.DefensiveValue
+		}
+	}
+}
+
+// guardBonus rewards reinforcing around our most critical cell under threat
+const guardBonus = 4.0
+
+// guardCriticalCell adds the guard bonus to moves next to the cell whose
+// loss would cost us the most, when an opponent can attack it or a cell
+// beside it. A cell that holds only itself is not worth guarding.
+func (s *HeuristicStrategy) guardCriticalCell(scored []scoredMove, state *game.GameState, playerID int) {
+	critical, cost := state.MostCriticalCell(playerID)
+	if cost <= 1 {
+		return
+	}
+
+	danger := state.DangerMap(playerID)
+	threatened := danger[critical.Row][critical.Col]
+	for _, n := range state.Board.GetNeighbors(critical) {
+		threatened = threatened || danger[n.Row][n.Col]
+	}
+	if !threatened {
+		return
+	}
+
+	s.logger.Debugf("Guarding (%d, %d), which holds %.0f cells", critical.Row, critical.Col, cost)
+	for i := range scored {
+		if state.Board.IsAdjacent(scored[i].move.Position, critical) {
+			scored[i].score += guardBonus * s.factors.DefensiveValue
+		}
+	}
+}
+
 // evaluateMove evaluates a single move
 func (s *HeuristicStrategy) evaluateMove(move game.Move, state *game.GameState, playerID int) float64 {
-	board := state.Board
+	features := state.Board.AnnotateMoves([]game.Move{move}, playerID)
+	return s.scoreFeatures(features[0], state, playerID, state.Phase())
+}
+
+// scoreFeatures combines the weighted factors for an annotated move
+func (s *HeuristicStrategy) scoreFeatures(f game.MoveFeatures, state *game.GameState, playerID int, phase game.Phase) float64 {
 	score := 0.0
 
+	// With more than two players alive, fighting one opponent weakens us
+	// against the rest: attack less and expand quietly until the field thins
+	attackScale, expansionScale := 1.0, 1.0
+	if isFreeForAll(state) {
+		attackScale = 1 - math.Min(math.Max(s.factors.FFAAttackDamping, 0), 1)
+		expansionScale = 1 + s.factors.FFAExpansion
+	}
+	sizeScale := s.sizeScale(state.Board)
+
 	// 1. Territory Gain
 	// +10 for each cell captured (both grow and attack)
 	score += 10.0 * s.factors.TerritoryGain
 
 	// 2. Strategic Position
-	if board.IsCornerPosition(move.Position) {
-		score += 8.0 * s.factors.StrategicPosition
-	} else if board.IsEdgePosition(move.Position) {
-		score += 5.0 * s.factors.StrategicPosition
-	}
+	score += s.positionalScore(f, state.Board, phase) * s.factors.StrategicPosition
 
 	// 3. Threat Removal
-	if move.Type == game.MoveAttack {
-		score += 15.0 * s.factors.ThreatRemoval
+	if f.IsAttack {
+		score += 15.0 * s.factors.ThreatRemoval * attackScale
 	}
 
 	// 4. Connectivity
 	// Check if this move helps reconnect cut-off cells
-	if s.improvesConnectivity(move, state, playerID) {
+	if f.ConnectsToTerritory {
 		score += 3.0 * s.factors.Connectivity
 	}
 
 	// 5. Expansion Potential
 	// How many new cells can we reach from this position?
-	emptyNeighbors := len(board.GetEmptyNeighbors(move.Position))
-	score += float64(emptyNeighbors) * 4.0 * s.factors.ExpansionPotential
+	score += float64(f.EmptyNeighbors) * 4.0 * s.factors.ExpansionPotential * expansionScale
 
 	// 6. Defensive Value
-	// Check if this move protects our base or creates a barrier
-	if s.hasDefensiveValue(move, state, playerID) {
+	// Check if this move protects our base, creates a barrier or gives
+	// territory behind a bridge a second way to the base. A move that adds
+	// a bridge leaves more of the territory one attack from being cut off.
+	if s.hasDefensiveValue(f.Move, state, playerID) || f.BridgeDelta < 0 {
 		score += 2.0 * s.factors.DefensiveValue
 	}
+	if f.BridgeDelta > 0 {
+		score -= 2.0 * s.factors.DefensiveValue
+	}
+
+	// 7. Mobility
+	// Prefer moves that keep enough targets open for the following moves
+	if s.factors.Mobility != 0 {
+		score += float64(f.Mobility) * sizeScale * s.factors.Mobility
+		if turnLength := state.Config().TurnLength(); f.Mobility < turnLength {
+			score -= float64(turnLength-f.Mobility) * 10.0 * s.factors.Mobility
+		}
+	}
+
+	// 8. Special Cells
+	// Capturing a power-up cell triggers its ability on top of the growth
+	if f.IsSpecial {
+		score += 25.0 * s.factors.SpecialCapture
+	}
+
+	// 9. Compactness
+	// A tight, hole-free territory exposes fewer cells to attack
+	score += f.CompactnessDelta * sizeScale * 50.0 * s.factors.Compactness
+	if f.FillsHole {
+		score += 6.0 * s.factors.Compactness
+	}
+
+	// 10. Perimeter
+	// Under pressure every perimeter cell must be defended, so consolidate;
+	// while expanding freely a longer frontier means more reach
+	if f.Contested {
+		score -= float64(f.PerimeterDelta) * 4.0 * s.factors.Compactness
+	} else {
+		score += float64(f.PerimeterDelta) * s.factors.ExpansionPotential
+	}
+
+	// 11. Threat Proximity
+	// Enemies near our base grow urgent with the square of their closeness
+	score -= f.BaseThreat * s.factors.ThreatDecay
+
+	// 12. Base Capture
+	// Taking an opponent's base usually eliminates them, whatever the weights
+	if f.CapturesBase {
+		score += baseCaptureBonus
+	}
 
 	return score
 }
 
-// improvesConnectivity checks if a move helps reconnect cells
-func (s *HeuristicStrategy) improvesConnectivity(move game.Move, state *game.GameState, playerID int) bool {
-	// If the move position is already connected to base, no improvement
-	if state.Board.IsConnectedToBase(playerID, move.Position) {
-		return false
+// baseCaptureBonus outweighs every other term, so a base capture is always
+// played when the rules allow one
+const baseCaptureBonus = 10000.0
+
+// referenceBoardSize is the board size the weights are tuned for
+const referenceBoardSize = 10
+
+// sizeScale returns the factor applied to terms that grow with the linear
+// size of the territory, such as the number of move targets and the area
+// to perimeter ratio. It is 1 unless normalization is enabled, so that
+// equivalent positions score alike on any board size.
+func (s *HeuristicStrategy) sizeScale(board *game.Board) float64 {
+	if !s.normalize || board.Rows*board.Cols == 0 {
+		return 1
 	}
+	return referenceBoardSize / math.Sqrt(float64(board.Rows*board.Cols))
+}
 
-	// Check if the move connects to the main territory
-	connectedCells := state.Board.GetReachableCells(playerID)
-	for _, cell := range connectedCells {
-		if state.Board.IsAdjacent(cell, move.Position) {
-			return true
-		}
+// isFreeForAll reports whether more than two players are still alive
+func isFreeForAll(state *game.GameState) bool {
+	return len(state.GetAlivePlayers()) > 2
+}
+
+// positionalScore returns the unweighted strategic position bonus. Central
+// influence matters in the opening, edges and corners once the board fills.
+func (s *HeuristicStrategy) positionalScore(f game.MoveFeatures, board *game.Board, phase game.Phase) float64 {
+	edge := 0.0
+	if f.IsCorner {
+		edge = 8.0
+	} else if f.IsEdge {
+		edge = 5.0
 	}
 
-	return false
+	switch phase {
+	case game.PhaseOpening:
+		shift := math.Min(math.Max(s.factors.OpeningCenter, 0), 1)
+		return edge*(1-shift) + 8.0*centrality(f.Move.Position, board)*shift
+	case game.PhaseEndgame:
+		return edge * (1 + s.factors.EndgameEdge)
+	}
+	return edge
+}
+
+// centrality is 1 at the center of the board and 0 on the edges
+func centrality(pos game.Position, board *game.Board) float64 {
+	return 1 - math.Max(offCenter(pos.Row, board.Rows), offCenter(pos.Col, board.Cols))
 }
 
+// offCenter is 0 in the middle of a line of n cells and 1 at either end
+func offCenter(i, n int) float64 {
+	half := float64(n-1) / 2
+	if half <= 0 {
+		return 0
+	}
+	return math.Abs(float64(i)-half) / half
+}
+
+// movesPerTurn is the number of moves a player makes each turn in the
+// standard rules
+const movesPerTurn = 3
+
 // hasDefensiveValue checks if a move has defensive value
 func (s *HeuristicStrategy) hasDefensiveValue(move game.Move, state *game.GameState, playerID int) bool {
 	player := state.GetYourPlayer()
@@ -191,7 +539,6 @@ func (s *HeuristicStrategy) hasDefensiveValue(move game.Move, state *game.GameSt
 			}
 		}
 	}
-
 	return false
 }
 
@@ -244,6 +591,9 @@ type scoredMove struct {
 
 // DecideNeutrals decides where to place neutral cells
 func (s *HeuristicStrategy) DecideNeutrals(state *game.GameState) []game.Position {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	player := state.GetYourPlayer()
 	if player == nil || player.HasUsedNeutrals {
 		return nil
@@ -285,7 +635,10 @@ func (s *HeuristicStrategy) DecideNeutrals(state *game.GameState) []game.Positio
 	return result
 }
 
-// evaluateNeutralPosition scores a position for neutral placement
+// evaluateNeutralPosition scores a position for neutral placement with the
+// weights of the factors each term serves. The default weights give 20 for
+// guarding the base, 3 per cell cut off, 10 for a corner, 3 per empty
+// neighbor and -10 next to our base.
 func (s *HeuristicStrategy) evaluateNeutralPosition(pos game.Position, state *game.GameState, playerID int) float64 {
 	score := 0.0
 
@@ -294,28 +647,26 @@ func (s *HeuristicStrategy) evaluateNeutralPosition(pos game.Position, state *ga
 	for _, opp := range opponents {
 		// Check if this position blocks the opponent from reaching our base
 		if s.blocksPathToBase(pos, state, opp.ID, playerID) {
-			score += 20.0
+			score += 100.0 * s.factors.DefensiveValue
 		}
 	}
 
-	// Prefer creating chokepoints
-	if s.createsChokepoint(pos, state) {
-		score += 15.0
-	}
+	// Prefer chokepoints: cells whose loss cuts opponents off from our side
+	score += s.chokepointValue(pos, state, playerID) * 10.0 * s.factors.Connectivity
 
 	// Prefer corners for blocking
 	if state.Board.IsCornerPosition(pos) {
-		score += 10.0
+		score += 20.0 * s.factors.StrategicPosition
 	}
 
 	// Prefer positions adjacent to many empty cells (blocking expansion)
 	emptyNeighbors := len(state.Board.GetEmptyNeighbors(pos))
-	score += float64(emptyNeighbors) * 3.0
+	score += float64(emptyNeighbors) * 7.5 * s.factors.ExpansionPotential
 
-	// Avoid placing near our base (don't block ourselves)
+	// Avoid placing near our base (don't block our own expansion)
 	player := state.GetYourPlayer()
 	if player != nil && state.Board.IsAdjacent(pos, player.BasePos) {
-		score -= 10.0
+		score -= 25.0 * s.factors.ExpansionPotential
 	}
 
 	return score
@@ -337,24 +688,83 @@ func (s *HeuristicStrategy) blocksPathToBase(pos game.Position, state *game.Game
 	return false
 }
 
-// createsChokepoint checks if a position creates a chokepoint
-func (s *HeuristicStrategy) createsChokepoint(pos game.Position, state *game.GameState) bool {
-	// A chokepoint is where we force opponents to go through a narrow passage
-	// Simplified: check if surrounded by our cells or board edges
-	neighbors := state.Board.GetNeighbors(pos)
-	ourCells := 0
-	for _, n := range neighbors {
-		// Would be our cell after placement - this is a simplification
-		if state.Board.IsEdgePosition(n) {
-			ourCells++
+// chokepointValue returns how many cells the opponents could no longer
+// reach if a neutral were placed on pos. A neutral in an open field costs
+// them only the cell itself; one in a corridor seals off everything behind
+// it.
+func (s *HeuristicStrategy) chokepointValue(pos game.Position, state *game.GameState, playerID int) float64 {
+	blocked := state.Board.Clone()
+	blocked.SetCell(pos, protocol.CellNeutral)
+
+	cut := 0
+	for _, opp := range state.GetOpponents() {
+		if opp.ID == playerID {
+			continue
 		}
+		cut += opponentReach(state.Board, opp.ID) - opponentReach(blocked, opp.ID)
 	}
-	return ourCells >= 2
+	return float64(cut)
 }
 
-// OnMoveMade is a no-op for heuristic strategy
+// opponentReach counts the cells a player could eventually take: a flood
+// fill from their base-connected territory through empty cells and cells
+// they can attack, stopped by neutrals, killed and protected cells
+func opponentReach(board *game.Board, playerID int) int {
+	territory := board.GetReachableCells(playerID)
+	visited := make(map[game.Position]bool, len(territory))
+	queue := append([]game.Position(nil), territory...)
+	for _, pos := range territory {
+		visited[pos] = true
+	}
+
+	reach := 0
+	var neighbors []game.Position
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		neighbors = board.AppendNeighbors(neighbors[:0], current)
+		for _, n := range neighbors {
+			if visited[n] || !(board.IsGrowable(n) || board.IsOpponent(n, playerID)) {
+				continue
+			}
+			visited[n] = true
+			reach++
+			queue = append(queue, n)
+		}
+	}
+	return reach
+}
+
+// OnMoveMade records opponent moves in the opponent model; our own moves
+// are ignored
 func (s *HeuristicStrategy) OnMoveMade(state *game.GameState, move game.Move) {
-	// No learning in basic heuristic strategy
+	if state.CurrentPlayer == 0 || state.CurrentPlayer == state.YourPlayerID {
+		return
+	}
+	s.opponents.Observe(state.CurrentPlayer, move.Position)
+}
+
+// Reset clears the opponent model for a new game
+func (s *HeuristicStrategy) Reset() {
+	s.opponents.Clear()
+}
+
+// Clone returns a copy with its own opponent model and random source
+func (s *HeuristicStrategy) Clone() Strategy {
+	return s.clone()
+}
+
+// clone is Clone with the concrete type, for strategies embedding a heuristic
+func (s *HeuristicStrategy) clone() *HeuristicStrategy {
+	return &HeuristicStrategy{
+		factors:       s.Factors(),
+		maxCandidates: s.maxCandidates,
+		normalize:     s.normalize,
+		epsilon:       s.epsilon,
+		rand:          rand.New(rand.NewSource(s.rand.Int63())),
+		opponents:     s.opponents.Clone(),
+		logger:        s.logger,
+	}
 }
 
 // scoredPosition is a position with its score for neutral placement
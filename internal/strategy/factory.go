@@ -1,15 +1,58 @@
 package strategy
 
-import (
-	"virusbot/config"
-)
+import "virusbot/config"
+
+// constructors maps strategy names to their constructors
+var constructors = map[config.StrategyType]func(cfg *config.Config) Strategy{
+	config.StrategyHeuristic:  func(cfg *config.Config) Strategy { return NewHeuristicStrategy(cfg) },
+	config.StrategyMCTS:       func(cfg *config.Config) Strategy { return NewMCTSStrategy(cfg) },
+	config.StrategyDisconnect: func(cfg *config.Config) Strategy { return NewDisconnectStrategy(cfg) },
+	config.StrategyPolicy:     func(cfg *config.Config) Strategy { return NewPolicyStrategy(cfg) },
+	config.StrategyMinimax:    func(cfg *config.Config) Strategy { return NewMinimaxStrategy(cfg) },
+	config.StrategyGreedy:     func(cfg *config.Config) Strategy { return NewGreedyStrategy(cfg) },
+}
 
 // NewStrategy creates a strategy based on configuration
 func NewStrategy(cfg *config.Config) Strategy {
-	switch cfg.GetStrategyType() {
-	case config.StrategyMCTS:
-		return NewMCTSStrategy(cfg)
-	default:
+	if len(cfg.StrategyChain) > 0 {
+		if chain := newChain(cfg); chain != nil {
+			return chain
+		}
+	}
+
+	strategyType, err := cfg.GetStrategyType()
+	if err != nil {
+		cfg.Logger().Warnf("%v, using heuristic", err)
 		return NewHeuristicStrategy(cfg)
 	}
+	strategy, _ := NewStrategyByName(string(strategyType), cfg)
+	return strategy
+}
+
+// NewStrategyByName creates a registered strategy by name
+func NewStrategyByName(name string, cfg *config.Config) (Strategy, bool) {
+	constructor, exists := constructors[config.StrategyType(name)]
+	if !exists {
+		return nil, false
+	}
+	return constructor(cfg), true
+}
+
+// newChain builds the configured fallback chain. Config.Validate rejects
+// unknown names, so an unvalidated one is only skipped here.
+func newChain(cfg *config.Config) *ChainStrategy {
+	strategies := make([]Strategy, 0, len(cfg.StrategyChain))
+	for _, name := range cfg.StrategyChain {
+		strategyType, err := config.ParseStrategyType(name)
+		if err != nil {
+			cfg.Logger().Warnf("%v in chain, skipping", err)
+			continue
+		}
+		strategy, _ := NewStrategyByName(string(strategyType), cfg)
+		strategies = append(strategies, strategy)
+	}
+	if len(strategies) == 0 {
+		return nil
+	}
+	return NewChainStrategy(strategies, cfg.StrategyChainTimeout, cfg.Logger())
 }
@@ -0,0 +1,130 @@
+package strategy
+
+import (
+	"sort"
+
+	"virusbot/config"
+	"virusbot/internal/game"
+)
+
+// GreedyStrategy takes the most territory it can right now: attacks first,
+// then grows into the cells with the most empty neighbors. It has no
+// randomness and no search, which makes it a fast, reproducible sparring
+// partner.
+type GreedyStrategy struct{}
+
+// NewGreedyStrategy creates a new greedy strategy
+func NewGreedyStrategy(cfg *config.Config) *GreedyStrategy {
+	return &GreedyStrategy{}
+}
+
+// Name returns the strategy name
+func (s *GreedyStrategy) Name() string {
+	return "greedy"
+}
+
+// DecideMoves picks the best immediate move count times, each on the board
+// the previous one leaves
+func (s *GreedyStrategy) DecideMoves(state *game.GameState, count int) []game.Move {
+	if !state.IsMyTurn() {
+		return nil
+	}
+	player := state.GetYourPlayer()
+	if player == nil {
+		return nil
+	}
+
+	board := state.Board
+	moves := make([]game.Move, 0, count)
+	for len(moves) < count {
+		best, ok := s.bestMove(board, player.ID)
+		if !ok {
+			break
+		}
+		moves = append(moves, best)
+		board = board.ApplyMove(best.Position, player.ID, best.Fortifies())
+	}
+	return moves
+}
+
+// bestMove returns the valid move that gains the most right away. Ties go to
+// the topmost, then leftmost cell, so the choice never depends on the order
+// the moves were generated in.
+func (s *GreedyStrategy) bestMove(board *game.Board, playerID int) (game.Move, bool) {
+	moves := board.GetValidMoves(playerID)
+	if len(moves) == 0 {
+		return game.Move{}, false
+	}
+	best, bestOpen := moves[0], emptyNeighbors(board, moves[0].Position)
+	for _, move := range moves[1:] {
+		open := emptyNeighbors(board, move.Position)
+		attack, bestAttack := move.Type == game.MoveAttack, best.Type == game.MoveAttack
+		switch {
+		case attack != bestAttack:
+			if !attack {
+				continue
+			}
+		case open != bestOpen:
+			if open < bestOpen {
+				continue
+			}
+		case !before(move.Position, best.Position):
+			continue
+		}
+		best, bestOpen = move, open
+	}
+	return best, true
+}
+
+// DecideNeutrals gives up the two cells of ours with the fewest empty
+// neighbors, the ones worth least for growing
+func (s *GreedyStrategy) DecideNeutrals(state *game.GameState) []game.Position {
+	player := state.GetYourPlayer()
+	if player == nil || player.HasUsedNeutrals {
+		return nil
+	}
+	positions := state.Board.GetNeutralPositions(player.ID)
+	if len(positions) < 2 {
+		return nil
+	}
+
+	open := make(map[game.Position]int, len(positions))
+	for _, pos := range positions {
+		open[pos] = emptyNeighbors(state.Board, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		a, b := positions[i], positions[j]
+		if open[a] != open[b] {
+			return open[a] < open[b]
+		}
+		return before(a, b)
+	})
+	return positions[:2]
+}
+
+// OnMoveMade does nothing; the greedy strategy learns nothing
+func (s *GreedyStrategy) OnMoveMade(state *game.GameState, move game.Move) {}
+
+// Reset does nothing; the greedy strategy keeps no state
+func (s *GreedyStrategy) Reset() {}
+
+// Clone returns the strategy itself, as it keeps no state
+func (s *GreedyStrategy) Clone() Strategy {
+	return s
+}
+
+// emptyNeighbors counts the empty cells next to pos
+func emptyNeighbors(board *game.Board, pos game.Position) int {
+	count := 0
+	for _, n := range board.GetNeighbors(pos) {
+		if board.IsEmpty(n) {
+			count++
+		}
+	}
+	return count
+}
+
+// before orders positions row by row
+func before(a, b game.Position) bool {
+	return a.Row < b.Row || (a.Row == b.Row && a.Col < b.Col)
+}
@@ -1,6 +1,9 @@
 package strategy
 
 import (
+	"context"
+	"time"
+
 	"virusbot/internal/game"
 )
 
@@ -15,6 +18,34 @@ type Strategy interface {
 	// DecideNeutrals decides where to place neutral cells
 	DecideNeutrals(state *game.GameState) []game.Position
 
-	// OnMoveMade is called when a move is made (for learning strategies)
+	// OnMoveMade is called when a move is made (for learning strategies).
+	// state.CurrentPlayer is the player who made the move.
 	OnMoveMade(state *game.GameState, move game.Move)
+
+	// Reset clears anything learned during a game before the next one starts
+	Reset()
+
+	// Clone returns an instance that shares no mutable state with this one,
+	// for running the strategy concurrently. Stateless strategies may return
+	// themselves.
+	Clone() Strategy
+}
+
+// Tunable is implemented by strategies whose heuristic weights can be
+// replaced while they play
+type Tunable interface {
+	SetFactors(factors EvaluationFactors)
+}
+
+// Budgeted is implemented by strategies that can fit their thinking into
+// the time left in the turn. remaining is the turn time not yet used; count
+// is, as for DecideMoves, the moves left to make, all decided by this call.
+type Budgeted interface {
+	DecideMovesWithin(state *game.GameState, count int, remaining time.Duration) []game.Move
+}
+
+// WarmUpper is implemented by strategies that can prepare in the background
+// at game start. WarmUp must return promptly once ctx is cancelled.
+type WarmUpper interface {
+	WarmUp(ctx context.Context, state *game.GameState)
 }
@@ -1,11 +1,18 @@
 package strategy
 
 import (
+	"context"
 	"math"
 	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
+
 	"virusbot/config"
 	"virusbot/internal/game"
+	"virusbot/internal/logging"
 )
 
 // MCTSConfig contains configuration for MCTS
@@ -28,13 +35,39 @@ func DefaultMCTSConfig() MCTSConfig {
 
 // MCTSStrategy uses Monte Carlo Tree Search
 type MCTSStrategy struct {
-	config MCTSConfig
-	rand   *rand.Rand
-	debug  bool
+	config        MCTSConfig
+	maxCandidates int
+	warmup        int  // playouts run from the initial position on game start
+	reuseTree     bool // keep the tree across turns, re-rooted at each played move
+	workers       int  // goroutines searching their own trees
+	rand          *rand.Rand
+	cache         *game.MoveCache
+	tree          *searchTree
+	warm          *warmState
+	heuristic     *HeuristicStrategy // ranks candidates and places neutrals with the configured weights
+	logger        logging.Logger
+}
+
+// warmState tracks a warm-up searching the kept tree. mu serialises the
+// warm-up's iterations with searches and with re-rooting the tree, so a
+// playout is never backed up into a tree that moved on under it. Moves
+// played while the tree is in use wait in queued, under queueMu, and are
+// applied before its next use, so OnMoveMade never waits for a search.
+type warmState struct {
+	mu     sync.Mutex
+	root   *game.GameState // position the warm-up searches from, nil when none
+	warmed bool            // the tree holds warm-up playouts for the next decision
+
+	queueMu sync.Mutex
+	queued  []game.Move
 }
 
 // NewMCTSStrategy creates a new MCTS strategy
 func NewMCTSStrategy(cfg *config.Config) *MCTSStrategy {
+	workers := cfg.MCTSWorkers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
 	return &MCTSStrategy{
 		config: MCTSConfig{
 			Iterations:       cfg.MCTSIterations,
@@ -42,11 +75,22 @@ func NewMCTSStrategy(cfg *config.Config) *MCTSStrategy {
 			ExplorationConst: cfg.MCTSUCTConst,
 			MaxDepth:         50,
 		},
-		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
-		debug: cfg.Debug,
+		maxCandidates: cfg.MaxCandidates,
+		warmup:        cfg.MCTSWarmup,
+		reuseTree:     cfg.MCTSReuseTree,
+		workers:       workers,
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		cache:         game.NewMoveCache(playoutCacheSize),
+		tree:          newSearchTree(),
+		warm:          &warmState{},
+		heuristic:     NewHeuristicStrategy(cfg),
+		logger:        cfg.Logger(),
 	}
 }
 
+// playoutCacheSize bounds the move cache shared by random playouts
+const playoutCacheSize = 4096
+
 // Name returns the strategy name
 func (s *MCTSStrategy) Name() string {
 	return "mcts"
@@ -54,6 +98,33 @@ func (s *MCTSStrategy) Name() string {
 
 // DecideMoves selects the best moves using MCTS
 func (s *MCTSStrategy) DecideMoves(state *game.GameState, count int) []game.Move {
+	return s.decide(state, count, s.config.TimeLimit)
+}
+
+// DecideMovesWithin searches for what is usable of the remaining turn time,
+// as this one call decides every move left, and never longer than the
+// configured time limit
+func (s *MCTSStrategy) DecideMovesWithin(state *game.GameState, count int, remaining time.Duration) []game.Move {
+	return s.decide(state, count, min(s.config.TimeLimit, thinkingTime(remaining)))
+}
+
+// budgetReserve is the share of the remaining turn time never spent
+// thinking, left for sending the moves and for the last iteration to
+// finish; minReserve is the least kept back
+const (
+	budgetReserve = 0.1
+	minReserve    = 20 * time.Millisecond
+)
+
+// thinkingTime is the remaining turn time less the reserve
+func thinkingTime(remaining time.Duration) time.Duration {
+	return max(remaining-max(time.Duration(float64(remaining)*budgetReserve), minReserve), 0)
+}
+
+// decide returns moves within limit, counting the preparation of the
+// search as well as the search itself
+func (s *MCTSStrategy) decide(state *game.GameState, count int, limit time.Duration) []game.Move {
+	deadline := time.Now().Add(limit)
 	if !state.IsMyTurn() {
 		return nil
 	}
@@ -63,8 +134,8 @@ func (s *MCTSStrategy) DecideMoves(state *game.GameState, count int) []game.Move
 		return nil
 	}
 
-	// Get all valid moves
-	validMoves := state.Board.GetValidMoves(player.ID)
+	// Get all valid moves, neutral placements included
+	validMoves := state.GetValidMoves(player.ID)
 	if len(validMoves) == 0 {
 		return nil
 	}
@@ -72,7 +143,7 @@ func (s *MCTSStrategy) DecideMoves(state *game.GameState, count int) []game.Move
 	// Filter out moves to already occupied cells (defensive check)
 	filteredMoves := make([]game.Move, 0, len(validMoves))
 	for _, move := range validMoves {
-		if state.Board.IsEmpty(move.Position) || state.Board.IsOpponent(move.Position, player.ID) {
+		if move.Type == game.MoveNeutral || state.Board.IsGrowable(move.Position) || state.Board.IsOpponent(move.Position, player.ID) {
 			filteredMoves = append(filteredMoves, move)
 		}
 	}
@@ -83,160 +154,377 @@ func (s *MCTSStrategy) DecideMoves(state *game.GameState, count int) []game.Move
 
 	// For 3 moves, we need to select the best combination
 	// Run MCTS to find the best moves
-	moves := s.runMCTS(state, filteredMoves, count)
+	candidates := s.heuristic.searchCandidates(filteredMoves, state, player.ID, s.maxCandidates)
+	if len(candidates) < len(filteredMoves) {
+		s.logger.Debugf("Candidate cap: considering %d of %d moves", len(candidates), len(filteredMoves))
+	}
+	moves := s.runMCTS(state, s.expandMoves(state, candidates), count, deadline)
 
 	return moves
 }
 
-// runMCTS runs the MCTS algorithm
-func (s *MCTSStrategy) runMCTS(state *game.GameState, validMoves []game.Move, count int) []game.Move {
-	if len(validMoves) <= count {
-		return validMoves
+// expandMoves returns the moves worth exploring from a position. While the
+// board is symmetric, mirror-equivalent moves collapse into one
+// representative; once symmetry is lost every move is kept. Neutral
+// placements are grouped by their first cell only, so they are all kept.
+func (s *MCTSStrategy) expandMoves(state *game.GameState, moves []game.Move) []game.Move {
+	groups := state.Board.SymmetryGroups(moves)
+	if len(groups) == len(moves) {
+		return moves
 	}
 
-	// Run simulations with time limit
-	deadline := time.Now().Add(s.config.TimeLimit)
-	iterations := 0
+	representatives := make([]game.Move, 0, len(groups))
+	for _, group := range groups {
+		if group[0].Type == game.MoveNeutral {
+			representatives = append(representatives, group...)
+			continue
+		}
+		representatives = append(representatives, group[0])
+	}
+	return representatives
+}
+
+// runMCTS runs the MCTS algorithm until deadline
+func (s *MCTSStrategy) runMCTS(state *game.GameState, validMoves []game.Move, count int, deadline time.Time) []game.Move {
+	if len(validMoves) <= count {
+		return turnOf(validMoves, count)
+	}
 
-	for time.Now().Before(deadline) && iterations < s.config.Iterations {
-		s.iteration(state, validMoves)
-		iterations++
+	s.warm.mu.Lock()
+	defer s.warm.mu.Unlock()
+	// Moves played during the search re-root the tree once it is done
+	defer s.applyQueued()
+	s.applyQueued()
+	if !s.reuseTree && !s.warm.warmed {
+		s.tree.clear()
 	}
+	// A warm-up's tree serves this first decision only
+	s.warm.root, s.warm.warmed = nil, false
+
+	// The search plays out the rest of our turn before handing it over
+	root := state.Clone()
+	root.MovesLeft = count
+
+	trees, iterations := s.search(root, validMoves, deadline)
+	s.logger.Debugf("MCTS ran %d iterations on %d trees", iterations, len(trees))
 
 	// Select best moves based on visit counts
-	return s.selectBestMoves(validMoves, count)
+	return s.selectBestMoves(root, validMoves, count, trees)
 }
 
-// iteration performs one MCTS iteration
-func (s *MCTSStrategy) iteration(rootState *game.GameState, validMoves []game.Move) {
-	// For simplicity, we'll use a simplified MCTS that evaluates each move independently
-	// This is a basic implementation - a full MCTS would build a tree
-
-	// Evaluate all moves and track the best
-	bestScore := -1.0
-	for _, move := range validMoves {
-		score := s.simulateRandomPlayout(rootState, move)
-		if score > bestScore {
-			bestScore = score
+// search runs iterations from root on one tree per worker until deadline
+// passes or the configured iterations are used up between them. The first
+// worker searches the kept tree, the others trees of their own. It returns
+// the trees and the number of iterations run.
+func (s *MCTSStrategy) search(root *game.GameState, validMoves []game.Move, deadline time.Time) ([]*searchTree, int) {
+	trees := make([]*searchTree, max(s.workers, 1))
+	budget := int64(s.config.Iterations)
+	var started atomic.Int64
+	var wg sync.WaitGroup
+	rngs := make([]*rand.Rand, len(trees))
+	for i := range trees {
+		trees[i], rngs[i] = s.tree, s.rand
+		if i > 0 {
+			// Seeded before any worker draws from s.rand
+			trees[i], rngs[i] = newSearchTree(), rand.New(rand.NewSource(s.rand.Int63()))
 		}
+		trees[i].prepareRoot(validMoves)
 	}
+	for i := range trees {
+		tree, rng := trees[i], rngs[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) && started.Add(1) <= budget {
+				s.iteration(tree, rng, root)
+			}
+		}()
+	}
+	wg.Wait()
+	return trees, int(min(started.Load(), budget))
+}
 
-	_ = bestScore // Suppress unused variable warning
+// iteration performs one MCTS iteration on tree: it selects a path down the
+// tree, expands one new node, plays the rest of the game out at random and
+// backs the result up the path
+func (s *MCTSStrategy) iteration(tree *searchTree, rng *rand.Rand, rootState *game.GameState) {
+	path, simState := s.descend(tree, rng, rootState)
+	score := s.rollout(simState, rootState.YourPlayerID, rng)
+	tree.record(path, score)
 }
 
-// simulateRandomPlayout simulates a random playout from the given move
-func (s *MCTSStrategy) simulateRandomPlayout(state *game.GameState, firstMove game.Move) float64 {
-	simState := state.Clone()
-	player := simState.GetCurrentPlayer()
-	if player == nil {
-		return 0
+// descend follows UCT from the root until it reaches a node with untried
+// moves, which it expands with one of them, or a leaf. It returns the moves
+// taken and the state they lead to.
+func (s *MCTSStrategy) descend(tree *searchTree, rng *rand.Rand, rootState *game.GameState) ([]game.Move, *game.GameState) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	simState := rootState.Clone()
+	node := tree.root
+	var path []game.Move
+	for {
+		if !node.expanded {
+			node.untried = s.nodeMoves(simState)
+			node.expanded = true
+		}
+
+		if len(node.untried) > 0 {
+			i := rng.Intn(len(node.untried))
+			move := node.untried[i]
+			node.untried[i] = node.untried[len(node.untried)-1]
+			node.untried = node.untried[:len(node.untried)-1]
+			// record creates the node once the playout is scored
+			return append(path, move), simState.ApplyMove(move)
+		}
+
+		child := s.selectChild(node, simState.CurrentPlayer == rootState.YourPlayerID)
+		if child == nil {
+			return path, simState
+		}
+		path = append(path, child.move)
+		simState = simState.ApplyMove(child.move)
+		node = child
 	}
+}
 
-	// Apply the first move
-	simState = simState.ApplyMove(firstMove)
+// nodeMoves returns the moves expanded below a position: the current
+// player's candidates, neutral placements included, or none once the game
+// is over
+func (s *MCTSStrategy) nodeMoves(state *game.GameState) []game.Move {
+	player := state.GetCurrentPlayer()
+	if player == nil {
+		return nil
+	}
+	if done, _ := state.IsTerminal(); done {
+		return nil
+	}
+	moves := s.cache.ValidMoves(state.Board, player.ID)
+	if !player.HasUsedNeutrals {
+		// The cached slice is shared; append to a copy
+		moves = append(moves[:len(moves):len(moves)], state.Board.GetNeutralMoves(player.ID)...)
+	}
+	return s.heuristic.searchCandidates(moves, state, player.ID, s.maxCandidates)
+}
 
-	depth := 1
-	winner := -1
+// selectChild returns the child with the best UCT value for the player to
+// move: our win rate on our moves, the opponents' on theirs
+func (s *MCTSStrategy) selectChild(node *searchNode, ours bool) *searchNode {
+	var best *searchNode
+	bestValue := math.Inf(-1)
+	for _, child := range node.children {
+		wins := child.wins
+		if !ours {
+			wins = float64(child.visits) - child.wins
+		}
+		value := s.UCT(wins, float64(child.visits), float64(node.visits))
+		if value > bestValue {
+			best, bestValue = child, value
+		}
+	}
+	return best
+}
 
-	// Random playout until game ends or max depth
-	for depth < s.config.MaxDepth {
-		alive := simState.GetAlivePlayers()
-		if len(alive) <= 1 {
-			if len(alive) == 1 && alive[0].ID == state.YourPlayerID {
-				winner = state.YourPlayerID
-			}
-			break
+// rollout plays random moves from state using rng, each player making a
+// turn's worth before the next, until the game ends or MaxDepth moves were
+// played. A finished game scores 1 for a win, 0.5 for a draw and 0 for a
+// loss; one cut off at MaxDepth scores our share of the players' cells.
+func (s *MCTSStrategy) rollout(state *game.GameState, you int, rng *rand.Rand) float64 {
+	simState := state.Clone()
+	for depth := 0; depth < s.config.MaxDepth; {
+		if done, winner := simState.IsTerminal(); done {
+			return outcome(winner, you)
 		}
 
-		// Get random move for current player
 		currentPlayer := simState.GetCurrentPlayer()
 		if currentPlayer == nil {
 			break
 		}
 
-		moves := simState.Board.GetValidMoves(currentPlayer.ID)
+		moves := s.cache.ValidMoves(simState.Board, currentPlayer.ID)
 		if len(moves) == 0 {
 			// Skip this player's turn
 			simState.AdvancePlayer()
 			continue
 		}
 
-		// Pick random move
-		move := moves[s.rand.Intn(len(moves))]
-		simState = simState.ApplyMove(move)
-
+		simState = simState.ApplyMove(moves[rng.Intn(len(moves))])
 		depth++
 	}
 
-	// Return a score based on outcome
-	if winner == state.YourPlayerID {
-		return 1.0
+	if done, winner := simState.IsTerminal(); done {
+		return outcome(winner, you)
 	}
-	return 0.0
+	return cellShare(simState, you)
 }
 
-// selectBestMoves selects the best moves based on simulation results
-func (s *MCTSStrategy) selectBestMoves(moves []game.Move, count int) []game.Move {
-	if len(moves) <= count {
-		return moves
+// outcome scores a finished game for player
+func outcome(winner, player int) float64 {
+	switch winner {
+	case player:
+		return 1
+	case game.Draw:
+		return 0.5
 	}
+	return 0
+}
 
-	// Score each move and pick the best
-	type moveScore struct {
-		move  game.Move
-		score float64
+// cellShare returns player's fraction of the cells owned by the players
+func cellShare(state *game.GameState, player int) float64 {
+	ours, total := 0, 0
+	for _, p := range state.Players {
+		cells := state.Board.CountCells(p.ID)
+		total += cells
+		if p.ID == player {
+			ours = cells
+		}
 	}
+	if total == 0 {
+		return 0
+	}
+	return float64(ours) / float64(total)
+}
 
-	scored := make([]moveScore, len(moves))
-	for i, move := range moves {
-		scored[i] = moveScore{move: move, score: 0}
+// WarmUp runs the configured number of search iterations on the kept tree,
+// rooted at the initial position, and leaves the tree for the first
+// decision. Moves played meanwhile re-root it. It stops early when ctx is
+// cancelled and never touches the strategy's own random source, so it may
+// run while DecideMoves is called.
+func (s *MCTSStrategy) WarmUp(ctx context.Context, state *game.GameState) {
+	if s.warmup <= 0 || state.GetYourPlayer() == nil {
+		return
 	}
 
-	// Run more thorough evaluation
-	for i, ms := range scored {
-		// Evaluate each move multiple times
-		sumScore := 0.0
-		for j := 0; j < 10; j++ {
-			sumScore += s.evaluateMove(ms.move)
-		}
-		scored[i].score = sumScore / 10.0
+	s.warm.mu.Lock()
+	s.dropQueued()
+	s.tree.clear()
+	s.warm.root, s.warm.warmed = state.Clone(), true
+	s.warm.mu.Unlock()
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	played := 0
+	for played < s.warmup && s.warmIteration(ctx, rng) {
+		played++
 	}
 
-	// Sort by score descending
-	for i := 0; i < len(scored)-1; i++ {
-		maxIdx := i
-		for j := i + 1; j < len(scored); j++ {
-			if scored[j].score > scored[maxIdx].score {
-				maxIdx = j
-			}
+	s.logger.Debugf("MCTS warm-up ran %d of %d iterations", played, s.warmup)
+}
+
+// warmIteration runs one warm-up iteration, reporting false once ctx is
+// cancelled or a decision has taken the tree over
+func (s *MCTSStrategy) warmIteration(ctx context.Context, rng *rand.Rand) bool {
+	s.warm.mu.Lock()
+	defer s.warm.mu.Unlock()
+	s.applyQueued()
+	if ctx.Err() != nil || s.warm.root == nil {
+		return false
+	}
+	s.iteration(s.tree, rng, s.warm.root)
+	return true
+}
+
+// selectBestMoves returns our turn from the search: the root move with the
+// most visits summed over the trees, then, while the turn is still ours,
+// the most visited follow-up under the moves chosen so far. Ties are broken
+// by wins. Where the trees never explored a follow-up, the next best root
+// move still legal is played.
+func (s *MCTSStrategy) selectBestMoves(state *game.GameState, moves []game.Move, count int, trees []*searchTree) []game.Move {
+	if len(moves) <= count {
+		return moves
+	}
+
+	ranked := rankChildren(trees, nil, moves)
+	if ranked[0].Type == game.MoveNeutral {
+		return ranked[:1]
+	}
+
+	you := state.YourPlayerID
+	turn := []game.Move{ranked[0]}
+	next := state.ApplyMove(ranked[0])
+	for len(turn) < count && next.CurrentPlayer == you {
+		move, ok := bestFollowUp(trees, turn, ranked, next)
+		if !ok {
+			break
 		}
-		scored[i], scored[maxIdx] = scored[maxIdx], scored[i]
+		turn = append(turn, move)
+		next = next.ApplyMove(move)
 	}
+	return turn
+}
 
-	// Select top moves
-	result := make([]game.Move, count)
-	for i := 0; i < count; i++ {
-		result[i] = scored[i].move
+// bestFollowUp returns the most visited move after path, or the best ranked
+// root move still legal in state when the trees have none. Neutral
+// placements end the turn, so they never follow a move.
+func bestFollowUp(trees []*searchTree, path, ranked []game.Move, state *game.GameState) (game.Move, bool) {
+	for _, move := range rankChildren(trees, path, nil) {
+		if move.Type != game.MoveNeutral {
+			return move, true
+		}
 	}
 
-	return result
+	played := make(map[moveKey]bool, len(path))
+	for _, move := range path {
+		played[keyOf(move)] = true
+	}
+	for _, move := range ranked {
+		if move.Type != game.MoveNeutral && !played[keyOf(move)] && game.ValidMove(state.Board, state.YourPlayerID, move) {
+			return move, true
+		}
+	}
+	return game.Move{}, false
 }
 
-// evaluateMove evaluates a single move (simplified)
-func (s *MCTSStrategy) evaluateMove(move game.Move) float64 {
-	score := 0.0
+// rankChildren returns the children of the node reached by path, most
+// visits summed over the trees first and ties broken by wins. Moves that
+// are given are ranked whether explored or not; otherwise only the
+// children found in the trees are.
+func rankChildren(trees []*searchTree, path, moves []game.Move) []game.Move {
+	type moveScore struct {
+		move   game.Move
+		visits int
+		wins   float64
+	}
 
-	// Prefer attacks
-	if move.Type == game.MoveAttack {
-		score += 15.0
-	} else {
-		score += 10.0
+	scores := make(map[moveKey]*moveScore)
+	var order []moveKey
+	add := func(move game.Move, visits int, wins float64) {
+		key := keyOf(move)
+		score, ok := scores[key]
+		if !ok {
+			score = &moveScore{move: move}
+			scores[key] = score
+			order = append(order, key)
+		}
+		score.visits += visits
+		score.wins += wins
+	}
+	for _, move := range moves {
+		add(move, 0, 0)
+	}
+	for _, tree := range trees {
+		for _, child := range tree.children(path) {
+			if _, ok := scores[keyOf(child.move)]; ok || moves == nil {
+				add(child.move, child.visits, child.wins)
+			}
+		}
 	}
 
-	// Add some randomness for exploration
-	score += s.rand.Float64() * 2.0
+	scored := make([]*moveScore, len(order))
+	for i, key := range order {
+		scored[i] = scores[key]
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].visits != scored[j].visits {
+			return scored[i].visits > scored[j].visits
+		}
+		return scored[i].wins > scored[j].wins
+	})
 
-	return score
+	ranked := make([]game.Move, len(scored))
+	for i, sm := range scored {
+		ranked[i] = sm.move
+	}
+	return ranked
 }
 
 // UCT calculates the Upper Confidence Bound for Trees
@@ -247,14 +535,80 @@ func (s *MCTSStrategy) UCT(wins, visits, parentVisits float64) float64 {
 	return (wins / visits) + s.config.ExplorationConst*math.Sqrt(math.Log(parentVisits)/visits)
 }
 
-// DecideNeutrals uses a simpler heuristic for neutral placement
+// DecideNeutrals uses the heuristic placement, with the configured weights
 func (s *MCTSStrategy) DecideNeutrals(state *game.GameState) []game.Position {
-	// Fall back to heuristic for neutrals (MCTS is complex for this)
-	heuristic := NewHeuristicStrategy(&config.Config{Debug: s.debug})
-	return heuristic.DecideNeutrals(state)
+	return s.heuristic.DecideNeutrals(state)
 }
 
-// OnMoveMade is a no-op for MCTS strategy
+// OnMoveMade re-roots the kept tree at the move that was played, or starts
+// a new tree if the move was never explored. It is called while the client
+// holds its lock, so while a search or warm-up has the tree the move is
+// queued for them to apply instead.
 func (s *MCTSStrategy) OnMoveMade(state *game.GameState, move game.Move) {
-	// No explicit learning in basic MCTS
+	s.warm.queueMu.Lock()
+	s.warm.queued = append(s.warm.queued, move)
+	s.warm.queueMu.Unlock()
+
+	if s.warm.mu.TryLock() {
+		s.applyQueued()
+		s.warm.mu.Unlock()
+	}
+}
+
+// applyQueued re-roots the tree at the moves played since its last use.
+// The caller holds warm.mu.
+func (s *MCTSStrategy) applyQueued() {
+	s.warm.queueMu.Lock()
+	moves := s.warm.queued
+	s.warm.queued = nil
+	s.warm.queueMu.Unlock()
+
+	for _, move := range moves {
+		if s.warm.root != nil {
+			s.warm.root = s.warm.root.ApplyMove(typedMove(s.warm.root, move))
+		}
+		if !s.reuseTree && !s.warm.warmed {
+			continue
+		}
+		if !s.tree.advance(move) {
+			s.logger.Debugf("MCTS tree has no node for (%d, %d), rebuilding", move.Position.Row, move.Position.Col)
+		}
+	}
+}
+
+// dropQueued forgets the moves waiting to re-root the tree
+func (s *MCTSStrategy) dropQueued() {
+	s.warm.queueMu.Lock()
+	s.warm.queued = nil
+	s.warm.queueMu.Unlock()
+}
+
+// typedMove sets the type of a played move that only names its cell, as
+// the server reports it: taking an opponent's cell is an attack
+func typedMove(state *game.GameState, move game.Move) game.Move {
+	player := state.GetCurrentPlayer()
+	if move.Type == game.MoveGrow && player != nil && state.Board.IsOpponent(move.Position, player.ID) {
+		move.Type = game.MoveAttack
+	}
+	return move
+}
+
+// Reset discards the tree kept from the previous game
+func (s *MCTSStrategy) Reset() {
+	s.warm.mu.Lock()
+	defer s.warm.mu.Unlock()
+	s.dropQueued()
+	s.tree.clear()
+	s.warm.root, s.warm.warmed = nil, false
+}
+
+// Clone returns a copy with its own random source and an empty tree. The
+// move cache is safe for concurrent use and stays shared.
+func (s *MCTSStrategy) Clone() Strategy {
+	clone := *s
+	clone.rand = rand.New(rand.NewSource(s.rand.Int63()))
+	clone.tree = newSearchTree()
+	clone.warm = &warmState{}
+	clone.heuristic = s.heuristic.clone()
+	return &clone
 }
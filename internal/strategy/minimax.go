@@ -0,0 +1,325 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"virusbot/config"
+	"virusbot/internal/game"
+	"virusbot/internal/logging"
+)
+
+// minimaxBranch bounds the moves tried at each step of a turn, so a full
+// three-move turn has at most minimaxBranch³ sequences
+const minimaxBranch = 5
+
+// eliminationScore dwarfs every positional term, so wiping out a player
+// always decides the search
+const eliminationScore = 1e6
+
+// MinimaxStrategy searches whole turns with depth-limited alpha-beta. A
+// player's sequence of up to a turn's moves is one ply; we maximize our
+// evaluation and every opponent minimizes it.
+type MinimaxStrategy struct {
+	depth        int
+	movesPerTurn int
+	factors      EvaluationFactors // the fallback's weights, read at each decision
+	fallback     *HeuristicStrategy
+	logger       logging.Logger
+}
+
+// NewMinimaxStrategy creates a new minimax strategy
+func NewMinimaxStrategy(cfg *config.Config) *MinimaxStrategy {
+	depth := cfg.MinimaxDepth
+	if depth <= 0 {
+		depth = 2
+	}
+	turnLength := cfg.MovesPerTurn
+	if turnLength <= 0 {
+		turnLength = movesPerTurn
+	}
+	fallback := NewHeuristicStrategy(cfg)
+	return &MinimaxStrategy{
+		depth:        depth,
+		movesPerTurn: turnLength,
+		factors:      fallback.factors,
+		fallback:     fallback,
+		logger:       cfg.Logger(),
+	}
+}
+
+// turn is one ply: the moves a player makes and the state they lead to
+type turn struct {
+	moves []game.Move
+	state *game.GameState
+}
+
+// Name returns the strategy name
+func (s *MinimaxStrategy) Name() string {
+	return "minimax"
+}
+
+// DecideMoves returns the first count moves of the best turn found
+func (s *MinimaxStrategy) DecideMoves(state *game.GameState, count int) []game.Move {
+	if !state.IsMyTurn() {
+		return nil
+	}
+	player := state.GetYourPlayer()
+	if player == nil {
+		return nil
+	}
+	s.factors = s.fallback.Factors()
+
+	turns := s.turns(state, player.ID, count)
+	if len(turns) == 0 {
+		return nil
+	}
+
+	you := player.ID
+	s.orderTurns(turns, you, true)
+	var best []game.Move
+	alpha := math.Inf(-1)
+	for _, t := range turns {
+		value := s.alphaBeta(t.state, s.depth-1, alpha, math.Inf(1), you)
+		if best == nil || value > alpha {
+			alpha, best = value, t.moves
+		}
+	}
+
+	s.logger.Debugf("Minimax picked %v (score %.2f, depth %d)", best, alpha, s.depth)
+	if len(best) > count {
+		best = best[:count]
+	}
+	return best
+}
+
+// alphaBeta returns the value of state for player you, searched depth more
+// turns
+func (s *MinimaxStrategy) alphaBeta(state *game.GameState, depth int, alpha, beta float64, you int) float64 {
+	if depth <= 0 || s.decided(state, you) {
+		return s.evaluate(state, you)
+	}
+	current := state.GetCurrentPlayer()
+	if current == nil {
+		return s.evaluate(state, you)
+	}
+
+	turns := s.turns(state, current.ID, s.turnLength(state))
+	if len(turns) == 0 {
+		// A player without moves passes
+		next := state.Clone()
+		next.AdvancePlayer()
+		if next.CurrentPlayer == state.CurrentPlayer {
+			return s.evaluate(state, you)
+		}
+		return s.alphaBeta(next, depth-1, alpha, beta, you)
+	}
+
+	maximizing := current.ID == you
+	s.orderTurns(turns, you, maximizing)
+	if maximizing {
+		value := math.Inf(-1)
+		for _, t := range turns {
+			value = math.Max(value, s.alphaBeta(t.state, depth-1, alpha, beta, you))
+			alpha = math.Max(alpha, value)
+			if alpha >= beta {
+				break
+			}
+		}
+		return value
+	}
+
+	value := math.Inf(1)
+	for _, t := range turns {
+		value = math.Min(value, s.alphaBeta(t.state, depth-1, alpha, beta, you))
+		beta = math.Min(beta, value)
+		if alpha >= beta {
+			break
+		}
+	}
+	return value
+}
+
+// turnLength returns the moves per turn announced by the game, or the
+// configured number
+func (s *MinimaxStrategy) turnLength(state *game.GameState) int {
+	if state.Board.Rules.MovesPerTurn > 0 {
+		return state.Board.Rules.MovesPerTurn
+	}
+	return s.movesPerTurn
+}
+
+// turns returns the move sequences of up to n moves playerID can make,
+// trying the best minimaxBranch candidates at each step. A sequence stops
+// early only when the player runs out of moves. Orderings of the same
+// cells are kept once. Placing neutrals, which ends the turn, is a turn of
+// its own.
+func (s *MinimaxStrategy) turns(state *game.GameState, playerID, n int) []turn {
+	var turns []turn
+	seen := make(map[string]bool)
+	start := state.Clone()
+	start.CurrentPlayer, start.MovesLeft = playerID, n
+	var extend func(current *game.GameState, moves []game.Move)
+	extend = func(current *game.GameState, moves []game.Move) {
+		var candidates []game.Move
+		switch {
+		case len(moves) == 0:
+			candidates = current.GetValidMoves(playerID)
+		case len(moves) < n:
+			candidates = current.Board.GetValidMoves(playerID)
+		}
+		if len(candidates) == 0 {
+			if key := turnKey(moves); len(moves) > 0 && !seen[key] {
+				seen[key] = true
+				next := current
+				if len(moves) < n {
+					// Out of moves early: pass the rest of the turn
+					next = current.Clone()
+					next.AdvancePlayer()
+				}
+				turns = append(turns, turn{moves: append([]game.Move(nil), moves...), state: next})
+			}
+			return
+		}
+
+		for _, move := range s.branchMoves(candidates, current, playerID) {
+			if move.Type == game.MoveNeutral {
+				turns = append(turns, turn{moves: []game.Move{move}, state: current.ApplyMove(move)})
+				continue
+			}
+			extend(current.ApplyMove(move), append(moves, move))
+		}
+	}
+	extend(start, nil)
+	return turns
+}
+
+// branchMoves returns the minimaxBranch candidates worth trying: attacks
+// first, as taking an opponent's cell moves our evaluation twice as far as
+// growing does, each group in the heuristic's order
+func (s *MinimaxStrategy) branchMoves(candidates []game.Move, state *game.GameState, playerID int) []game.Move {
+	if len(candidates) <= minimaxBranch {
+		return candidates
+	}
+	var attacks, others []game.Move
+	for _, move := range candidates {
+		if move.Type == game.MoveAttack {
+			attacks = append(attacks, move)
+		} else {
+			others = append(others, move)
+		}
+	}
+	branch := s.fallback.branchCandidates(attacks, state, playerID, minimaxBranch)
+	if len(branch) < minimaxBranch {
+		branch = append(branch, s.fallback.branchCandidates(others, state, playerID, minimaxBranch-len(branch))...)
+	}
+	return branch
+}
+
+// turnKey identifies the cells a turn takes, whatever their order
+func turnKey(moves []game.Move) string {
+	cells := make([]string, len(moves))
+	for i, move := range moves {
+		cells[i] = fmt.Sprintf("%d,%d", move.Position.Row, move.Position.Col)
+	}
+	sort.Strings(cells)
+	return strings.Join(cells, ";")
+}
+
+// orderTurns sorts turns so the most promising for the player to move come
+// first, which lets alpha-beta cut more
+func (s *MinimaxStrategy) orderTurns(turns []turn, you int, maximizing bool) {
+	values := make(map[*game.GameState]float64, len(turns))
+	for _, t := range turns {
+		values[t.state] = s.evaluate(t.state, you)
+	}
+	sort.SliceStable(turns, func(i, j int) bool {
+		if maximizing {
+			return values[turns[i].state] > values[turns[j].state]
+		}
+		return values[turns[i].state] < values[turns[j].state]
+	})
+}
+
+// decided reports whether we or every opponent have been wiped off the board
+func (s *MinimaxStrategy) decided(state *game.GameState, you int) bool {
+	if state.Board.CountCells(you) == 0 {
+		return true
+	}
+	for _, opp := range state.Players {
+		if opp.ID != you && state.Board.CountCells(opp.ID) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluate scores a position for player you: the territory and
+// base-connected cells ahead of the opponents, minus the threat to our base
+// beyond the threat we pose to theirs. Eliminations outweigh everything.
+func (s *MinimaxStrategy) evaluate(state *game.GameState, you int) float64 {
+	board := state.Board
+	ours := board.Analyze(you)
+	if ours.Cells == 0 {
+		return -eliminationScore
+	}
+
+	// Mobility counts each player's targets in one pass over the board
+	var mobility map[int]int
+	if s.factors.Mobility != 0 {
+		ids := make([]int, 0, len(state.Players))
+		for _, p := range state.Players {
+			ids = append(ids, p.ID)
+		}
+		mobility = board.MobilityByPlayer(ids)
+	}
+
+	score := s.factors.TerritoryGain*float64(ours.Cells) +
+		s.factors.Connectivity*float64(ours.Reachable) +
+		s.factors.Mobility*float64(mobility[you]) -
+		s.factors.ThreatDecay*board.BaseThreat(you)
+	for _, opp := range state.Players {
+		if opp.ID == you {
+			continue
+		}
+		theirs := board.Analyze(opp.ID)
+		if theirs.Cells == 0 {
+			score += eliminationScore
+			continue
+		}
+		score -= s.factors.TerritoryGain*float64(theirs.Cells) +
+			s.factors.Connectivity*float64(theirs.Reachable) +
+			s.factors.Mobility*float64(mobility[opp.ID])
+		score += s.factors.ThreatDecay * board.BaseThreat(opp.ID)
+	}
+	return score
+}
+
+// DecideNeutrals uses the heuristic placement
+func (s *MinimaxStrategy) DecideNeutrals(state *game.GameState) []game.Position {
+	return s.fallback.DecideNeutrals(state)
+}
+
+// OnMoveMade feeds the heuristic fallback's opponent model
+func (s *MinimaxStrategy) OnMoveMade(state *game.GameState, move game.Move) {
+	s.fallback.OnMoveMade(state, move)
+}
+
+// SetFactors replaces the weights the evaluation and the fallback use
+func (s *MinimaxStrategy) SetFactors(factors EvaluationFactors) {
+	s.fallback.SetFactors(factors)
+}
+
+// Reset resets the heuristic fallback
+func (s *MinimaxStrategy) Reset() {
+	s.fallback.Reset()
+}
+
+// Clone returns a copy with its own heuristic fallback
+func (s *MinimaxStrategy) Clone() Strategy {
+	clone := *s
+	clone.fallback = s.fallback.clone()
+	return &clone
+}
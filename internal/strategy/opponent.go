@@ -0,0 +1,85 @@
+package strategy
+
+import (
+	"sync"
+
+	"virusbot/internal/game"
+)
+
+// opponentMemory is how many of the latest opponent moves the model keeps
+const opponentMemory = 9
+
+// OpponentModel summarizes how the opponents have played this game. It is
+// updated as moves arrive rather than recomputed on every decision, and is
+// safe to update from the client goroutine while a decision is running.
+type OpponentModel struct {
+	mu     sync.Mutex
+	moves  map[int]int
+	recent []game.Position
+}
+
+// NewOpponentModel creates an empty opponent model
+func NewOpponentModel() *OpponentModel {
+	return &OpponentModel{
+		moves:  make(map[int]int),
+		recent: make([]game.Position, 0, opponentMemory),
+	}
+}
+
+// Observe records a move played by an opponent
+func (m *OpponentModel) Observe(playerID int, pos game.Position) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.moves[playerID]++
+	if len(m.recent) == opponentMemory {
+		m.recent = append(m.recent[:0], m.recent[1:]...)
+	}
+	m.recent = append(m.recent, pos)
+}
+
+// MoveCount returns how many moves of the player have been observed
+func (m *OpponentModel) MoveCount(playerID int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.moves[playerID]
+}
+
+// Recent returns the latest opponent moves, oldest first
+func (m *OpponentModel) Recent() []game.Position {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]game.Position(nil), m.recent...)
+}
+
+// Clone returns an independent copy of the model
+func (m *OpponentModel) Clone() *OpponentModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clone := NewOpponentModel()
+	for id, n := range m.moves {
+		clone.moves[id] = n
+	}
+	clone.recent = append(clone.recent, m.recent...)
+	return clone
+}
+
+// Clear forgets every observed move
+func (m *OpponentModel) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.moves = make(map[int]int)
+	m.recent = m.recent[:0]
+}
+
+// nearRecent reports whether pos touches any of the recent opponent moves
+func nearRecent(pos game.Position, recent []game.Position) bool {
+	for _, r := range recent {
+		dr, dc := pos.Row-r.Row, pos.Col-r.Col
+		if dr >= -1 && dr <= 1 && dc >= -1 && dc <= 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"virusbot/config"
+	"virusbot/internal/game"
+	"virusbot/internal/logging"
+)
+
+// Policy maps board hashes to the move a player made from that position
+type Policy struct {
+	mu    sync.Mutex
+	moves map[uint64]game.Position
+}
+
+// policyMove is the JSON form of a policy entry
+type policyMove struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// NewPolicy creates an empty policy
+func NewPolicy() *Policy {
+	return &Policy{moves: make(map[uint64]game.Position)}
+}
+
+// LoadPolicy reads a policy written by Save
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	var entries map[string]policyMove
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	policy := NewPolicy()
+	for key, move := range entries {
+		hash, err := strconv.ParseUint(key, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid board hash %q in policy: %w", key, err)
+		}
+		policy.moves[hash] = game.Position{Row: move.Row, Col: move.Col}
+	}
+	return policy, nil
+}
+
+// Save writes the policy as JSON keyed by hexadecimal board hash
+func (p *Policy) Save(path string) error {
+	p.mu.Lock()
+	entries := make(map[string]policyMove, len(p.moves))
+	for hash, pos := range p.moves {
+		entries[strconv.FormatUint(hash, 16)] = policyMove{Row: pos.Row, Col: pos.Col}
+	}
+	p.mu.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal policy: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Lookup returns the move recorded for a board hash
+func (p *Policy) Lookup(hash uint64) (game.Position, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pos, ok := p.moves[hash]
+	return pos, ok
+}
+
+// Set records the move played from a board hash
+func (p *Policy) Set(hash uint64, pos game.Position) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.moves[hash] = pos
+}
+
+// Len returns the number of recorded positions
+func (p *Policy) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.moves)
+}
+
+// PolicyRecorder builds a policy from the moves opponents make. It follows
+// the game on its own copy of the board, since move callbacks carry only the
+// move; positions reached through neutral placements are not recorded.
+type PolicyRecorder struct {
+	mu     sync.Mutex
+	policy *Policy
+	board  *game.Board
+	ourID  int
+}
+
+// NewPolicyRecorder creates a recorder adding to policy
+func NewPolicyRecorder(policy *Policy) *PolicyRecorder {
+	return &PolicyRecorder{policy: policy}
+}
+
+// Start begins following a game from the given board
+func (r *PolicyRecorder) Start(board *game.Board, ourID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.board = board.Clone()
+	r.ourID = ourID
+}
+
+// Observe records an opponent's move against the position it was made from
+// and applies every move to the followed board
+func (r *PolicyRecorder) Observe(playerID int, pos game.Position) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.board == nil || !r.board.IsValid(pos) {
+		return
+	}
+
+	if playerID != r.ourID {
+		r.policy.Set(r.board.Hash(), pos)
+	}
+	owner := r.board.GetCell(pos).Player()
+	isAttack := !r.board.IsEmpty(pos) && owner != 0 && owner != playerID
+	r.board = r.board.ApplyMove(pos, playerID, isAttack)
+}
+
+// Policy returns the policy being recorded
+func (r *PolicyRecorder) Policy() *Policy {
+	return r.policy
+}
+
+// PolicyStrategy replays a recorded policy: on a known position it plays the
+// recorded move, otherwise it plays like the heuristic
+type PolicyStrategy struct {
+	policy   *Policy
+	fallback *HeuristicStrategy
+	logger   logging.Logger
+}
+
+// NewPolicyStrategy creates a strategy replaying the configured policy file.
+// Without a readable file every position misses and the heuristic plays.
+func NewPolicyStrategy(cfg *config.Config) *PolicyStrategy {
+	policy := NewPolicy()
+	if cfg.PolicyFile != "" {
+		loaded, err := LoadPolicy(cfg.PolicyFile)
+		if err != nil {
+			cfg.Logger().Warnf("Policy strategy: %v, falling back to heuristic", err)
+		} else {
+			policy = loaded
+		}
+	}
+	return NewPolicyStrategyFrom(policy, cfg)
+}
+
+// NewPolicyStrategyFrom creates a strategy replaying an in-memory policy
+func NewPolicyStrategyFrom(policy *Policy, cfg *config.Config) *PolicyStrategy {
+	return &PolicyStrategy{
+		policy:   policy,
+		fallback: NewHeuristicStrategy(cfg),
+		logger:   cfg.Logger(),
+	}
+}
+
+// Name returns the strategy name
+func (s *PolicyStrategy) Name() string {
+	return "policy"
+}
+
+// DecideMoves plays recorded moves while the position is known and legal,
+// then tops up with heuristic moves from the position they leave behind
+func (s *PolicyStrategy) DecideMoves(state *game.GameState, count int) []game.Move {
+	if !state.IsMyTurn() {
+		return nil
+	}
+
+	player := state.GetYourPlayer()
+	if player == nil {
+		return s.fallback.DecideMoves(state, count)
+	}
+
+	board := state.Board
+	moves := make([]game.Move, 0, count)
+	for len(moves) < count {
+		pos, ok := s.policy.Lookup(board.Hash())
+		if !ok {
+			break
+		}
+		move, ok := legalMoveTo(board, player.ID, pos)
+		if !ok {
+			break
+		}
+		moves = append(moves, move)
+		board = board.ApplyMove(move.Position, player.ID, move.Fortifies())
+	}
+
+	s.logger.Debugf("Policy strategy: %d of %d moves from the policy", len(moves), count)
+	if len(moves) == count {
+		return moves
+	}
+
+	after := state.Clone()
+	after.Board = board
+	return append(moves, s.fallback.DecideMoves(after, count-len(moves))...)
+}
+
+// legalMoveTo returns the player's legal move targeting pos
+func legalMoveTo(board *game.Board, playerID int, pos game.Position) (game.Move, bool) {
+	for _, move := range board.GetValidMoves(playerID) {
+		if move.Position == pos {
+			return move, true
+		}
+	}
+	return game.Move{}, false
+}
+
+// DecideNeutrals places neutrals like the heuristic
+func (s *PolicyStrategy) DecideNeutrals(state *game.GameState) []game.Position {
+	return s.fallback.DecideNeutrals(state)
+}
+
+// OnMoveMade feeds the heuristic fallback's opponent model
+func (s *PolicyStrategy) OnMoveMade(state *game.GameState, move game.Move) {
+	s.fallback.OnMoveMade(state, move)
+}
+
+// SetFactors replaces the heuristic fallback's weights
+func (s *PolicyStrategy) SetFactors(factors EvaluationFactors) {
+	s.fallback.SetFactors(factors)
+}
+
+// Reset resets the heuristic fallback
+func (s *PolicyStrategy) Reset() {
+	s.fallback.Reset()
+}
+
+// Clone returns a copy sharing the read-only policy
+func (s *PolicyStrategy) Clone() Strategy {
+	clone := *s
+	clone.fallback = s.fallback.clone()
+	return &clone
+}
@@ -1,10 +1,20 @@
 package strategy
 
 import (
+	"context"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"virusbot/config"
 	"virusbot/internal/game"
+	"virusbot/internal/logging"
 	"virusbot/internal/protocol"
 )
 
@@ -101,7 +111,7 @@ func TestMCTSStrategyNeverReturnsInvalidMoves(t *testing.T) {
 func TestStrategyWithCompletelyOccupiedBoard(t *testing.T) {
 	cfg := &config.Config{Debug: false}
 	heuristic := NewHeuristicStrategy(cfg)
-	mcts := &MCTSStrategy{config: DefaultMCTSConfig(), debug: false}
+	mcts := &MCTSStrategy{config: DefaultMCTSConfig(), logger: logging.Nop()}
 
 	// Create a board where player 2 has no valid moves
 	board := game.NewBoard(5)
@@ -171,3 +181,1414 @@ func TestStrategyFiltersOccupiedCells(t *testing.T) {
 		}
 	}
 }
+
+func TestMobilityPenalizesCulDeSac(t *testing.T) {
+	cfg := &config.Config{WeightTerritory: 1.0, WeightMobility: 1.0}
+	strategy := NewHeuristicStrategy(cfg)
+
+	// Wall everything off with neutrals, leaving a dead-end pocket to the
+	// west of our base and an open column to the east
+	board := game.NewBoard(5)
+	for r := 0; r < 5; r++ {
+		for c := 0; c < 5; c++ {
+			board.SetCell(game.Position{Row: r, Col: c}, protocol.CellNeutral)
+		}
+	}
+	board.BasePos[1] = game.Position{Row: 2, Col: 2}
+	board.SetCell(game.Position{Row: 2, Col: 2}, protocol.CellPlayer1)
+	board.SetCell(game.Position{Row: 2, Col: 1}, protocol.CellEmpty) // cul-de-sac
+	board.SetCell(game.Position{Row: 2, Col: 3}, protocol.CellEmpty) // keeps options open
+	for r := 1; r <= 3; r++ {
+		board.SetCell(game.Position{Row: r, Col: 4}, protocol.CellEmpty)
+	}
+
+	state := &game.GameState{
+		Board:         board,
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	from := game.Position{Row: 2, Col: 2}
+	culDeSac := game.Move{Position: game.Position{Row: 2, Col: 1}, Type: game.MoveGrow, FromCell: from}
+	open := game.Move{Position: game.Position{Row: 2, Col: 3}, Type: game.MoveGrow, FromCell: from}
+
+	culDeSacScore := strategy.evaluateMove(culDeSac, state, 1)
+	openScore := strategy.evaluateMove(open, state, 1)
+
+	if culDeSacScore >= openScore {
+		t.Errorf("Expected cul-de-sac move (%.2f) to score lower than open move (%.2f)", culDeSacScore, openScore)
+	}
+}
+
+func TestHeuristicPrefersSpecialCell(t *testing.T) {
+	cfg := &config.Config{WeightTerritory: 1.0, WeightSpecial: 1.0}
+	strategy := NewHeuristicStrategy(cfg)
+
+	board := game.NewBoard(5)
+	board.BasePos[1] = game.Position{Row: 2, Col: 2}
+	board.SetCell(game.Position{Row: 2, Col: 2}, protocol.CellPlayer1)
+	special := game.Position{Row: 2, Col: 3}
+	board.SetCell(special, protocol.CellSpecial)
+
+	state := &game.GameState{
+		Board:         board,
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, game.Position{Row: 2, Col: 2}),
+		},
+	}
+
+	moves := strategy.DecideMoves(state, 1)
+	if len(moves) != 1 {
+		t.Fatalf("Expected 1 move, got %d", len(moves))
+	}
+	if moves[0].Position != special || moves[0].Type != game.MoveGrow {
+		t.Errorf("Expected a grow into the special cell at %v, got %+v", special, moves[0])
+	}
+}
+
+func TestPositionalWeightingFollowsPhase(t *testing.T) {
+	cfg := &config.Config{WeightStrategic: 1.0, WeightOpeningCenter: 0.8, WeightEndgameEdge: 0.5}
+	strategy := NewHeuristicStrategy(cfg)
+
+	center := game.Move{Position: game.Position{Row: 3, Col: 3}, Type: game.MoveGrow}
+	corner := game.Move{Position: game.Position{Row: 0, Col: 0}, Type: game.MoveGrow}
+
+	opening := &game.GameState{Board: game.NewBoard(7), YourPlayerID: 1}
+	if phase := opening.Phase(); phase != game.PhaseOpening {
+		t.Fatalf("Expected opening, got %v", phase)
+	}
+	centerScore := strategy.evaluateMove(center, opening, 1)
+	cornerScore := strategy.evaluateMove(corner, opening, 1)
+	if centerScore <= cornerScore {
+		t.Errorf("Opening: expected center (%.2f) to outscore corner (%.2f)", centerScore, cornerScore)
+	}
+
+	// Fill everything but the two targets
+	board := game.NewBoard(7)
+	for r := 0; r < 7; r++ {
+		for c := 0; c < 7; c++ {
+			board.SetCell(game.Position{Row: r, Col: c}, protocol.CellNeutral)
+		}
+	}
+	board.SetCell(center.Position, protocol.CellEmpty)
+	board.SetCell(corner.Position, protocol.CellEmpty)
+	endgame := &game.GameState{Board: board, YourPlayerID: 1}
+	if phase := endgame.Phase(); phase != game.PhaseEndgame {
+		t.Fatalf("Expected endgame, got %v", phase)
+	}
+	centerScore = strategy.evaluateMove(center, endgame, 1)
+	cornerScore = strategy.evaluateMove(corner, endgame, 1)
+	if cornerScore <= centerScore {
+		t.Errorf("Endgame: expected corner (%.2f) to outscore center (%.2f)", cornerScore, centerScore)
+	}
+}
+
+func TestDisconnectPlannerFindsTwoMoveCut(t *testing.T) {
+	// Player 2 holds a two-row band along the top with its base in the
+	// corner; the arm west of column 5 hangs on the two cells of column 5
+	board := game.NewBoard(7)
+	board.BasePos[1] = game.Position{Row: 3, Col: 5}
+	board.BasePos[2] = game.Position{Row: 0, Col: 6}
+	board.SetCell(game.Position{Row: 3, Col: 5}, protocol.CellType(1|int(protocol.CellFlagBase)))
+	board.SetCell(game.Position{Row: 2, Col: 5}, protocol.CellPlayer1)
+	for r := 0; r <= 1; r++ {
+		for c := 2; c <= 6; c++ {
+			board.SetCell(game.Position{Row: r, Col: c}, protocol.CellPlayer2)
+		}
+	}
+	board.SetCell(game.Position{Row: 0, Col: 6}, protocol.CellType(2|int(protocol.CellFlagBase)))
+
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, game.Position{Row: 3, Col: 5}),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, game.Position{Row: 0, Col: 6}),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	// No single attack cuts anything off
+	for _, move := range board.GetAttackMoves(1) {
+		if cut := board.WouldDisconnect(move.Position, 2); cut != 0 {
+			t.Fatalf("Expected no single-move cut, %v cuts %d", move.Position, cut)
+		}
+	}
+
+	strategy := NewDisconnectStrategy(&config.Config{MovesPerTurn: 3, WeightTerritory: 1.0})
+	plan, cut := strategy.PlanTurn(board, 1, 2)
+	if cut != 6 {
+		t.Errorf("Expected the plan to cut off 6 cells, got %d (plan %v)", cut, plan)
+	}
+	want := []game.Position{{Row: 1, Col: 5}, {Row: 0, Col: 5}}
+	if len(plan) != len(want) {
+		t.Fatalf("Expected a %d-move plan, got %v", len(want), plan)
+	}
+	for i, pos := range want {
+		if plan[i].Position != pos {
+			t.Errorf("Plan move %d: expected %v, got %v", i, pos, plan[i].Position)
+		}
+	}
+
+	moves := strategy.DecideMoves(state, 1)
+	if len(moves) != 1 || moves[0].Position != want[0] {
+		t.Errorf("Expected DecideMoves to start the plan at %v, got %v", want[0], moves)
+	}
+}
+
+// referenceScore evaluates a move with one board query per factor, the way
+// the heuristic did before moves were annotated in a batch
+func referenceScore(s *HeuristicStrategy, move game.Move, state *game.GameState, playerID int) float64 {
+	board := state.Board
+	score := 10.0 * s.factors.TerritoryGain
+
+	if board.IsCornerPosition(move.Position) {
+		score += 8.0 * s.factors.StrategicPosition
+	} else if board.IsEdgePosition(move.Position) {
+		score += 5.0 * s.factors.StrategicPosition
+	}
+
+	if move.Type == game.MoveAttack {
+		score += 15.0 * s.factors.ThreatRemoval
+	}
+
+	if !board.IsConnectedToBase(playerID, move.Position) {
+		for _, cell := range board.GetReachableCells(playerID) {
+			if board.IsAdjacent(cell, move.Position) {
+				score += 3.0 * s.factors.Connectivity
+				break
+			}
+		}
+	}
+
+	score += float64(len(board.GetEmptyNeighbors(move.Position))) * 4.0 * s.factors.ExpansionPotential
+
+	bridges := len(board.ApplyMove(move.Position, playerID, move.Fortifies()).ArticulationCells(playerID)) -
+		len(board.ArticulationCells(playerID))
+	if s.hasDefensiveValue(move, state, playerID) || bridges < 0 {
+		score += 2.0 * s.factors.DefensiveValue
+	}
+	if bridges > 0 {
+		score -= 2.0 * s.factors.DefensiveValue
+	}
+
+	next := board.ApplyMove(move.Position, playerID, move.Fortifies())
+	targets := make(map[game.Position]bool)
+	for _, m := range next.GetValidMoves(playerID) {
+		targets[m.Position] = true
+	}
+	mobility := len(targets)
+	score += float64(mobility) * s.factors.Mobility
+	if turnLength := state.Config().TurnLength(); mobility < turnLength {
+		score -= float64(turnLength-mobility) * 10.0 * s.factors.Mobility
+	}
+
+	if board.IsSpecial(move.Position) {
+		score += 25.0 * s.factors.SpecialCapture
+	}
+
+	score += (next.Compactness(playerID) - board.Compactness(playerID)) * 50.0 * s.factors.Compactness
+	holesBefore := board.InternalHoles(playerID)
+	if holesBefore > 0 && next.InternalHoles(playerID) < holesBefore {
+		score += 6.0 * s.factors.Compactness
+	}
+
+	contested := false
+	for _, cell := range board.GetPlayerCells(playerID) {
+		for _, neighbor := range board.GetNeighbors(cell) {
+			if p := board.GetCell(neighbor).Player(); p >= 1 && p <= 4 && p != playerID {
+				contested = true
+			}
+		}
+	}
+	perimeterDelta := float64(next.PerimeterLength(playerID) - board.PerimeterLength(playerID))
+	if contested {
+		score -= perimeterDelta * 4.0 * s.factors.Compactness
+	} else {
+		score += perimeterDelta * s.factors.ExpansionPotential
+	}
+
+	score -= next.BaseThreat(playerID) * s.factors.ThreatDecay
+
+	return score
+}
+
+// createMidGameState builds a two-player position with attacks available
+func createMidGameState() *game.GameState {
+	board := game.NewBoard(8)
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.BasePos[2] = game.Position{Row: 7, Col: 7}
+
+	board.SetCell(game.Position{Row: 0, Col: 0}, protocol.CellType(1|int(protocol.CellFlagBase)))
+	for _, pos := range []game.Position{{Row: 0, Col: 1}, {Row: 1, Col: 1}, {Row: 2, Col: 2}, {Row: 3, Col: 3}, {Row: 5, Col: 0}} {
+		board.SetCell(pos, protocol.CellPlayer1)
+	}
+	board.SetCell(game.Position{Row: 7, Col: 7}, protocol.CellType(2|int(protocol.CellFlagBase)))
+	for _, pos := range []game.Position{{Row: 6, Col: 6}, {Row: 5, Col: 5}, {Row: 4, Col: 4}, {Row: 4, Col: 3}, {Row: 1, Col: 6}} {
+		board.SetCell(pos, protocol.CellPlayer2)
+	}
+
+	return &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, game.Position{Row: 0, Col: 0}),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, game.Position{Row: 7, Col: 7}),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+}
+
+func TestFeatureScoresMatchPerMoveScores(t *testing.T) {
+	strategy := NewHeuristicStrategy(&config.Config{
+		WeightTerritory:    1.0,
+		WeightStrategic:    0.5,
+		WeightThreat:       1.5,
+		WeightConnectivity: 0.3,
+		WeightExpansion:    0.4,
+		WeightDefensive:    0.2,
+		WeightMobility:     0.3,
+		WeightCompactness:  0.5,
+	})
+	for _, state := range []*game.GameState{createMidGameState(), createGeneratedState(12, 0.4, 3)} {
+		moves := state.Board.GetValidMoves(1)
+
+		scored := strategy.scoreMoves(moves, state)
+		if len(scored) != len(moves) {
+			t.Fatalf("Expected %d scored moves, got %d", len(moves), len(scored))
+		}
+
+		for _, sm := range scored {
+			want := referenceScore(strategy, sm.move, state, 1)
+			if math.Abs(sm.score-want) > 1e-9 {
+				t.Errorf("Move %v scored %.4f, want %.4f", sm.move.Position, sm.score, want)
+			}
+		}
+	}
+}
+
+// createGeneratedState wraps a generated two-player board, player 1 to move
+func createGeneratedState(size int, fill float64, seed int64) *game.GameState {
+	board := game.GenerateBoard(size, fill, seed)
+	return &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+}
+
+func BenchmarkScoreMovesBatch(b *testing.B) {
+	strategy := NewHeuristicStrategy(&config.Config{WeightTerritory: 1.0, WeightExpansion: 0.4, WeightMobility: 0.3})
+	state := createGeneratedState(16, 0.4, 1)
+	moves := state.Board.GetValidMoves(1)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		strategy.scoreMoves(moves, state)
+	}
+}
+
+func BenchmarkScoreMovesPerMove(b *testing.B) {
+	strategy := NewHeuristicStrategy(&config.Config{WeightTerritory: 1.0, WeightExpansion: 0.4, WeightMobility: 0.3})
+	state := createGeneratedState(16, 0.4, 1)
+	moves := state.Board.GetValidMoves(1)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, move := range moves {
+			referenceScore(strategy, move, state, 1)
+		}
+	}
+}
+
+// stubStrategy returns fixed moves after an optional delay
+type stubStrategy struct {
+	name  string
+	moves []game.Move
+	delay time.Duration
+	calls int
+}
+
+func (s *stubStrategy) Name() string { return s.name }
+
+func (s *stubStrategy) DecideMoves(state *game.GameState, count int) []game.Move {
+	s.calls++
+	time.Sleep(s.delay)
+	return s.moves
+}
+
+func (s *stubStrategy) DecideNeutrals(state *game.GameState) []game.Position { return nil }
+
+func (s *stubStrategy) OnMoveMade(state *game.GameState, move game.Move) {}
+
+func (s *stubStrategy) Reset() {}
+
+func (s *stubStrategy) Clone() Strategy { return s }
+
+func TestChainStrategyFallsThrough(t *testing.T) {
+	want := game.Move{Position: game.Position{Row: 1, Col: 2}}
+	empty := &stubStrategy{name: "empty"}
+	slow := &stubStrategy{name: "slow", moves: []game.Move{{}}, delay: 200 * time.Millisecond}
+	working := &stubStrategy{name: "working", moves: []game.Move{want}}
+
+	chain := NewChainStrategy([]Strategy{empty, slow, working}, 20*time.Millisecond, logging.Nop())
+	if chain.Name() != "chain(empty,slow,working)" {
+		t.Errorf("Unexpected chain name %q", chain.Name())
+	}
+
+	moves := chain.DecideMoves(createMidGameState(), 1)
+	if len(moves) != 1 || moves[0] != want {
+		t.Fatalf("Expected the chain to fall through to the working strategy, got %v", moves)
+	}
+	if empty.calls != 1 || working.calls != 1 {
+		t.Errorf("Expected each strategy to be tried once, got empty=%d working=%d", empty.calls, working.calls)
+	}
+}
+
+// countingStrategy counts its calls safely, as a timed-out call runs on
+type countingStrategy struct {
+	stubStrategy
+	started atomic.Int32
+}
+
+func (s *countingStrategy) DecideMoves(state *game.GameState, count int) []game.Move {
+	s.started.Add(1)
+	time.Sleep(s.delay)
+	return s.moves
+}
+
+func TestChainStrategySkipsBusyStrategy(t *testing.T) {
+	slow := &countingStrategy{stubStrategy: stubStrategy{name: "slow", moves: []game.Move{{}}, delay: 200 * time.Millisecond}}
+	working := &stubStrategy{name: "working", moves: []game.Move{{Position: game.Position{Row: 1, Col: 2}}}}
+	chain := NewChainStrategy([]Strategy{slow, working}, 20*time.Millisecond, logging.Nop())
+
+	chain.DecideMoves(createMidGameState(), 1)
+	chain.DecideMoves(createMidGameState(), 1)
+	if n := slow.started.Load(); n != 1 {
+		t.Errorf("Expected the timed-out strategy not to be called again while running, got %d calls", n)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	chain.DecideMoves(createMidGameState(), 1)
+	if n := slow.started.Load(); n != 2 {
+		t.Errorf("Expected the strategy to be tried again once it returned, got %d calls", n)
+	}
+}
+
+// budgetedStub records the budget it is given
+type budgetedStub struct {
+	stubStrategy
+	budget time.Duration
+}
+
+func (s *budgetedStub) DecideMovesWithin(state *game.GameState, count int, remaining time.Duration) []game.Move {
+	s.budget = remaining
+	return s.moves
+}
+
+func TestChainStrategyHandsLimitToBudgetedStrategy(t *testing.T) {
+	budgeted := &budgetedStub{stubStrategy: stubStrategy{name: "budgeted", moves: []game.Move{{}}}}
+	chain := NewChainStrategy([]Strategy{budgeted}, 30*time.Millisecond, logging.Nop())
+
+	if moves := chain.DecideMoves(createMidGameState(), 1); len(moves) != 1 {
+		t.Fatalf("Expected the budgeted strategy's move, got %v", moves)
+	}
+	if budgeted.budget != 30*time.Millisecond {
+		t.Errorf("Expected the step limit as budget, got %v", budgeted.budget)
+	}
+	if budgeted.calls != 0 {
+		t.Error("Expected DecideMovesWithin instead of DecideMoves")
+	}
+}
+
+func TestNewStrategyBuildsChain(t *testing.T) {
+	cfg := &config.Config{StrategyChain: []string{"mcts", "bogus", "heuristic"}}
+
+	chain, ok := NewStrategy(cfg).(*ChainStrategy)
+	if !ok {
+		t.Fatal("Expected a chain strategy when a chain is configured")
+	}
+	if chain.Name() != "chain(mcts,heuristic)" {
+		t.Errorf("Expected unknown names to be skipped, got %q", chain.Name())
+	}
+}
+
+func TestMCTSExpansionPrunesSymmetricMoves(t *testing.T) {
+	mcts := NewMCTSStrategy(&config.Config{MCTSIterations: 10})
+
+	board := game.NewBoard(6)
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.BasePos[2] = game.Position{Row: 5, Col: 5}
+	board.SetCell(game.Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(game.Position{Row: 5, Col: 5}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	state := &game.GameState{Board: board, CurrentPlayer: 1, YourPlayerID: 1}
+
+	moves := board.GetValidMoves(1)
+	children := mcts.expandMoves(state, moves)
+	if len(children) >= len(moves) {
+		t.Errorf("Expected fewer children (%d) than legal moves (%d) on a symmetric start", len(children), len(moves))
+	}
+}
+
+func TestHeuristicEpsilonExploration(t *testing.T) {
+	state := createMidGameState()
+	sameMoves := func(a, b []game.Move) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i].Position != b[i].Position {
+				return false
+			}
+		}
+		return true
+	}
+
+	greedy := NewHeuristicStrategy(&config.Config{})
+	want := greedy.DecideMoves(state, 3)
+	for i := 0; i < 50; i++ {
+		if got := greedy.DecideMoves(state, 3); !sameMoves(got, want) {
+			t.Fatalf("Expected epsilon=0 to be deterministic, got %v then %v", want, got)
+		}
+	}
+
+	explorer := NewHeuristicStrategy(&config.Config{HeuristicEpsilon: 0.3})
+	diverged := false
+	for i := 0; i < 200 && !diverged; i++ {
+		got := explorer.DecideMoves(state, 3)
+		if len(got) != 3 {
+			t.Fatalf("Expected 3 moves, got %v", got)
+		}
+		for _, move := range got {
+			if !game.ValidMove(state.Board, 1, move) {
+				t.Fatalf("Exploration returned illegal move %v", move)
+			}
+		}
+		diverged = !sameMoves(got, want)
+	}
+	if !diverged {
+		t.Error("Expected epsilon>0 to diverge from the greedy choice over many samples")
+	}
+}
+
+func TestOpponentModelUpdatesOnOpponentMovesOnly(t *testing.T) {
+	s := NewHeuristicStrategy(&config.Config{WeightDefensive: 1})
+	opponentMove := game.Move{Position: game.Position{Row: 4, Col: 4}}
+
+	// Our own moves are not part of the opponent model
+	s.OnMoveMade(&game.GameState{CurrentPlayer: 1, YourPlayerID: 1}, game.Move{Position: game.Position{Row: 1, Col: 1}})
+	if got := s.opponents.Recent(); len(got) != 0 {
+		t.Fatalf("Expected our own move to be ignored, got %v", got)
+	}
+
+	s.OnMoveMade(&game.GameState{CurrentPlayer: 2, YourPlayerID: 1}, opponentMove)
+	if got := s.opponents.MoveCount(2); got != 1 {
+		t.Errorf("Expected 1 observed move for player 2, got %d", got)
+	}
+	if got := s.opponents.Recent(); len(got) != 1 || got[0] != opponentMove.Position {
+		t.Errorf("Expected the opponent move to be recorded, got %v", got)
+	}
+
+	// Moves next to the opponent's latest move get the response bonus
+	scored := []scoredMove{
+		{move: game.Move{Position: game.Position{Row: 4, Col: 3}}},
+		{move: game.Move{Position: game.Position{Row: 0, Col: 0}}},
+	}
+	s.respondToOpponents(scored)
+	if scored[0].score != responseBonus || scored[1].score != 0 {
+		t.Errorf("Expected only the move near the opponent to gain %v, got %v and %v", responseBonus, scored[0].score, scored[1].score)
+	}
+
+	s.Reset()
+	if got := s.opponents.MoveCount(2); got != 0 {
+		t.Errorf("Expected Reset to clear move counts, got %d", got)
+	}
+	if got := s.opponents.Recent(); len(got) != 0 {
+		t.Errorf("Expected Reset to clear recent moves, got %v", got)
+	}
+}
+
+func TestCloneDoesNotShareState(t *testing.T) {
+	original := NewHeuristicStrategy(&config.Config{})
+	original.OnMoveMade(&game.GameState{CurrentPlayer: 2, YourPlayerID: 1}, game.Move{Position: game.Position{Row: 1, Col: 1}})
+
+	clone := original.Clone().(*HeuristicStrategy)
+	if got := clone.opponents.MoveCount(2); got != 1 {
+		t.Fatalf("Expected the clone to start from the original's model, got %d moves", got)
+	}
+
+	clone.OnMoveMade(&game.GameState{CurrentPlayer: 2, YourPlayerID: 1}, game.Move{Position: game.Position{Row: 2, Col: 2}})
+	if got := original.opponents.MoveCount(2); got != 1 {
+		t.Errorf("Mutating the clone changed the original: %d moves", got)
+	}
+
+	original.Reset()
+	if got := clone.opponents.MoveCount(2); got != 2 {
+		t.Errorf("Resetting the original changed the clone: %d moves", got)
+	}
+
+	// Chains clone their members
+	chain := NewChainStrategy([]Strategy{original}, 0, logging.Nop())
+	chainClone := chain.Clone().(*ChainStrategy)
+	if chainClone.strategies[0] == Strategy(original) {
+		t.Error("Expected the chain clone to hold a cloned heuristic")
+	}
+}
+
+func TestCandidateCapBoundsOpeningOnLargeBoard(t *testing.T) {
+	state := &game.GameState{
+		Board:         game.NewBoard(30),
+		Players:       []*game.Player{game.NewPlayer(1, "Bot", protocol.CellPlayer1, game.Position{Row: 0, Col: 0})},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+	moves := state.Board.GetValidMoves(1)
+	if len(moves) != 900 {
+		t.Fatalf("Expected 900 opening moves on an empty 30x30 board, got %d", len(moves))
+	}
+
+	heuristic := NewHeuristicStrategy(&config.Config{})
+	capped := heuristic.capCandidates(moves, state, 1, 50)
+	if len(capped) != 50 {
+		t.Fatalf("Expected the cap to keep 50 candidates, got %d", len(capped))
+	}
+	if got := heuristic.capCandidates(moves, state, 1, 0); len(got) != len(moves) {
+		t.Errorf("Expected a zero cap to keep every move, got %d", len(got))
+	}
+
+	// Past the opening every move is scored
+	midgame := createGeneratedState(10, 0.4, 1)
+	if midgame.Phase() == game.PhaseOpening {
+		t.Fatal("Test state must be past the opening")
+	}
+	all := midgame.Board.GetValidMoves(1)
+	if got := heuristic.capCandidates(all, midgame, 1, 2); len(got) != len(all) {
+		t.Errorf("Expected no cap past the opening, kept %d of %d moves", len(got), len(all))
+	}
+
+	cfg := &config.Config{MaxCandidates: 50, WeightStrategic: 0.5, WeightOpeningCenter: 0.5, WeightExpansion: 0.4}
+	chosen := NewHeuristicStrategy(cfg).DecideMoves(state, 1)
+	if len(chosen) != 1 {
+		t.Fatalf("Expected one move, got %v", chosen)
+	}
+	// The kept candidates form the central region of the board
+	if pos := chosen[0].Position; pos.Row < 10 || pos.Row > 19 || pos.Col < 10 || pos.Col > 19 {
+		t.Errorf("Expected a central opening move, got %v", pos)
+	}
+}
+
+func TestMCTSWarmUpPopulatesCacheBeforeFirstDecision(t *testing.T) {
+	board := createTestBoard()
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.BasePos[2] = game.Position{Row: 9, Col: 9}
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 2,
+		YourPlayerID:  1,
+	}
+
+	// A cancelled warm-up does no work
+	cancelled := NewMCTSStrategy(&config.Config{MCTSWarmup: 50})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelled.WarmUp(ctx, state)
+	if n := cancelled.cache.Len(); n != 0 {
+		t.Errorf("Expected a cancelled warm-up to leave the cache empty, got %d entries", n)
+	}
+	if visits := cancelled.tree.rootVisits(); visits != 0 {
+		t.Errorf("Expected a cancelled warm-up to leave the tree empty, got %d visits", visits)
+	}
+
+	// The opponent has one move left before our turn
+	state.MovesLeft = 1
+	mcts := NewMCTSStrategy(&config.Config{MCTSWarmup: 50, MCTSIterations: 10, MCTSWorkers: 1, MCTSTimeLimit: time.Second})
+	mcts.WarmUp(context.Background(), state)
+	if n := mcts.cache.Len(); n == 0 {
+		t.Fatal("Expected warm-up to populate the search cache")
+	}
+	warmed := mcts.tree.rootVisits()
+	if warmed == 0 {
+		t.Fatal("Expected warm-up to search the tree")
+	}
+
+	// The first decision searches on from the opponent's move
+	opening := game.Move{Position: game.Position{Row: 8, Col: 9}}
+	mcts.OnMoveMade(state, opening)
+	ours := state.ApplyMove(opening)
+	following := mcts.tree.rootVisits()
+	if following == 0 {
+		t.Fatal("Expected the warm-up tree to follow the opponent's move")
+	}
+	if moves := mcts.DecideMoves(ours, 1); len(moves) != 1 {
+		t.Fatalf("Expected a move, got %v", moves)
+	}
+	if visits := mcts.tree.rootVisits(); visits <= following {
+		t.Errorf("Expected the first decision to keep the %d warm-up visits, got %d", following, visits)
+	}
+}
+
+func TestMCTSQueuesMovesWhileSearching(t *testing.T) {
+	board := game.NewBoard(5)
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.BasePos[2] = game.Position{Row: 4, Col: 4}
+	board.SetCell(game.Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(game.Position{Row: 1, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(game.Position{Row: 4, Col: 4}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	board.SetCell(game.Position{Row: 2, Col: 2}, protocol.CellPlayer2)
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 2,
+		YourPlayerID:  1,
+	}
+
+	mcts := NewMCTSStrategy(&config.Config{MCTSWarmup: 1, MCTSWorkers: 1})
+	mcts.WarmUp(context.Background(), state)
+
+	// A search holding the tree must not hold up the reported move
+	attack := game.Move{Position: game.Position{Row: 1, Col: 1}}
+	mcts.warm.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		mcts.OnMoveMade(state, attack)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected OnMoveMade not to wait for the search")
+	}
+	if mcts.warm.root.Board.IsOwnedBy(attack.Position, 2) {
+		t.Error("Expected the move to wait until the search is done")
+	}
+	mcts.applyQueued()
+	mcts.warm.mu.Unlock()
+
+	// The reported cell was ours, so the move is replayed as an attack
+	if cell := mcts.warm.root.Board.GetCell(attack.Position); cell.Player() != 2 || !cell.IsFortified() {
+		t.Errorf("Expected the attacked cell to be player 2's and fortified, got %v", cell)
+	}
+}
+
+func TestFreeForAllDampensAttackPreference(t *testing.T) {
+	board := game.NewBoard(10)
+	board.BasePos[1] = game.Position{Row: 4, Col: 4}
+	board.BasePos[2] = game.Position{Row: 4, Col: 6}
+	board.SetCell(game.Position{Row: 4, Col: 4}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(game.Position{Row: 4, Col: 5}, protocol.CellPlayer1)
+	board.SetCell(game.Position{Row: 4, Col: 6}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	board.SetCell(game.Position{Row: 3, Col: 6}, protocol.CellPlayer2)
+
+	players := []*game.Player{
+		game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+		game.NewPlayer(2, "P2", protocol.CellPlayer2, board.BasePos[2]),
+		game.NewPlayer(3, "P3", protocol.CellPlayer3, game.Position{Row: 0, Col: 9}),
+		game.NewPlayer(4, "P4", protocol.CellPlayer4, game.Position{Row: 9, Col: 0}),
+	}
+
+	// attackMargin returns how much the best attack outscores the best grow
+	attackMargin := func(alive int) float64 {
+		for i, p := range players {
+			p.IsAlive = i < alive
+		}
+		state := &game.GameState{Board: board, Players: players, CurrentPlayer: 1, YourPlayerID: 1}
+		s := NewHeuristicStrategy(&config.Config{WeightTerritory: 1.0, WeightThreat: 1.5, WeightExpansion: 0.4, WeightFFAAttack: 0.5, WeightFFAExpansion: 0.5})
+		bestAttack, bestGrow := math.Inf(-1), math.Inf(-1)
+		for _, sm := range s.scoreMoves(board.GetValidMoves(1), state) {
+			if sm.move.Type == game.MoveAttack {
+				bestAttack = math.Max(bestAttack, sm.score)
+			} else {
+				bestGrow = math.Max(bestGrow, sm.score)
+			}
+		}
+		return bestAttack - bestGrow
+	}
+
+	duel, ffa := attackMargin(2), attackMargin(4)
+	if ffa >= duel {
+		t.Errorf("Expected attacks to be less attractive with 4 alive (margin %.2f) than with 2 (margin %.2f)", ffa, duel)
+	}
+}
+
+func TestMCTSTreeReuseKeepsMatchingSubtree(t *testing.T) {
+	mcts := NewMCTSStrategy(&config.Config{MCTSIterations: 20, MCTSTimeLimit: time.Second, MCTSReuseTree: true})
+	ours := game.Move{Position: game.Position{Row: 1, Col: 1}}
+	reply := game.Move{Position: game.Position{Row: 5, Col: 5}}
+	other := game.Move{Position: game.Position{Row: 6, Col: 6}}
+	mcts.tree.record([]game.Move{ours, reply}, 1)
+	mcts.tree.record([]game.Move{ours, reply}, 0)
+	mcts.tree.record([]game.Move{ours, other}, 1)
+
+	mcts.OnMoveMade(&game.GameState{CurrentPlayer: 1, YourPlayerID: 1}, ours)
+	mcts.OnMoveMade(&game.GameState{CurrentPlayer: 2, YourPlayerID: 1}, reply)
+	if visits := mcts.tree.rootVisits(); visits != 2 {
+		t.Fatalf("Expected the re-rooted tree to keep 2 visits, got %d", visits)
+	}
+
+	// A reply that was never explored discards the tree
+	mcts.OnMoveMade(&game.GameState{CurrentPlayer: 2, YourPlayerID: 1}, other)
+	if visits := mcts.tree.rootVisits(); visits != 0 {
+		t.Errorf("Expected an unexplored reply to start a new tree, got %d visits", visits)
+	}
+
+	// Playouts from a real decision land in the tree and survive our move
+	state := createMidGameState()
+	state.CurrentPlayer = 1
+	chosen := mcts.DecideMoves(state, 1)
+	if len(chosen) != 1 {
+		t.Fatalf("Expected one move, got %v", chosen)
+	}
+	visits, _ := mcts.tree.stats(chosen[0])
+	mcts.OnMoveMade(state, chosen[0])
+	if got := mcts.tree.rootVisits(); visits == 0 || got != visits {
+		t.Errorf("Expected the chosen move's %d visits to be kept, got %d", visits, got)
+	}
+}
+
+func TestPolicyRecordThenReplay(t *testing.T) {
+	state := createMidGameState()
+	opponent := []game.Position{{Row: 5, Col: 4}, {Row: 4, Col: 5}, {Row: 3, Col: 4}}
+
+	// Record player 2's turn as seen by player 1, plus one of our own moves
+	recorder := NewPolicyRecorder(NewPolicy())
+	recorder.Start(state.Board, 1)
+	recorder.Observe(1, game.Position{Row: 1, Col: 2})
+	afterOurs := state.Board.ApplyMove(game.Position{Row: 1, Col: 2}, 1, false)
+	for _, pos := range opponent {
+		recorder.Observe(2, pos)
+	}
+	if n := recorder.Policy().Len(); n != len(opponent) {
+		t.Fatalf("Expected only the %d opponent moves recorded, got %d", len(opponent), n)
+	}
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := recorder.Policy().Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Replaying as player 2 from the recorded position reproduces the turn
+	replay := NewPolicyStrategy(&config.Config{PolicyFile: path, WeightTerritory: 1.0})
+	asOpponent := state.Clone()
+	asOpponent.Board = afterOurs
+	asOpponent.CurrentPlayer, asOpponent.YourPlayerID = 2, 2
+	moves := replay.DecideMoves(asOpponent, len(opponent))
+	if len(moves) != len(opponent) {
+		t.Fatalf("Expected %d moves, got %v", len(opponent), moves)
+	}
+	for i, move := range moves {
+		if move.Position != opponent[i] {
+			t.Errorf("Move %d: got %v, want recorded %v", i, move.Position, opponent[i])
+		}
+	}
+
+	// An unknown position falls back to the heuristic
+	asOpponent.Board = state.Board
+	if moves := replay.DecideMoves(asOpponent, 1); len(moves) != 1 {
+		t.Errorf("Expected a heuristic move on a policy miss, got %v", moves)
+	}
+}
+
+func TestThreatDecayGrowsAsEnemyApproachesBase(t *testing.T) {
+	cfg := &config.Config{WeightTerritory: 1.0, WeightThreatDecay: 5.0}
+	grow := game.Move{Position: game.Position{Row: 0, Col: 1}, Type: game.MoveGrow, FromCell: game.Position{Row: 0, Col: 0}}
+
+	// evaluate scores the same grow with a lone enemy cell at enemy
+	evaluate := func(enemy game.Position) (float64, float64) {
+		board := game.NewBoard(8)
+		board.BasePos[1] = game.Position{Row: 0, Col: 0}
+		board.BasePos[2] = game.Position{Row: 7, Col: 7}
+		board.SetCell(game.Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+		board.SetCell(game.Position{Row: 7, Col: 7}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+		board.SetCell(enemy, protocol.CellPlayer2)
+
+		state := &game.GameState{
+			Board: board,
+			Players: []*game.Player{
+				game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+				game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+			},
+			CurrentPlayer: 1,
+			YourPlayerID:  1,
+		}
+		threat := board.AnnotateMoves([]game.Move{grow}, 1)[0].BaseThreat
+		return threat, NewHeuristicStrategy(cfg).evaluateMove(grow, state, 1)
+	}
+
+	farThreat, farScore := evaluate(game.Position{Row: 3, Col: 3})
+	nearThreat, nearScore := evaluate(game.Position{Row: 2, Col: 2})
+	if nearThreat <= farThreat {
+		t.Errorf("Expected a higher threat one cell closer to the base, got %.3f vs %.3f", nearThreat, farThreat)
+	}
+	if nearScore >= farScore {
+		t.Errorf("Expected the closer enemy to lower the score, got %.3f vs %.3f", nearScore, farScore)
+	}
+}
+
+func TestNormalizedEvalComparableAcrossBoardSizes(t *testing.T) {
+	// blockState gives player 1 a block of side block in the top-left corner
+	// of a size x size board, with the opponent's base in the far corner
+	blockState := func(size, block int) *game.GameState {
+		board := game.NewBoard(size)
+		board.BasePos[1] = game.Position{Row: 0, Col: 0}
+		board.BasePos[2] = game.Position{Row: size - 1, Col: size - 1}
+		for row := 0; row < block; row++ {
+			for col := 0; col < block; col++ {
+				board.SetCell(game.Position{Row: row, Col: col}, protocol.CellPlayer1)
+			}
+		}
+		board.SetCell(board.BasePos[1], protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+		board.SetCell(board.BasePos[2], protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+		return &game.GameState{
+			Board: board,
+			Players: []*game.Player{
+				game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+				game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+			},
+			CurrentPlayer: 1,
+			YourPlayerID:  1,
+		}
+	}
+
+	// score rates growing out of the middle of the block's right side
+	score := func(normalize bool, size, block int) float64 {
+		s := NewHeuristicStrategy(&config.Config{
+			WeightTerritory:   1.0,
+			WeightExpansion:   0.4,
+			WeightMobility:    0.3,
+			WeightCompactness: 0.5,
+			NormalizeEval:     normalize,
+		})
+		from := game.Position{Row: block / 2, Col: block - 1}
+		move := game.Move{Position: game.Position{Row: block / 2, Col: block}, Type: game.MoveGrow, FromCell: from}
+		return s.evaluateMove(move, blockState(size, block), 1)
+	}
+
+	rawSmall, rawLarge := score(false, 10, 3), score(false, 20, 6)
+	small, large := score(true, 10, 3), score(true, 20, 6)
+	if math.Abs(small-large) >= math.Abs(rawSmall-rawLarge) {
+		t.Errorf("Expected normalization to narrow the gap: raw %.2f vs %.2f, normalized %.2f vs %.2f", rawSmall, rawLarge, small, large)
+	}
+	if math.Abs(small-large) > 0.1*math.Abs(small) {
+		t.Errorf("Expected similar normalized scores, got %.2f on 10x10 and %.2f on 20x20", small, large)
+	}
+}
+
+func TestMCTSVisitsForcedWinMost(t *testing.T) {
+	board := game.NewBoard(5)
+	board.Rules.BasesAttackable = true
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.BasePos[2] = game.Position{Row: 4, Col: 4}
+	board.SetCell(game.Position{Row: 0, Col: 0}, protocol.CellType(1|int(protocol.CellFlagBase)))
+	for _, pos := range []game.Position{{Row: 1, Col: 1}, {Row: 2, Col: 2}, {Row: 3, Col: 3}} {
+		board.SetCell(pos, protocol.CellPlayer1)
+	}
+	// The opponent is down to its base, next to our chain
+	board.SetCell(game.Position{Row: 4, Col: 4}, protocol.CellType(2|int(protocol.CellFlagBase)))
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, game.Position{Row: 0, Col: 0}),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, game.Position{Row: 4, Col: 4}),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	mcts := NewMCTSStrategy(&config.Config{MCTSIterations: 2000, MCTSTimeLimit: 10 * time.Second, MCTSUCTConst: 1.41})
+	chosen := mcts.DecideMoves(state, 1)
+	win := game.Position{Row: 4, Col: 4}
+	if len(chosen) != 1 || chosen[0].Position != win {
+		t.Fatalf("Expected the base capture, got %v", chosen)
+	}
+
+	best, _ := mcts.tree.stats(game.Move{Position: win})
+	for _, move := range state.Board.GetValidMoves(1) {
+		if visits, _ := mcts.tree.stats(move); move.Position != win && visits >= best {
+			t.Errorf("Move %v got %d visits, the base capture only %d", move.Position, visits, best)
+		}
+	}
+}
+
+func TestSearchesChooseNeutralPlacement(t *testing.T) {
+	// Nothing is left to grow into or attack, but our two plain cells can
+	// become neutrals
+	cells := [][]int{{17, 34, 33}, {33, 34, 34}, {1, 1, 18}}
+	board := game.NewBoard(3)
+	for row, line := range cells {
+		for col, cell := range line {
+			board.SetCell(game.Position{Row: row, Col: col}, protocol.CellType(cell))
+		}
+	}
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.BasePos[2] = game.Position{Row: 2, Col: 2}
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+	want := game.Move{Position: game.Position{Row: 2, Col: 0}, Type: game.MoveNeutral, FromCell: game.Position{Row: 2, Col: 0}, Pair: game.Position{Row: 2, Col: 1}}
+
+	cfg := &config.Config{MCTSIterations: 50, MCTSTimeLimit: time.Second, MinimaxDepth: 2, WeightTerritory: 1.0}
+	for _, name := range []string{"mcts", "minimax"} {
+		s, _ := NewStrategyByName(name, cfg)
+		if moves := s.DecideMoves(state, 3); len(moves) != 1 || moves[0] != want {
+			t.Errorf("Expected %s to place neutrals alone, got %v", name, moves)
+		}
+	}
+
+	// A neutral placement is played alone or not at all
+	grow := game.Move{Position: game.Position{Row: 1, Col: 1}}
+	if turn := turnOf([]game.Move{grow, want, grow}, 3); len(turn) != 2 || turn[0] != grow || turn[1] != grow {
+		t.Errorf("Expected the neutral placement behind a grow to be skipped, got %v", turn)
+	}
+	if turn := turnOf([]game.Move{want, grow}, 3); len(turn) != 1 || turn[0] != want {
+		t.Errorf("Expected a leading neutral placement to be the whole turn, got %v", turn)
+	}
+}
+
+func TestMinimaxEliminatesOpponent(t *testing.T) {
+	board := game.NewBoard(6)
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.SetCell(game.Position{Row: 0, Col: 0}, protocol.CellType(1|int(protocol.CellFlagBase)))
+	for _, pos := range []game.Position{{Row: 1, Col: 1}, {Row: 2, Col: 2}, {Row: 3, Col: 3}} {
+		board.SetCell(pos, protocol.CellPlayer1)
+	}
+	// The opponent lost its base and is down to two cells next to our chain
+	remaining := []game.Position{{Row: 3, Col: 4}, {Row: 4, Col: 4}}
+	for _, pos := range remaining {
+		board.SetCell(pos, protocol.CellPlayer2)
+	}
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, game.Position{Row: 0, Col: 0}),
+			{ID: 2, Name: "Opponent", Symbol: protocol.CellPlayer2, Cells: remaining, IsAlive: true},
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	strategy, ok := NewStrategyByName("minimax", &config.Config{
+		MinimaxDepth:       2,
+		WeightTerritory:    1.0,
+		WeightConnectivity: 0.3,
+		WeightThreatDecay:  5.0,
+	})
+	if !ok {
+		t.Fatal("Expected minimax to be registered")
+	}
+	moves := strategy.DecideMoves(state, 3)
+	if len(moves) == 0 {
+		t.Fatal("Expected moves")
+	}
+
+	after := board
+	for _, move := range moves {
+		if !game.ValidMove(after, 1, move) {
+			t.Fatalf("Move %v is not legal", move)
+		}
+		after = after.ApplyMove(move.Position, 1, move.Fortifies())
+	}
+	if cells := after.CountCells(2); cells != 0 {
+		t.Errorf("Expected %v to eliminate the opponent, %d cells left", moves, cells)
+	}
+}
+
+func TestMinimaxEvaluatesMobility(t *testing.T) {
+	stateFor := func(boxed bool) *game.GameState {
+		board := game.NewBoard(6)
+		board.BasePos[1] = game.Position{Row: 0, Col: 0}
+		board.BasePos[2] = game.Position{Row: 5, Col: 5}
+		board.SetCell(board.BasePos[1], protocol.CellType(1|int(protocol.CellFlagBase)))
+		board.SetCell(board.BasePos[2], protocol.CellType(2|int(protocol.CellFlagBase)))
+		if boxed {
+			// Neutral walls leave our base without a single target
+			for _, pos := range []game.Position{{Row: 0, Col: 1}, {Row: 1, Col: 0}, {Row: 1, Col: 1}} {
+				board.SetCell(pos, protocol.CellNeutral)
+			}
+		}
+		return &game.GameState{
+			Board: board,
+			Players: []*game.Player{
+				game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+				game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+			},
+			CurrentPlayer: 1,
+			YourPlayerID:  1,
+		}
+	}
+	open, boxed := stateFor(false), stateFor(true)
+
+	territory := NewMinimaxStrategy(&config.Config{WeightTerritory: 1.0})
+	if territory.evaluate(open, 1) != territory.evaluate(boxed, 1) {
+		t.Error("Expected territory alone not to tell the positions apart")
+	}
+	mobile := NewMinimaxStrategy(&config.Config{WeightTerritory: 1.0, WeightMobility: 1.0})
+	if mobile.evaluate(open, 1) <= mobile.evaluate(boxed, 1) {
+		t.Error("Expected the mobility weight to favor the open position")
+	}
+}
+
+func TestNeutralPlacementFollowsWeights(t *testing.T) {
+	// Our cells line the top edge from the corner; the opponent is far away
+	board := game.NewBoard(6)
+	board.BasePos[1] = game.Position{Row: 0, Col: 2}
+	board.BasePos[2] = game.Position{Row: 5, Col: 5}
+	board.SetCell(board.BasePos[1], protocol.CellType(1|int(protocol.CellFlagBase)))
+	board.SetCell(board.BasePos[2], protocol.CellType(2|int(protocol.CellFlagBase)))
+	for _, pos := range []game.Position{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 3}, {Row: 0, Col: 4}} {
+		board.SetCell(pos, protocol.CellPlayer1)
+	}
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+	corner := game.Position{Row: 0, Col: 0}
+
+	// With the default weights the corner's bonus loses to open cells
+	cfg := &config.Config{WeightStrategic: 0.5, WeightConnectivity: 0.3, WeightExpansion: 0.4, WeightDefensive: 0.2}
+	plain := NewMCTSStrategy(cfg).DecideNeutrals(state)
+	if len(plain) != 2 || plain[0] == corner {
+		t.Fatalf("Expected an open cell first with the default weights, got %v", plain)
+	}
+
+	// Weighting position heavily puts the corner first, for MCTS as well
+	cfg.WeightStrategic = 5
+	if got := NewHeuristicStrategy(cfg).DecideNeutrals(state); len(got) != 2 || got[0] != corner {
+		t.Errorf("Expected the corner first with a heavy position weight, got %v", got)
+	}
+	if got := NewMCTSStrategy(cfg).DecideNeutrals(state); len(got) != 2 || got[0] != corner {
+		t.Errorf("Expected MCTS to place on the corner first as well, got %v", got)
+	}
+}
+
+func TestSetFactorsChangesLaterScores(t *testing.T) {
+	board := game.NewBoard(6)
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.BasePos[2] = game.Position{Row: 5, Col: 5}
+	board.SetCell(board.BasePos[1], protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(board.BasePos[2], protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	board.SetCell(game.Position{Row: 1, Col: 1}, protocol.CellPlayer2)
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+	attack := game.Move{Position: game.Position{Row: 1, Col: 1}, Type: game.MoveAttack, FromCell: board.BasePos[1]}
+
+	s := NewHeuristicStrategy(&config.Config{WeightTerritory: 1, WeightThreat: 1})
+	before := s.evaluateMove(attack, state, 1)
+
+	factors := s.Factors()
+	factors.ThreatRemoval = 3
+	s.SetFactors(factors)
+	after := s.evaluateMove(attack, state, 1)
+	if after-before != 30 {
+		t.Errorf("Expected tripling the attack weight to add 30, got %.2f -> %.2f", before, after)
+	}
+
+	path := filepath.Join(t.TempDir(), "weights.json")
+	if err := os.WriteFile(path, []byte(`{"threat": 0.5}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFactors(path, factors)
+	if err != nil {
+		t.Fatalf("LoadFactors failed: %v", err)
+	}
+	if loaded.ThreatRemoval != 0.5 || loaded.TerritoryGain != 1 {
+		t.Errorf("Expected the file's threat weight and the base territory weight, got %+v", loaded)
+	}
+	if err := os.WriteFile(path, []byte(`{"treat": 2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFactors(path, factors); err == nil {
+		t.Error("Expected an unknown weight name to be rejected")
+	}
+}
+
+func TestHeuristicPrefersBaseCapture(t *testing.T) {
+	board := game.NewBoard(6)
+	board.Rules.BasesAttackable = true
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.BasePos[2] = game.Position{Row: 3, Col: 3}
+	board.SetCell(board.BasePos[1], protocol.CellType(1|int(protocol.CellFlagBase)))
+	board.SetCell(board.BasePos[2], protocol.CellType(2|int(protocol.CellFlagBase)))
+	board.SetCell(game.Position{Row: 1, Col: 1}, protocol.CellPlayer1)
+	board.SetCell(game.Position{Row: 2, Col: 2}, protocol.CellPlayer1)
+	// A normal opponent cell our chain can attack as well
+	board.SetCell(game.Position{Row: 2, Col: 3}, protocol.CellPlayer2)
+	board.SetCell(game.Position{Row: 3, Col: 4}, protocol.CellPlayer2)
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+	if !board.IsBaseCell(board.BasePos[2]) || board.IsBaseCell(game.Position{Row: 2, Col: 3}) {
+		t.Fatal("Expected only the base position to be a base cell")
+	}
+
+	s := NewHeuristicStrategy(&config.Config{WeightTerritory: 1, WeightThreat: 1.5, WeightThreatDecay: 5})
+	moves := s.DecideMoves(state, 1)
+	if len(moves) != 1 || moves[0].Position != board.BasePos[2] {
+		t.Fatalf("Expected the base capture at %v, got %v", board.BasePos[2], moves)
+	}
+}
+
+func TestNeutralSealsCorridor(t *testing.T) {
+	board := game.NewBoard(7)
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.BasePos[2] = game.Position{Row: 6, Col: 6}
+	board.SetCell(board.BasePos[1], protocol.CellType(1|int(protocol.CellFlagBase)))
+	board.SetCell(board.BasePos[2], protocol.CellType(2|int(protocol.CellFlagBase)))
+	// A wall across row 3 whose only gap is our cell at (3,3)
+	for col := 0; col < 7; col++ {
+		if col != 3 {
+			board.SetCell(game.Position{Row: 3, Col: col}, protocol.CellNeutral)
+		}
+	}
+	gap := game.Position{Row: 3, Col: 3}
+	for _, pos := range []game.Position{{Row: 0, Col: 1}, {Row: 1, Col: 1}, {Row: 2, Col: 2}, gap} {
+		board.SetCell(pos, protocol.CellPlayer1)
+	}
+	for _, pos := range []game.Position{{Row: 5, Col: 5}, {Row: 4, Col: 4}} {
+		board.SetCell(pos, protocol.CellPlayer2)
+	}
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	s := NewHeuristicStrategy(&config.Config{WeightTerritory: 1, WeightConnectivity: 0.3})
+	if cut := s.chokepointValue(game.Position{Row: 2, Col: 2}, state, 1); cut != 1 {
+		t.Errorf("Expected an open-field neutral to cut only its own cell, got %.0f", cut)
+	}
+	neutrals := s.DecideNeutrals(state)
+	if len(neutrals) == 0 || neutrals[0] != gap {
+		t.Fatalf("Expected the corridor cell %v first, got %v", gap, neutrals)
+	}
+}
+
+func TestGreedyIsDeterministic(t *testing.T) {
+	state := createGeneratedState(10, 0.3, 7)
+	s := NewGreedyStrategy(&config.Config{})
+
+	first := s.DecideMoves(state, 3)
+	if len(first) != 3 {
+		t.Fatalf("Expected 3 moves, got %v", first)
+	}
+	for i := 0; i < 5; i++ {
+		again := s.Clone().DecideMoves(state.Clone(), 3)
+		if !reflect.DeepEqual(first, again) {
+			t.Fatalf("Expected the same moves %v on the same state, got %v", first, again)
+		}
+	}
+
+	board := state.Board
+	for _, move := range first {
+		if !game.ValidMove(board, 1, move) {
+			t.Errorf("Greedy returned invalid move %v", move)
+		}
+		board = board.ApplyMove(move.Position, 1, move.Fortifies())
+	}
+}
+
+func TestMCTSStaysWithinTurnBudget(t *testing.T) {
+	mcts := NewMCTSStrategy(&config.Config{MCTSIterations: 1 << 30, MCTSTimeLimit: time.Minute})
+	state := createGeneratedState(16, 0.3, 5)
+	// A neutral placement would be a turn of its own
+	state.GetYourPlayer().HasUsedNeutrals = true
+	budget := 300 * time.Millisecond
+
+	// The bot decides the whole turn in one call
+	start := time.Now()
+	if moves := mcts.DecideMovesWithin(state, 3, budget); len(moves) != 3 {
+		t.Fatalf("Expected 3 moves, got %v", moves)
+	}
+	elapsed := time.Since(start)
+	if elapsed >= budget {
+		t.Errorf("Expected the turn to take under %s, took %s", budget, elapsed)
+	}
+	if elapsed < budget/2 {
+		t.Errorf("Expected the turn to use most of its %s budget, took %s", budget, elapsed)
+	}
+}
+
+func TestMCTSRolloutScoresStalemateAsDraw(t *testing.T) {
+	// Both bases are walled in by neutrals, player 1 with a cell more
+	board := game.NewBoard(3)
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			board.SetCell(game.Position{Row: row, Col: col}, protocol.CellNeutral)
+		}
+	}
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.BasePos[2] = game.Position{Row: 2, Col: 2}
+	board.SetCell(board.BasePos[1], protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(board.BasePos[2], protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	board.SetCell(game.Position{Row: 0, Col: 1}, protocol.CellPlayer1)
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+
+	mcts := NewMCTSStrategy(&config.Config{MCTSIterations: 10})
+	if score := mcts.rollout(state, 1, rand.New(rand.NewSource(1))); score != 0.5 {
+		t.Errorf("Expected a stalemate to score 0.5, got %.2f", score)
+	}
+}
+
+func TestMCTSWorkersMergeTheirTrees(t *testing.T) {
+	mcts := NewMCTSStrategy(&config.Config{MCTSIterations: 300, MCTSTimeLimit: time.Minute, MCTSWorkers: 3})
+	state := createGeneratedState(10, 0.2, 2)
+	moves := state.Board.GetValidMoves(1)
+
+	trees, iterations := mcts.search(state, moves, time.Now().Add(time.Minute))
+	if len(trees) != 3 || trees[0] != mcts.tree {
+		t.Fatalf("Expected the kept tree and two more, got %d trees", len(trees))
+	}
+	visits := 0
+	for _, tree := range trees {
+		visits += tree.rootVisits()
+	}
+	if iterations != 300 || visits != 300 {
+		t.Errorf("Expected 300 iterations over all trees, ran %d with %d root visits", iterations, visits)
+	}
+	if chosen := mcts.selectBestMoves(state, moves, 3, trees); len(chosen) != 3 {
+		t.Errorf("Expected 3 moves from the merged trees, got %v", chosen)
+	}
+}
+
+func TestMCTSFollowsTheMostVisitedPath(t *testing.T) {
+	board := game.NewBoard(5)
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	board.BasePos[2] = game.Position{Row: 4, Col: 4}
+	board.SetCell(game.Position{Row: 0, Col: 0}, protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	board.SetCell(game.Position{Row: 4, Col: 4}, protocol.CellType(int(protocol.CellPlayer2)|int(protocol.CellFlagBase)))
+	state := &game.GameState{
+		Board: board,
+		Players: []*game.Player{
+			game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1]),
+			game.NewPlayer(2, "Opponent", protocol.CellPlayer2, board.BasePos[2]),
+		},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+		MovesLeft:     2,
+	}
+	first := game.Move{Position: game.Position{Row: 1, Col: 1}}
+	sibling := game.Move{Position: game.Position{Row: 0, Col: 1}}
+	followUp := game.Move{Position: game.Position{Row: 2, Col: 2}}
+
+	// The sibling is the second most visited first move, but after the
+	// chosen one the search preferred a cell only it makes reachable
+	tree := newSearchTree()
+	for i := 0; i < 3; i++ {
+		tree.record([]game.Move{first, followUp}, 1)
+	}
+	tree.record([]game.Move{first, sibling}, 0)
+	for i := 0; i < 2; i++ {
+		tree.record([]game.Move{sibling, first}, 0)
+	}
+
+	mcts := NewMCTSStrategy(&config.Config{})
+	moves := board.GetValidMoves(1)
+	chosen := mcts.selectBestMoves(state, moves, 2, []*searchTree{tree})
+	if len(chosen) != 2 || chosen[0].Position != first.Position || chosen[1].Position != followUp.Position {
+		t.Errorf("Expected %v then %v, got %v", first.Position, followUp.Position, chosen)
+	}
+}
+
+func TestMCTSWorkersScaleIterations(t *testing.T) {
+	workers := min(runtime.NumCPU(), 4)
+	if workers < 2 {
+		t.Skip("needs more than one CPU")
+	}
+	state := createGeneratedState(10, 0.2, 2)
+	moves := state.Board.GetValidMoves(1)
+	run := func(workers int) int {
+		mcts := NewMCTSStrategy(&config.Config{MCTSIterations: 1 << 30, MCTSWorkers: workers})
+		_, iterations := mcts.search(state, moves, time.Now().Add(200*time.Millisecond))
+		return iterations
+	}
+
+	single, parallel := run(1), run(workers)
+	if parallel < single*workers/2 {
+		t.Errorf("Expected %d workers to run about %d times the %d iterations of one, ran %d", workers, workers, single, parallel)
+	}
+}
+
+func TestSecondCrossingOverBridgeHasDefensiveValue(t *testing.T) {
+	// Player 1 holds rows 0-1 and 3-4, joined only by the bridge at (2,2)
+	board := game.NewBoard(5)
+	board.BasePos[1] = game.Position{Row: 0, Col: 0}
+	for row := 0; row < 5; row++ {
+		for col := 0; col < 5; col++ {
+			if row != 2 || col == 2 {
+				board.SetCell(game.Position{Row: row, Col: col}, protocol.CellPlayer1)
+			}
+		}
+	}
+	board.SetCell(board.BasePos[1], protocol.CellType(int(protocol.CellPlayer1)|int(protocol.CellFlagBase)))
+	state := &game.GameState{
+		Board:         board,
+		Players:       []*game.Player{game.NewPlayer(1, "Bot", protocol.CellPlayer1, board.BasePos[1])},
+		CurrentPlayer: 1,
+		YourPlayerID:  1,
+	}
+	s := NewHeuristicStrategy(&config.Config{WeightDefensive: 1.0})
+	defensive := func(move game.Move) float64 {
+		f := board.AnnotateMoves([]game.Move{move}, 1)[0]
+		return s.scoreFeatures(f, state, 1, state.Phase())
+	}
+
+	crossing := game.Move{Position: game.Position{Row: 2, Col: 0}, Type: game.MoveGrow}
+	if score := defensive(crossing); score != 2.0 {
+		t.Errorf("Expected a second crossing next to the bridge to have defensive value, scored %.2f", score)
+	}
+
+	// Once crossed twice, another crossing shores up nothing
+	board.SetCell(crossing.Position, protocol.CellPlayer1)
+	third := game.Move{Position: game.Position{Row: 2, Col: 4}, Type: game.MoveGrow}
+	if score := defensive(third); score != 0 {
+		t.Errorf("Expected no defensive value without a bridge to shore up, scored %.2f", score)
+	}
+}
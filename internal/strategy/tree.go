@@ -0,0 +1,162 @@
+package strategy
+
+import (
+	"sync"
+
+	"virusbot/internal/game"
+)
+
+// searchNode holds the playout statistics of one move sequence. Wins are
+// counted from our point of view, whoever moves at the node.
+type searchNode struct {
+	move     game.Move // the move leading here from the parent
+	visits   int
+	wins     float64
+	children map[moveKey]*searchNode
+	untried  []game.Move // legal moves without a child yet, once expanded
+	expanded bool        // untried has been filled in
+}
+
+// newSearchNode creates a node without statistics
+func newSearchNode() *searchNode {
+	return &searchNode{children: make(map[moveKey]*searchNode)}
+}
+
+// moveKey identifies a move among its siblings. A grow or attack is told
+// apart by its target alone; neutral placements sharing a first cell differ
+// in their second.
+type moveKey struct {
+	pos, pair game.Position
+}
+
+// keyOf returns the key of move
+func keyOf(move game.Move) moveKey {
+	return moveKey{pos: move.Position, pair: move.Pair}
+}
+
+// searchTree is the MCTS tree rooted at the current position. It can be
+// kept across turns by re-rooting it at each move actually played.
+type searchTree struct {
+	mu   sync.Mutex
+	root *searchNode
+}
+
+// newSearchTree creates an empty tree
+func newSearchTree() *searchTree {
+	return &searchTree{root: newSearchNode()}
+}
+
+// record backs a playout result up every node along the path of moves from
+// the root, creating the nodes that do not exist yet
+func (t *searchTree) record(path []game.Move, score float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	node := t.root
+	node.visits++
+	node.wins += score
+	for _, move := range path {
+		child, ok := node.children[keyOf(move)]
+		if !ok {
+			child = newSearchNode()
+			child.move = move
+			node.children[keyOf(move)] = child
+		}
+		child.visits++
+		child.wins += score
+		node = child
+	}
+}
+
+// stats returns the visits and wins of the root child for move
+func (t *searchTree) stats(move game.Move) (int, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	child, ok := t.root.children[keyOf(move)]
+	if !ok {
+		return 0, 0
+	}
+	return child.visits, child.wins
+}
+
+// childStat is a copy of a child's statistics, safe to read unlocked
+type childStat struct {
+	move   game.Move
+	visits int
+	wins   float64
+}
+
+// children returns the statistics of the children of the node reached by
+// following path from the root, or none when the path was never explored
+func (t *searchTree) children(path []game.Move) []childStat {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	node := t.root
+	for _, move := range path {
+		child, ok := node.children[keyOf(move)]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+	stats := make([]childStat, 0, len(node.children))
+	for _, child := range node.children {
+		stats = append(stats, childStat{move: child.move, visits: child.visits, wins: child.wins})
+	}
+	return stats
+}
+
+// prepareRoot makes moves the root's choices: children kept from an earlier
+// search for other moves are dropped, and moves without a child are left to
+// expand
+func (t *searchTree) prepareRoot(moves []game.Move) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	legal := make(map[moveKey]bool, len(moves))
+	untried := make([]game.Move, 0, len(moves))
+	for _, move := range moves {
+		legal[keyOf(move)] = true
+		if _, ok := t.root.children[keyOf(move)]; !ok {
+			untried = append(untried, move)
+		}
+	}
+	for key := range t.root.children {
+		if !legal[key] {
+			delete(t.root.children, key)
+		}
+	}
+	t.root.untried = untried
+	t.root.expanded = true
+}
+
+// advance re-roots the tree at the child for a move that was played,
+// keeping its statistics. It reports false and starts an empty tree when
+// the move was never explored.
+func (t *searchTree) advance(move game.Move) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if child, ok := t.root.children[keyOf(move)]; ok {
+		t.root = child
+		return true
+	}
+	t.root = newSearchNode()
+	return false
+}
+
+// clear discards every statistic
+func (t *searchTree) clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.root = newSearchNode()
+}
+
+// rootVisits returns the number of playouts recorded at the root
+func (t *searchTree) rootVisits() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.root.visits
+}